@@ -77,6 +77,19 @@ func TestGetAPIEventsFilterInput_formatFilters(t *testing.T) {
 				jsonapi.QueryParamPageNumber: "2",
 			},
 		},
+		{
+			description: "filters on the creation time range",
+			filters: GetAPIEventsFilterInput{
+				CreatedAfter:  "2026-08-12T15:00:00Z",
+				CreatedBefore: "2026-08-13T15:00:00Z",
+				ServiceID:     "5343548168357658",
+			},
+			expected: map[string]string{
+				"filter[created_at][gte]": "2026-08-12T15:00:00Z",
+				"filter[created_at][lte]": "2026-08-13T15:00:00Z",
+				"filter[service_id]":      "5343548168357658",
+			},
+		},
 	}
 	for _, testcase := range tests {
 		answer := testcase.filters.formatEventFilters()
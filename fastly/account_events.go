@@ -30,6 +30,12 @@ type Event struct {
 
 // GetAPIEventsFilterInput is used as input to the GetAPIEvents function.
 type GetAPIEventsFilterInput struct {
+	// CreatedAfter limits the returned events to those created at or after
+	// this RFC3339 timestamp.
+	CreatedAfter string
+	// CreatedBefore limits the returned events to those created at or before
+	// this RFC3339 timestamp.
+	CreatedBefore string
 	// CustomerID to Limit the returned events to a specific customer.
 	CustomerID string
 	// EventType to limit the returned events to a specific event type. See above for event codes.
@@ -176,6 +182,8 @@ func getEventsPages(body io.Reader) (EventsPaginationInfo, io.Reader, error) {
 func (i *GetAPIEventsFilterInput) formatEventFilters() map[string]string {
 	result := map[string]string{}
 	pairings := map[string]any{
+		"filter[created_at][gte]":    i.CreatedAfter,
+		"filter[created_at][lte]":    i.CreatedBefore,
 		"filter[customer_id]":        i.CustomerID,
 		"filter[service_id]":         i.ServiceID,
 		"filter[event_type]":         i.EventType,
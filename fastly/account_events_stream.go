@@ -0,0 +1,345 @@
+package fastly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fastly/go-fastly/v17/internal/ctxutil"
+)
+
+// DefaultEventStreamPollInterval is the default delay between two polls of
+// StreamAPIEvents.
+const DefaultEventStreamPollInterval = time.Minute
+
+// EventCheckpoint records the position of an event stream, so that it can
+// resume without skipping or repeating events.
+type EventCheckpoint struct {
+	// CreatedAt is the creation time of the most recent event delivered.
+	CreatedAt time.Time `json:"created_at"`
+	// IDs are the IDs of the events delivered that were created at CreatedAt.
+	IDs []string `json:"ids"`
+}
+
+// delivered reports whether an event is at or before the checkpoint.
+func (cp *EventCheckpoint) delivered(ev *Event) bool {
+	switch {
+	case ev.CreatedAt.Before(cp.CreatedAt):
+		return true
+	case ev.CreatedAt.Equal(cp.CreatedAt):
+		return slices.Contains(cp.IDs, ev.ID)
+	default:
+		return false
+	}
+}
+
+// advance moves the checkpoint past an event.
+func (cp *EventCheckpoint) advance(ev *Event) {
+	if ev.CreatedAt.After(cp.CreatedAt) {
+		cp.CreatedAt = *ev.CreatedAt
+		cp.IDs = nil
+	}
+	cp.IDs = append(cp.IDs, ev.ID)
+}
+
+// EventCheckpointStore persists the checkpoint of an event stream.
+type EventCheckpointStore interface {
+	// Load returns the saved checkpoint, or nil if there is none.
+	Load(ctx context.Context) (*EventCheckpoint, error)
+	// Save replaces the saved checkpoint.
+	Save(ctx context.Context, cp *EventCheckpoint) error
+}
+
+// FileEventCheckpointStore stores a checkpoint as a JSON file.
+type FileEventCheckpointStore struct {
+	// Path is the path of the file.
+	Path string
+}
+
+// Load implements EventCheckpointStore. A missing file means there is no
+// checkpoint.
+func (s *FileEventCheckpointStore) Load(_ context.Context) (*EventCheckpoint, error) {
+	// #nosec G304 -- the checkpoint path is chosen by the caller
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp EventCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("reading checkpoint %s: %w", s.Path, err)
+	}
+	return &cp, nil
+}
+
+// Save implements EventCheckpointStore. The file is replaced atomically, so a
+// crash cannot leave a partial checkpoint.
+func (s *FileEventCheckpointStore) Save(_ context.Context, cp *EventCheckpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.Path, data)
+}
+
+// EventSink receives the events of a stream.
+type EventSink interface {
+	// WriteEvent delivers one event. An error stops the stream.
+	WriteEvent(ctx context.Context, ev *Event) error
+}
+
+// EventSinkFunc adapts a function to the EventSink interface.
+type EventSinkFunc func(ctx context.Context, ev *Event) error
+
+// WriteEvent implements EventSink.
+func (f EventSinkFunc) WriteEvent(ctx context.Context, ev *Event) error {
+	return f(ctx, ev)
+}
+
+// ChannelEventSink returns a sink that sends events on ch, blocking until
+// they are received or the context is cancelled.
+func ChannelEventSink(ch chan<- *Event) EventSink {
+	return EventSinkFunc(func(ctx context.Context, ev *Event) error {
+		if !ctxutil.Send(ctx, ch, ev) {
+			return ctx.Err()
+		}
+		return nil
+	})
+}
+
+// NDJSONEventSink returns a sink that writes each event to w as a line of
+// JSON, using the attribute names of the API.
+func NDJSONEventSink(w io.Writer) EventSink {
+	enc := json.NewEncoder(w)
+	return EventSinkFunc(func(_ context.Context, ev *Event) error {
+		return enc.Encode(eventJSON{
+			Admin:       ev.Admin,
+			CreatedAt:   ev.CreatedAt,
+			CustomerID:  ev.CustomerID,
+			Description: ev.Description,
+			EventType:   ev.EventType,
+			ID:          ev.ID,
+			IP:          ev.IP,
+			Metadata:    ev.Metadata,
+			ServiceID:   ev.ServiceID,
+			UserID:      ev.UserID,
+		})
+	})
+}
+
+// eventJSON is the NDJSON form of an Event.
+type eventJSON struct {
+	Admin       bool           `json:"admin"`
+	CreatedAt   *time.Time     `json:"created_at"`
+	CustomerID  string         `json:"customer_id"`
+	Description string         `json:"description"`
+	EventType   string         `json:"event_type"`
+	ID          string         `json:"id"`
+	IP          string         `json:"ip"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+	ServiceID   string         `json:"service_id"`
+	UserID      string         `json:"user_id"`
+}
+
+// CEFEventSink returns a sink that writes each event to w as a line in the
+// ArcSight Common Event Format. The signature ID is the event type and the
+// severity is 6 for events performed by Fastly admins and 3 otherwise.
+func CEFEventSink(w io.Writer) EventSink {
+	return EventSinkFunc(func(_ context.Context, ev *Event) error {
+		_, err := io.WriteString(w, formatCEFEvent(ev)+"\n")
+		return err
+	})
+}
+
+// formatCEFEvent formats an event as a CEF line, without the trailing
+// newline.
+func formatCEFEvent(ev *Event) string {
+	severity := 3
+	if ev.Admin {
+		severity = 6
+	}
+	name := ev.Description
+	if name == "" {
+		name = ev.EventType
+	}
+
+	var ext []string
+	add := func(key, value string) {
+		if value != "" {
+			ext = append(ext, key+"="+escapeCEFExtension(value))
+		}
+	}
+	if ev.CreatedAt != nil {
+		add("rt", fmt.Sprint(ev.CreatedAt.UnixMilli()))
+	}
+	add("externalId", ev.ID)
+	add("suser", ev.UserID)
+	add("src", ev.IP)
+	if ev.CustomerID != "" {
+		add("cs1Label", "customerId")
+		add("cs1", ev.CustomerID)
+	}
+	if ev.ServiceID != "" {
+		add("cs2Label", "serviceId")
+		add("cs2", ev.ServiceID)
+	}
+
+	return fmt.Sprintf("CEF:0|Fastly|Fastly API|%s|%s|%s|%d|%s",
+		escapeCEFHeader(ProjectVersion), escapeCEFHeader(ev.EventType), escapeCEFHeader(name), severity, strings.Join(ext, " "))
+}
+
+// escapeCEFHeader escapes a CEF header field.
+func escapeCEFHeader(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r", " ", "\n", " ").Replace(s)
+}
+
+// escapeCEFExtension escapes a CEF extension value.
+func escapeCEFExtension(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\r", `\r`, "\n", `\n`).Replace(s)
+}
+
+// StreamAPIEventsInput is used as input to the StreamAPIEvents function.
+type StreamAPIEventsInput struct {
+	// Checkpoint persists the position of the stream. Without it, the stream
+	// starts at From and does not survive a restart.
+	Checkpoint EventCheckpointStore
+	// EventTypes limits the stream to events of these types. All types are
+	// delivered when empty.
+	EventTypes []string
+	// Filter is sent with each request to filter events server-side. Its
+	// CreatedAfter, MaxResults and PageNumber fields are managed by the
+	// stream.
+	Filter GetAPIEventsFilterInput
+	// From skips events created before this time. It is also where the stream
+	// starts when there is no checkpoint (default: now).
+	From time.Time
+	// Once stops the stream after a single poll.
+	Once bool
+	// PollInterval is the delay between two polls (default:
+	// DefaultEventStreamPollInterval).
+	PollInterval time.Duration
+	// Sink receives the events (required).
+	Sink EventSink
+	// To stops the stream once the events up to this time have been delivered.
+	// The stream runs until the context is cancelled when zero.
+	To time.Time
+}
+
+// StreamAPIEvents polls for new account events and delivers them to a sink
+// in creation order.
+//
+// Each poll requests the events created since the checkpoint, following all
+// pages. Events already delivered, including duplicates returned by
+// overlapping pages, are skipped, and the checkpoint is saved after each poll
+// whose events were all delivered, so delivery is at least once across
+// restarts. Events that do not match EventTypes, From or To still advance the
+// checkpoint.
+//
+// Retryable API errors are retried at the next poll. StreamAPIEvents returns
+// nil once To is reached or after a single poll with Once, and the context
+// error when it is cancelled.
+func (c *Client) StreamAPIEvents(ctx context.Context, i *StreamAPIEventsInput) error {
+	if i.Sink == nil {
+		return ErrMissingSink
+	}
+	interval := i.PollInterval
+	if interval <= 0 {
+		interval = DefaultEventStreamPollInterval
+	}
+
+	var cp *EventCheckpoint
+	if i.Checkpoint != nil {
+		loaded, err := i.Checkpoint.Load(ctx)
+		if err != nil {
+			return err
+		}
+		cp = loaded
+	}
+	if cp == nil {
+		start := i.From
+		if start.IsZero() {
+			start = time.Now()
+		}
+		cp = &EventCheckpoint{CreatedAt: start}
+	}
+
+	for {
+		polled := time.Now()
+		filter := i.Filter
+		filter.CreatedAfter = cp.CreatedAt.UTC().Truncate(time.Second).Format(time.RFC3339)
+		filter.MaxResults = 0
+		filter.PageNumber = 0
+
+		resp, err := c.GetAPIEvents(ctx, &filter)
+		var herr *HTTPError
+		switch {
+		case err == nil:
+			if err := deliverAPIEvents(ctx, i, cp, resp.Events); err != nil {
+				return err
+			}
+			if i.Checkpoint != nil {
+				if err := i.Checkpoint.Save(ctx, cp); err != nil {
+					return err
+				}
+			}
+		case !errors.As(err, &herr) || !herr.IsRetryable():
+			return err
+		}
+		if i.Once || (!i.To.IsZero() && !polled.Before(i.To)) {
+			return nil
+		}
+		if !ctxutil.Sleep(ctx, interval) {
+			return ctx.Err()
+		}
+	}
+}
+
+// deliverAPIEvents sends the undelivered events of a poll to the sink in
+// creation order, then ID order, advancing the checkpoint past each.
+func deliverAPIEvents(ctx context.Context, i *StreamAPIEventsInput, cp *EventCheckpoint, events []*Event) error {
+	events = slices.DeleteFunc(slices.Clone(events), func(ev *Event) bool {
+		return ev == nil || ev.CreatedAt == nil
+	})
+	sort.SliceStable(events, func(a, b int) bool {
+		if !events[a].CreatedAt.Equal(*events[b].CreatedAt) {
+			return events[a].CreatedAt.Before(*events[b].CreatedAt)
+		}
+		return events[a].ID < events[b].ID
+	})
+
+	for _, ev := range events {
+		if cp.delivered(ev) {
+			continue
+		}
+		if matchesEventStream(i, ev) {
+			if err := i.Sink.WriteEvent(ctx, ev); err != nil {
+				return err
+			}
+		}
+		cp.advance(ev)
+	}
+	return nil
+}
+
+// matchesEventStream applies the client-side filters of a stream.
+func matchesEventStream(i *StreamAPIEventsInput, ev *Event) bool {
+	if len(i.EventTypes) > 0 && !slices.Contains(i.EventTypes, ev.EventType) {
+		return false
+	}
+	if !i.From.IsZero() && ev.CreatedAt.Before(i.From) {
+		return false
+	}
+	if !i.To.IsZero() && !ev.CreatedAt.Before(i.To) {
+		return false
+	}
+	return true
+}
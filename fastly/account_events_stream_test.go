@@ -0,0 +1,161 @@
+package fastly
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStreamAPIEvents_checkpoint(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	store := &FileEventCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+
+	var ids []string
+	input := &StreamAPIEventsInput{
+		Checkpoint: store,
+		EventTypes: []string{"version.activate"},
+		From:       base,
+		Once:       true,
+		Sink: EventSinkFunc(func(_ context.Context, ev *Event) error {
+			ids = append(ids, ev.ID)
+			return nil
+		}),
+	}
+
+	var first, second []string
+	// The first poll returns two pages, newest first, with an event repeated
+	// on both. A fourth event is created in the same second as the
+	// checkpoint before the second poll.
+	Record(t, "account_events_stream/checkpoint", func(c *Client) {
+		if err := c.StreamAPIEvents(context.TODO(), input); err != nil {
+			t.Fatal(err)
+		}
+		first, ids = ids, nil
+
+		cp, err := store.Load(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &EventCheckpoint{CreatedAt: base.Add(time.Second), IDs: []string{"4HcLq3VwNz5KtRb1XpMd6J", "6TbKw2NqVz8XcLp4RmJd1F"}}
+		if diff := cmp.Diff(want, cp); diff != "" {
+			t.Errorf("unexpected checkpoint (-want +got):\n%s", diff)
+		}
+
+		// Resuming from the checkpoint requests the events since its second
+		// and delivers only the new one.
+		if err := c.StreamAPIEvents(context.TODO(), input); err != nil {
+			t.Fatal(err)
+		}
+		second = ids
+	})
+
+	if diff := cmp.Diff([]string{"1mXzQ7pWc4RtLv9NbKd2Hs", "6TbKw2NqVz8XcLp4RmJd1F"}, first); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"8NvKz9WqLc2XbTp6RmHd3S"}, second); diff != "" {
+		t.Errorf("unexpected events after resume (-want +got):\n%s", diff)
+	}
+}
+
+func TestStreamAPIEvents_to(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	ch := make(chan *Event, 10)
+	var err error
+	// The poll returns an event created after To, which ends the stream
+	// without polling again.
+	Record(t, "account_events_stream/to", func(c *Client) {
+		err = c.StreamAPIEvents(context.TODO(), &StreamAPIEventsInput{
+			From:         base,
+			PollInterval: time.Millisecond,
+			Sink:         ChannelEventSink(ch),
+			To:           base.Add(time.Minute),
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+	var ids []string
+	for ev := range ch {
+		ids = append(ids, ev.ID)
+	}
+	if diff := cmp.Diff([]string{"1mXzQ7pWc4RtLv9NbKd2Hs"}, ids); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+func TestStreamAPIEvents_cancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var err error
+	// The sink cancels the stream, which stops waiting for the next poll.
+	Record(t, "account_events_stream/to", func(c *Client) {
+		err = c.StreamAPIEvents(ctx, &StreamAPIEventsInput{
+			From:         time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC),
+			PollInterval: time.Hour,
+			Sink: EventSinkFunc(func(context.Context, *Event) error {
+				cancel()
+				return nil
+			}),
+		})
+	})
+	if err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+
+	if err := TestClient.StreamAPIEvents(ctx, &StreamAPIEventsInput{}); err != ErrMissingSink {
+		t.Errorf("got %v, want ErrMissingSink", err)
+	}
+}
+
+func TestEventSinks(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	ev := &Event{
+		CreatedAt:   &at,
+		CustomerID:  "cust",
+		Description: "Activated | version=3",
+		EventType:   "version.activate",
+		ID:          "ev1",
+		IP:          "192.0.2.1",
+		Metadata:    map[string]any{"version": float64(3)},
+		ServiceID:   "svc",
+		UserID:      "alice",
+	}
+
+	var js bytes.Buffer
+	if err := NDJSONEventSink(&js).WriteEvent(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `{"admin":false,"created_at":"2026-08-12T15:00:00Z","customer_id":"cust","description":"Activated | version=3","event_type":"version.activate","id":"ev1","ip":"192.0.2.1","metadata":{"version":3},"service_id":"svc","user_id":"alice"}` + "\n"
+	if diff := cmp.Diff(wantJSON, js.String()); diff != "" {
+		t.Errorf("unexpected NDJSON (-want +got):\n%s", diff)
+	}
+
+	var cef bytes.Buffer
+	if err := CEFEventSink(&cef).WriteEvent(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	wantCEF := `CEF:0|Fastly|Fastly API|` + ProjectVersion + `|version.activate|Activated \| version=3|3|rt=1786546800000 externalId=ev1 suser=alice src=192.0.2.1 cs1Label=customerId cs1=cust cs2Label=serviceId cs2=svc` + "\n"
+	if diff := cmp.Diff(wantCEF, cef.String()); diff != "" {
+		t.Errorf("unexpected CEF (-want +got):\n%s", diff)
+	}
+	if got := escapeCEFExtension("a=b\\c\nd"); got != `a\=b\\c\nd` {
+		t.Errorf("unexpected escaping %q", got)
+	}
+	if strings.Contains(formatCEFEvent(&Event{Description: "x\ny"}), "\n") {
+		t.Error("CEF line contains a newline")
+	}
+}
@@ -0,0 +1,84 @@
+package fastly
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/fastly/go-fastly/v17/internal/cidr"
+)
+
+// ACLMatcher evaluates IP addresses against the entries of an ACL locally,
+// without calling the API.
+//
+// Matching mirrors the VCL `client.ip ~ acl` operator: the entry with the
+// longest prefix containing the address decides the result, and a negated
+// entry causes the address not to match. When two entries cover the same
+// network the negated one takes precedence.
+type ACLMatcher struct {
+	trie cidr.Trie[*ACLEntry]
+}
+
+// ACLMatch is the result of matching an address with an ACLMatcher.
+type ACLMatch struct {
+	// Addr is the address that was matched.
+	Addr netip.Addr
+	// Entry is the longest-prefix entry containing Addr, or nil if no entry
+	// contains it.
+	Entry *ACLEntry
+	// Matched indicates whether Addr matches the ACL, i.e. Entry is set and
+	// not negated.
+	Matched bool
+}
+
+// NewACLMatcher builds an ACLMatcher from a list of ACL entries.
+func NewACLMatcher(entries []*ACLEntry) (*ACLMatcher, error) {
+	m := &ACLMatcher{}
+	for _, e := range entries {
+		p, err := aclEntryPrefix(e)
+		if err != nil {
+			return nil, err
+		}
+		if existing, ok := m.trie.Get(p); ok && ToValue(existing.Negated) {
+			continue
+		}
+		m.trie.Insert(p, e)
+	}
+	return m, nil
+}
+
+// LoadACLMatcher retrieves all entries of an ACL and builds an ACLMatcher from
+// them.
+func (c *Client) LoadACLMatcher(ctx context.Context, i *ListACLEntriesInput) (*ACLMatcher, error) {
+	entries, err := c.ListACLEntries(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	return NewACLMatcher(entries)
+}
+
+// Len returns the number of distinct networks held by the matcher.
+func (m *ACLMatcher) Len() int {
+	return m.trie.Len()
+}
+
+// Match evaluates a single address.
+func (m *ACLMatcher) Match(addr netip.Addr) ACLMatch {
+	e, _, ok := m.trie.Lookup(addr)
+	if !ok {
+		return ACLMatch{Addr: addr}
+	}
+	return ACLMatch{
+		Addr:    addr,
+		Entry:   e,
+		Matched: !ToValue(e.Negated),
+	}
+}
+
+// MatchAll evaluates each address, returning the results in the same order.
+func (m *ACLMatcher) MatchAll(addrs []netip.Addr) []ACLMatch {
+	results := make([]ACLMatch, len(addrs))
+	for n, addr := range addrs {
+		results[n] = m.Match(addr)
+	}
+	return results
+}
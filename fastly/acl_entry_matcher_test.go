@@ -0,0 +1,59 @@
+package fastly
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestACLMatcher(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewACLMatcher([]*ACLEntry{
+		{EntryID: ToPointer("net"), IP: ToPointer("192.0.2.0"), Subnet: ToPointer(24)},
+		{EntryID: ToPointer("hole"), IP: ToPointer("192.0.2.64"), Subnet: ToPointer(26), Negated: ToPointer(true)},
+		{EntryID: ToPointer("host"), IP: ToPointer("192.0.2.65")},
+		{EntryID: ToPointer("v6"), IP: ToPointer("2001:db8::"), Subnet: ToPointer(32)},
+		{EntryID: ToPointer("dupe"), IP: ToPointer("198.51.100.0"), Subnet: ToPointer(24), Negated: ToPointer(true)},
+		{EntryID: ToPointer("dupe-positive"), IP: ToPointer("198.51.100.0"), Subnet: ToPointer(24)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 5 {
+		t.Errorf("expected 5 networks, got %d", m.Len())
+	}
+
+	tests := []struct {
+		addr    string
+		entry   string
+		matched bool
+	}{
+		{addr: "192.0.2.1", entry: "net", matched: true},
+		{addr: "192.0.2.100", entry: "hole", matched: false},
+		{addr: "192.0.2.65", entry: "host", matched: true},
+		{addr: "2001:db8:1::1", entry: "v6", matched: true},
+		{addr: "198.51.100.7", entry: "dupe", matched: false},
+		{addr: "203.0.113.1", matched: false},
+	}
+
+	addrs := make([]netip.Addr, 0, len(tests))
+	for _, tc := range tests {
+		addrs = append(addrs, netip.MustParseAddr(tc.addr))
+	}
+	for n, got := range m.MatchAll(addrs) {
+		tc := tests[n]
+		var entry string
+		if got.Entry != nil {
+			entry = ToValue(got.Entry.EntryID)
+		}
+		if entry != tc.entry || got.Matched != tc.matched {
+			t.Errorf("Match(%s) = %q, %t; want %q, %t", tc.addr, entry, got.Matched, tc.entry, tc.matched)
+		}
+	}
+
+	_, err = NewACLMatcher([]*ACLEntry{{IP: ToPointer("bogus")}})
+	if !errors.Is(err, ErrInvalidACLPrefix) {
+		t.Errorf("expected ErrInvalidACLPrefix, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package fastly
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+
+	"github.com/fastly/go-fastly/v17/internal/batch"
+	"github.com/fastly/go-fastly/v17/internal/cidr"
+)
+
+// SyncACLInput is the input to the SyncACL function.
+type SyncACLInput struct {
+	// ACLID is an alphanumeric string identifying a ACL (required).
+	ACLID string
+	// BatchSize is the maximum number of operations sent per batch request
+	// (default: BatchModifyMaximumOperations).
+	BatchSize *int
+	// Comment is a freeform descriptive note set on created entries.
+	Comment *string
+	// DryRun, if true, computes the changes without applying them.
+	DryRun bool
+	// MaxRetries is the number of times a failed batch request is retried
+	// (default: DefaultSyncMaxRetries).
+	MaxRetries *int
+	// NegatedPrefixes is the list of IPv4 or IPv6 prefixes excluded from the
+	// ACL. They only have an effect inside a broader entry of Prefixes.
+	NegatedPrefixes []netip.Prefix
+	// Prefixes is the list of IPv4 or IPv6 prefixes matched by the ACL.
+	Prefixes []netip.Prefix
+	// ServiceID is an alphanumeric string identifying the service (required).
+	ServiceID string
+}
+
+// SyncACL makes the entries of an ACL match the given prefixes using the
+// minimal number of create, update and delete operations.
+//
+// Overlapping and adjacent prefixes are collapsed first, so the ACL ends up
+// with the smallest set of entries that matches exactly the same addresses
+// under longest-prefix-match semantics. Existing entries are compared by the
+// network they cover, regardless of how their IP and subnet were written.
+// Deletes are sent before creates so the ACL stays within MaximumACLSize,
+// except where a created prefix replaces narrower entries: it is created
+// before they are deleted, so no address the ACL should match is ever left
+// unmatched between batches.
+func (c *Client) SyncACL(ctx context.Context, i *SyncACLInput) (*SyncReport, error) {
+	if i.ACLID == "" {
+		return nil, ErrMissingACLID
+	}
+	if i.ServiceID == "" {
+		return nil, ErrMissingServiceID
+	}
+	size, err := syncBatchSize(i.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	desired, err := collapseACLPrefixes(i.Prefixes, i.NegatedPrefixes)
+	if err != nil {
+		return nil, err
+	}
+	if len(desired) > MaximumACLSize {
+		return nil, ErrMaxExceededACLSize
+	}
+
+	entries, err := c.ListACLEntries(ctx, &ListACLEntriesInput{
+		ACLID:     i.ACLID,
+		ServiceID: i.ServiceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes, report := diffACLEntries(entries, desired, i.Comment)
+	if i.DryRun {
+		report.DryRun = true
+		return report, nil
+	}
+
+	report.Batches, err = batch.Apply(ctx, changes, size, newSyncRetryPolicy(i.MaxRetries), func(ctx context.Context, chunk []*BatchACLEntry) error {
+		return c.BatchModifyACLEntries(ctx, &BatchModifyACLEntriesInput{
+			ACLID:     i.ACLID,
+			Entries:   chunk,
+			ServiceID: i.ServiceID,
+		})
+	})
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// collapseACLPrefixes returns the minimal set of prefixes, mapped to whether
+// they are negated, equivalent to the given matched and negated prefixes.
+func collapseACLPrefixes(prefixes, negated []netip.Prefix) (map[netip.Prefix]bool, error) {
+	entries := make(map[netip.Prefix]bool, len(prefixes)+len(negated))
+	for _, p := range prefixes {
+		if !p.IsValid() {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidACLPrefix, p)
+		}
+		entries[cidr.Normalize(p)] = false
+	}
+	for _, p := range negated {
+		if !p.IsValid() {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidACLPrefix, p)
+		}
+		p = cidr.Normalize(p)
+		if v, ok := entries[p]; ok && !v {
+			return nil, fmt.Errorf("%w: %s", ErrConflictingACLPrefix, p)
+		}
+		entries[p] = true
+	}
+
+	return cidr.Collapse(entries, func(negated bool) bool { return negated }), nil
+}
+
+// diffACLEntries computes the batch operations needed to turn the current
+// entries into the desired prefixes, ordered as described by cidr.Diff.
+func diffACLEntries(current []*ACLEntry, desired map[netip.Prefix]bool, comment *string) ([]*BatchACLEntry, *SyncReport) {
+	entries := make([]cidr.Entry[bool], len(current))
+	keys := make([]string, len(current))
+	for n, e := range current {
+		p, err := aclEntryPrefix(e)
+		keys[n] = p.String()
+		if err != nil {
+			keys[n] = ToValue(e.IP)
+		}
+		entries[n] = cidr.Entry[bool]{Prefix: p, Value: bool(ToValue(e.Negated))}
+	}
+
+	diff, unchanged := cidr.Diff(entries, desired)
+	report := &SyncReport{Unchanged: unchanged}
+	changes := make([]*BatchACLEntry, 0, len(diff))
+	for _, d := range diff {
+		switch d.Operation {
+		case cidr.Delete:
+			report.Deleted = append(report.Deleted, keys[d.Index])
+			changes = append(changes, &BatchACLEntry{
+				EntryID:   current[d.Index].EntryID,
+				Operation: ToPointer(DeleteBatchOperation),
+			})
+		case cidr.Update:
+			report.Updated = append(report.Updated, keys[d.Index])
+			changes = append(changes, &BatchACLEntry{
+				EntryID:   current[d.Index].EntryID,
+				IP:        ToPointer(d.Prefix.Addr().String()),
+				Negated:   ToPointer(Compatibool(d.Value)),
+				Operation: ToPointer(UpdateBatchOperation),
+				Subnet:    ToPointer(d.Prefix.Bits()),
+			})
+		case cidr.Create:
+			report.Created = append(report.Created, d.Prefix.String())
+			entry := &BatchACLEntry{
+				Comment:   comment,
+				IP:        ToPointer(d.Prefix.Addr().String()),
+				Negated:   ToPointer(Compatibool(d.Value)),
+				Operation: ToPointer(CreateBatchOperation),
+			}
+			if d.Prefix.Bits() != d.Prefix.Addr().BitLen() {
+				entry.Subnet = ToPointer(d.Prefix.Bits())
+			}
+			changes = append(changes, entry)
+		}
+	}
+
+	sort.Strings(report.Created)
+	sort.Strings(report.Updated)
+	sort.Strings(report.Deleted)
+	return changes, report
+}
+
+// aclEntryPrefix returns the normalized network covered by an ACL entry. An
+// entry without a subnet covers a single address.
+func aclEntryPrefix(e *ACLEntry) (netip.Prefix, error) {
+	addr, err := netip.ParseAddr(ToValue(e.IP))
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w: %s", ErrInvalidACLPrefix, ToValue(e.IP))
+	}
+	bits := addr.BitLen()
+	if e.Subnet != nil {
+		bits = *e.Subnet
+	}
+	p := netip.PrefixFrom(addr, bits)
+	if !p.IsValid() {
+		return netip.Prefix{}, fmt.Errorf("%w: %s/%d", ErrInvalidACLPrefix, ToValue(e.IP), bits)
+	}
+	return cidr.Normalize(p), nil
+}
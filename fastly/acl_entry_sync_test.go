@@ -0,0 +1,185 @@
+package fastly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCollapseACLPrefixes(t *testing.T) {
+	t.Parallel()
+
+	got, err := collapseACLPrefixes(
+		[]netip.Prefix{
+			netip.MustParsePrefix("192.0.2.0/25"),
+			netip.MustParsePrefix("192.0.2.128/25"),
+			netip.MustParsePrefix("192.0.2.7/32"),
+			netip.MustParsePrefix("2001:db8::/48"),
+		},
+		[]netip.Prefix{
+			netip.MustParsePrefix("192.0.2.64/26"),
+			netip.MustParsePrefix("198.51.100.0/24"),
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[netip.Prefix]bool{
+		netip.MustParsePrefix("192.0.2.0/24"):  false,
+		netip.MustParsePrefix("192.0.2.64/26"): true,
+		netip.MustParsePrefix("2001:db8::/48"): false,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected prefixes (-want +got):\n%s", diff)
+	}
+
+	_, err = collapseACLPrefixes(
+		[]netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+		[]netip.Prefix{netip.MustParsePrefix("192.0.2.1/24")},
+	)
+	if !errors.Is(err, ErrConflictingACLPrefix) {
+		t.Errorf("expected ErrConflictingACLPrefix, got %v", err)
+	}
+}
+
+func TestDiffACLEntries(t *testing.T) {
+	t.Parallel()
+
+	current := []*ACLEntry{
+		{EntryID: ToPointer("keep"), IP: ToPointer("192.0.2.9"), Subnet: ToPointer(24)},
+		{EntryID: ToPointer("dupe"), IP: ToPointer("192.0.2.0"), Subnet: ToPointer(24)},
+		{EntryID: ToPointer("flip"), IP: ToPointer("192.0.2.64"), Subnet: ToPointer(26)},
+		{EntryID: ToPointer("gone"), IP: ToPointer("203.0.113.5")},
+		{EntryID: ToPointer("junk"), IP: ToPointer("not-an-ip")},
+	}
+	desired := map[netip.Prefix]bool{
+		netip.MustParsePrefix("192.0.2.0/24"):    false,
+		netip.MustParsePrefix("192.0.2.64/26"):   true,
+		netip.MustParsePrefix("2001:db8::1/128"): false,
+	}
+
+	changes, report := diffACLEntries(current, desired, ToPointer("managed"))
+
+	wantReport := &SyncReport{
+		Created:   []string{"2001:db8::1/128"},
+		Deleted:   []string{"192.0.2.0/24", "203.0.113.5/32", "not-an-ip"},
+		Unchanged: 1,
+		Updated:   []string{"192.0.2.64/26"},
+	}
+	if diff := cmp.Diff(wantReport, report); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+
+	wantChanges := []*BatchACLEntry{
+		{EntryID: ToPointer("dupe"), Operation: ToPointer(DeleteBatchOperation)},
+		{EntryID: ToPointer("gone"), Operation: ToPointer(DeleteBatchOperation)},
+		{EntryID: ToPointer("junk"), Operation: ToPointer(DeleteBatchOperation)},
+		{
+			EntryID:   ToPointer("flip"),
+			IP:        ToPointer("192.0.2.64"),
+			Negated:   ToPointer(Compatibool(true)),
+			Operation: ToPointer(UpdateBatchOperation),
+			Subnet:    ToPointer(26),
+		},
+		{
+			Comment:   ToPointer("managed"),
+			IP:        ToPointer("2001:db8::1"),
+			Negated:   ToPointer(Compatibool(false)),
+			Operation: ToPointer(CreateBatchOperation),
+		},
+	}
+	if diff := cmp.Diff(wantChanges, changes); diff != "" {
+		t.Errorf("unexpected changes (-want +got):\n%s", diff)
+	}
+}
+
+// patchBodies records the bodies of the PATCH requests sent through it.
+type patchBodies struct {
+	bodies [][]byte
+	next   http.RoundTripper
+}
+
+func (p *patchBodies) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method == http.MethodPatch && r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		p.bodies = append(p.bodies, body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return p.next.RoundTrip(r)
+}
+
+func TestClient_SyncACL(t *testing.T) {
+	t.Parallel()
+
+	var (
+		batches patchBodies
+		report  *SyncReport
+		err     error
+	)
+	RecordMatchBody(t, "acl_entry_sync/sync", func(c *Client) {
+		batches.next = c.HTTPClient.Transport
+		c.HTTPClient.Transport = &batches
+		report, err = c.SyncACL(context.TODO(), &SyncACLInput{
+			ACLID:     "gn5aWs0VHPj0DyskUd3W30",
+			BatchSize: ToPointer(2),
+			Prefixes: []netip.Prefix{
+				netip.MustParsePrefix("192.0.2.0/24"),
+				netip.MustParsePrefix("192.0.2.64/26"),
+				netip.MustParsePrefix("198.51.100.0/24"),
+			},
+			ServiceID: "h0r9bHHO8AxEkQ10Nm0Ve7",
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantReport := &SyncReport{
+		Batches: 3,
+		Created: []string{"192.0.2.0/24", "198.51.100.0/24"},
+		Deleted: []string{"192.0.2.0/25", "192.0.2.128/25", "203.0.113.5/32"},
+	}
+	if diff := cmp.Diff(wantReport, report); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+
+	// The /24 replacing the two /25 entries is created before they are
+	// deleted, so 192.0.2.0/24 stays matched between batches.
+	var got [][]string
+	for _, body := range batches.bodies {
+		var input BatchModifyACLEntriesInput
+		if err := json.Unmarshal(body, &input); err != nil {
+			t.Fatal(err)
+		}
+		var ops []string
+		for _, e := range input.Entries {
+			switch *e.Operation {
+			case DeleteBatchOperation:
+				ops = append(ops, "delete "+*e.EntryID)
+			default:
+				ops = append(ops, fmt.Sprintf("%s %s/%d", *e.Operation, *e.IP, *e.Subnet))
+			}
+		}
+		got = append(got, ops)
+	}
+	want := [][]string{
+		{"delete Qn6CbrT0ewKxVm3N0fNhe2", "create 192.0.2.0/24"},
+		{"delete W3lz3G5uyKsDlGkKfQJ0J1", "delete jnNjRGzeSoSzBnFpGpMU43"},
+		{"create 198.51.100.0/24"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected batches (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,415 @@
+package fastly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// AlertTemplate is an alert definition applied to many services. Its Name,
+// Description and Dimensions values are text/template templates executed with
+// an AlertTemplateData, such as:
+//
+//	{{.ServiceName}}: 5xx ratio above 1%
+type AlertTemplate struct {
+	// Description is additional text included in an alert notification.
+	Description string
+	// Dimensions are a list of origins or domains that the alert is
+	// restricted to.
+	Dimensions map[string][]string
+	// EvaluationStrategy is the evaluation strategy for the alert (required).
+	EvaluationStrategy map[string]any
+	// IntegrationIDs are IDs of integrations that notifications will be sent
+	// to.
+	IntegrationIDs []string
+	// Metric is the name of the metric being monitored (required).
+	Metric string
+	// Name is the summary text of the alert (required). Alerts are matched to
+	// their template by rendered name, so it must be unique per service.
+	Name string
+	// Source is the metric source: stats, origins or domains (required).
+	Source string
+}
+
+// AlertTemplateData holds the values available to the placeholders of an
+// AlertTemplate.
+type AlertTemplateData struct {
+	// ServiceID is the ID of the service.
+	ServiceID string
+	// ServiceName is the name of the service.
+	ServiceName string
+	// ServiceType is the type of the service (vcl or wasm).
+	ServiceType string
+}
+
+// Render executes the placeholders of the template for a service, returning
+// the input needed to create its alert definition.
+func (t *AlertTemplate) Render(data AlertTemplateData) (*CreateAlertDefinitionInput, error) {
+	if t.Name == "" {
+		return nil, ErrMissingName
+	}
+	if t.Metric == "" {
+		return nil, ErrMissingMetric
+	}
+	if t.Source == "" {
+		return nil, ErrMissingSource
+	}
+
+	name, err := renderAlertTemplate(t.Name, data)
+	if err != nil {
+		return nil, err
+	}
+	description, err := renderAlertTemplate(t.Description, data)
+	if err != nil {
+		return nil, err
+	}
+	var dimensions map[string][]string
+	if t.Dimensions != nil {
+		dimensions = make(map[string][]string, len(t.Dimensions))
+		for k, values := range t.Dimensions {
+			rendered := make([]string, len(values))
+			for n, v := range values {
+				if rendered[n], err = renderAlertTemplate(v, data); err != nil {
+					return nil, err
+				}
+			}
+			dimensions[k] = rendered
+		}
+	}
+
+	return &CreateAlertDefinitionInput{
+		Description:        ToPointer(description),
+		Dimensions:         dimensions,
+		EvaluationStrategy: t.EvaluationStrategy,
+		IntegrationIDs:     t.IntegrationIDs,
+		Metric:             ToPointer(t.Metric),
+		Name:               ToPointer(name),
+		ServiceID:          ToPointer(data.ServiceID),
+		Source:             ToPointer(t.Source),
+	}, nil
+}
+
+// renderAlertTemplate executes a single template string.
+func renderAlertTemplate(text string, data AlertTemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("alert").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// ServiceFilter selects services. A service must match every non-empty
+// field.
+type ServiceFilter struct {
+	// Name is a glob, in the syntax of path.Match, matched against the service
+	// name.
+	Name string
+	// Tags are words that must all appear in the service comment, which is
+	// split on whitespace and commas. For example, the tag alerts:standard
+	// matches the comment "prod, alerts:standard".
+	Tags []string
+	// Type is the service type (vcl or wasm).
+	Type string
+}
+
+// Matches reports whether a service is selected by the filter. A malformed
+// Name pattern matches no service.
+func (f *ServiceFilter) Matches(s *Service) bool {
+	if f.Name != "" {
+		if ok, err := path.Match(f.Name, ToValue(s.Name)); err != nil || !ok {
+			return false
+		}
+	}
+	if f.Type != "" && ToValue(s.Type) != f.Type {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		words := strings.FieldsFunc(ToValue(s.Comment), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		})
+		for _, tag := range f.Tags {
+			if !slices.Contains(words, tag) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// AlertTemplateAction is the action taken for one alert by
+// ApplyAlertTemplates.
+type AlertTemplateAction string
+
+const (
+	// AlertTemplateCreate means the alert did not exist.
+	AlertTemplateCreate AlertTemplateAction = "create"
+	// AlertTemplateUnchanged means the alert matched its template.
+	AlertTemplateUnchanged AlertTemplateAction = "unchanged"
+	// AlertTemplateUpdate means the alert had drifted from its template.
+	AlertTemplateUpdate AlertTemplateAction = "update"
+)
+
+// AlertTemplateResult is the outcome of applying one template to one service.
+type AlertTemplateResult struct {
+	// Action is the action taken, or for a dry run, the action needed.
+	Action AlertTemplateAction
+	// DefinitionID is the ID of the alert definition, if it exists.
+	DefinitionID string
+	// Drift lists the fields of an existing alert that differ from the
+	// template: description, dimensions, evaluation_strategy,
+	// integration_ids, metric or source. Drift in source cannot be fixed by
+	// an update and is reported with ErrAlertSourceChanged.
+	Drift []string
+	// Err is the error that prevented the action, if any.
+	Err error
+	// Name is the rendered name of the alert.
+	Name string
+	// ServiceID is the ID of the service.
+	ServiceID string
+}
+
+// AlertTemplateReport is the outcome of ApplyAlertTemplates.
+type AlertTemplateReport struct {
+	// DryRun indicates the changes were computed but not applied.
+	DryRun bool
+	// Results holds one result per template and matched service, ordered by
+	// service ID and then template order.
+	Results []AlertTemplateResult
+}
+
+// Drifted returns the results of existing alerts that differ from their
+// template.
+func (r *AlertTemplateReport) Drifted() []AlertTemplateResult {
+	var drifted []AlertTemplateResult
+	for _, res := range r.Results {
+		if len(res.Drift) > 0 {
+			drifted = append(drifted, res)
+		}
+	}
+	return drifted
+}
+
+// Failed returns the results whose action could not be completed.
+func (r *AlertTemplateReport) Failed() []AlertTemplateResult {
+	var failed []AlertTemplateResult
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// ApplyAlertTemplatesInput is used as input to the ApplyAlertTemplates
+// function.
+type ApplyAlertTemplatesInput struct {
+	// DryRun computes the changes and drift without applying them.
+	DryRun bool
+	// Filter selects the services the templates are applied to. All services
+	// are selected when it is empty.
+	Filter ServiceFilter
+	// SkipTest creates alerts without validating them with
+	// TestAlertDefinition first, which also sends test notifications.
+	SkipTest bool
+	// Templates is the list of templates to apply (required).
+	Templates []*AlertTemplate
+}
+
+// ApplyAlertTemplates reconciles the alert definitions of every service
+// selected by the filter with a set of templates.
+//
+// Existing alerts are matched to templates by their rendered name. Missing
+// alerts are validated with TestAlertDefinition, unless SkipTest is set, and
+// then created; alerts that have drifted from their template are updated.
+// Alerts without a matching template are left untouched. Failures for one
+// alert are recorded in its result rather than stopping the run, so the
+// returned error only covers listing services and rendering templates.
+func (c *Client) ApplyAlertTemplates(ctx context.Context, i *ApplyAlertTemplatesInput) (*AlertTemplateReport, error) {
+	if len(i.Templates) == 0 {
+		return nil, ErrMissingTemplates
+	}
+
+	services, err := c.ListServices(ctx, &ListServicesInput{})
+	if err != nil {
+		return nil, err
+	}
+	var selected []*Service
+	for _, s := range services {
+		if s != nil && s.ServiceID != nil && i.Filter.Matches(s) {
+			selected = append(selected, s)
+		}
+	}
+	sort.Slice(selected, func(a, b int) bool { return *selected[a].ServiceID < *selected[b].ServiceID })
+
+	report := &AlertTemplateReport{DryRun: i.DryRun}
+	for _, s := range selected {
+		data := AlertTemplateData{
+			ServiceID:   *s.ServiceID,
+			ServiceName: ToValue(s.Name),
+			ServiceType: ToValue(s.Type),
+		}
+
+		inputs := make([]*CreateAlertDefinitionInput, len(i.Templates))
+		for n, t := range i.Templates {
+			if inputs[n], err = t.Render(data); err != nil {
+				return report, fmt.Errorf("rendering alert template %q for service %s: %w", t.Name, data.ServiceID, err)
+			}
+		}
+
+		existing, err := c.listServiceAlertDefinitions(ctx, data.ServiceID)
+		for _, in := range inputs {
+			res := AlertTemplateResult{Name: *in.Name, ServiceID: data.ServiceID}
+			if err != nil {
+				res.Err = err
+				report.Results = append(report.Results, res)
+				continue
+			}
+
+			def, ok := existing[*in.Name]
+			switch {
+			case !ok:
+				res.Action = AlertTemplateCreate
+				if !i.DryRun {
+					res.DefinitionID, res.Err = c.createAlertFromTemplate(ctx, in, i.SkipTest)
+				}
+			default:
+				res.DefinitionID = def.ID
+				res.Drift = alertDefinitionDrift(def, in)
+				res.Action = AlertTemplateUnchanged
+				if len(res.Drift) > 0 {
+					res.Action = AlertTemplateUpdate
+					if slices.Contains(res.Drift, "source") {
+						res.Err = ErrAlertSourceChanged
+					} else if !i.DryRun {
+						_, res.Err = c.UpdateAlertDefinition(ctx, &UpdateAlertDefinitionInput{
+							Description:        in.Description,
+							Dimensions:         in.Dimensions,
+							EvaluationStrategy: in.EvaluationStrategy,
+							ID:                 ToPointer(def.ID),
+							IntegrationIDs:     in.IntegrationIDs,
+							Metric:             in.Metric,
+							Name:               in.Name,
+						})
+					}
+				}
+			}
+			report.Results = append(report.Results, res)
+		}
+	}
+	return report, nil
+}
+
+// createAlertFromTemplate tests, unless skipTest is set, and creates an alert
+// definition, returning its ID.
+func (c *Client) createAlertFromTemplate(ctx context.Context, in *CreateAlertDefinitionInput, skipTest bool) (string, error) {
+	if !skipTest {
+		if err := c.TestAlertDefinition(ctx, &TestAlertDefinitionInput{
+			Description:        in.Description,
+			Dimensions:         in.Dimensions,
+			EvaluationStrategy: in.EvaluationStrategy,
+			IntegrationIDs:     in.IntegrationIDs,
+			Metric:             in.Metric,
+			Name:               in.Name,
+			ServiceID:          in.ServiceID,
+			Source:             in.Source,
+		}); err != nil {
+			return "", fmt.Errorf("testing alert definition: %w", err)
+		}
+	}
+	def, err := c.CreateAlertDefinition(ctx, in)
+	if err != nil {
+		return "", err
+	}
+	return def.ID, nil
+}
+
+// listServiceAlertDefinitions lists every alert definition of a service,
+// following pagination cursors, keyed by name.
+func (c *Client) listServiceAlertDefinitions(ctx context.Context, serviceID string) (map[string]*AlertDefinition, error) {
+	byName := make(map[string]*AlertDefinition)
+	input := &ListAlertDefinitionsInput{ServiceID: ToPointer(serviceID)}
+	for {
+		resp, err := c.ListAlertDefinitions(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for n := range resp.Data {
+			d := &resp.Data[n]
+			byName[d.Name] = d
+		}
+		if resp.Meta.NextCursor == "" || (input.Cursor != nil && *input.Cursor == resp.Meta.NextCursor) {
+			return byName, nil
+		}
+		input = &ListAlertDefinitionsInput{Cursor: ToPointer(resp.Meta.NextCursor), ServiceID: ToPointer(serviceID)}
+	}
+}
+
+// alertDefinitionDrift returns the JSON names of the fields of def that
+// differ from the desired input.
+func alertDefinitionDrift(def *AlertDefinition, in *CreateAlertDefinitionInput) []string {
+	var drift []string
+	if def.Description != ToValue(in.Description) {
+		drift = append(drift, "description")
+	}
+	if !jsonEquivalent(def.Dimensions, in.Dimensions) {
+		drift = append(drift, "dimensions")
+	}
+	if !jsonEquivalent(def.EvaluationStrategy, in.EvaluationStrategy) {
+		drift = append(drift, "evaluation_strategy")
+	}
+	have := slices.Sorted(slices.Values(def.IntegrationIDs))
+	want := slices.Sorted(slices.Values(in.IntegrationIDs))
+	if !slices.Equal(have, want) {
+		drift = append(drift, "integration_ids")
+	}
+	if def.Metric != ToValue(in.Metric) {
+		drift = append(drift, "metric")
+	}
+	if def.Source != ToValue(in.Source) {
+		drift = append(drift, "source")
+	}
+	return drift
+}
+
+// jsonEquivalent reports whether a and b have the same JSON form, treating
+// empty maps and slices as null. This makes values decoded from the API, whose
+// numbers are float64, comparable with those built in code.
+func jsonEquivalent(a, b any) bool {
+	return reflect.DeepEqual(jsonNormalize(a), jsonNormalize(b))
+}
+
+// jsonNormalize round-trips v through JSON.
+func jsonNormalize(v any) any {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(js, &out); err != nil {
+		return v
+	}
+	switch o := out.(type) {
+	case map[string]any:
+		if len(o) == 0 {
+			return nil
+		}
+	case []any:
+		if len(o) == 0 {
+			return nil
+		}
+	}
+	return out
+}
@@ -0,0 +1,178 @@
+package fastly
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func testAlertTemplates() []*AlertTemplate {
+	return []*AlertTemplate{
+		{
+			Description:        "5xx responses on {{.ServiceName}}",
+			EvaluationStrategy: map[string]any{"type": "above_threshold", "period": "5m", "threshold": 0.01},
+			Metric:             "status_5xx",
+			Name:               "{{.ServiceName}}: 5xx ratio",
+			Source:             "stats",
+		},
+		{
+			Dimensions:         map[string][]string{"origins": {"{{.ServiceName}}-origin"}},
+			EvaluationStrategy: map[string]any{"type": "above_threshold", "period": "5m", "threshold": 500},
+			Metric:             "latency_p95",
+			Name:               "{{.ServiceName}}: origin latency",
+			Source:             "origins",
+		},
+	}
+}
+
+func TestServiceFilter_Matches(t *testing.T) {
+	t.Parallel()
+
+	s := &Service{Comment: ToPointer("prod, alerts:standard"), Name: ToPointer("shop-eu"), Type: ToPointer("vcl")}
+	cases := []struct {
+		filter ServiceFilter
+		want   bool
+	}{
+		{filter: ServiceFilter{}, want: true},
+		{filter: ServiceFilter{Name: "shop-*"}, want: true},
+		{filter: ServiceFilter{Name: "blog-*"}, want: false},
+		{filter: ServiceFilter{Name: "["}, want: false},
+		{filter: ServiceFilter{Type: "wasm"}, want: false},
+		{filter: ServiceFilter{Tags: []string{"alerts:standard", "prod"}}, want: true},
+		{filter: ServiceFilter{Tags: []string{"alerts"}}, want: false},
+	}
+	for _, tc := range cases {
+		if got := tc.filter.Matches(s); got != tc.want {
+			t.Errorf("%+v: got %t, want %t", tc.filter, got, tc.want)
+		}
+	}
+}
+
+func TestAlertTemplate_Render(t *testing.T) {
+	t.Parallel()
+
+	in, err := testAlertTemplates()[1].Render(AlertTemplateData{ServiceID: "svc", ServiceName: "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *in.Name != "shop: origin latency" || in.Dimensions["origins"][0] != "shop-origin" || *in.ServiceID != "svc" {
+		t.Errorf("unexpected input: %+v", in)
+	}
+
+	_, err = (&AlertTemplate{Metric: "m", Name: "{{.Nope}}", Source: "stats"}).Render(AlertTemplateData{})
+	if err == nil {
+		t.Error("expected an error for an unknown placeholder")
+	}
+	_, err = (&AlertTemplate{Name: "n", Source: "stats"}).Render(AlertTemplateData{})
+	if !errors.Is(err, ErrMissingMetric) {
+		t.Errorf("expected ErrMissingMetric, got %v", err)
+	}
+}
+
+func TestApplyAlertTemplates(t *testing.T) {
+	t.Parallel()
+
+	input := &ApplyAlertTemplatesInput{
+		Filter:    ServiceFilter{Tags: []string{"alerts:standard"}},
+		Templates: testAlertTemplates(),
+	}
+
+	var (
+		created, dryRun, updated *AlertTemplateReport
+		err                      error
+	)
+	// The services shop and blog are tagged alerts:standard and start without
+	// alert definitions. Between the first and second runs, the description of
+	// "blog: 5xx ratio" was edited by hand and the source of "shop: origin
+	// latency" changed. A request made by the dry run would not match the
+	// fixture.
+	RecordMatchBody(t, "alert_templates/apply", func(c *Client) {
+		if created, err = c.ApplyAlertTemplates(context.TODO(), input); err != nil {
+			return
+		}
+		dryRun, err = c.ApplyAlertTemplates(context.TODO(), &ApplyAlertTemplatesInput{
+			DryRun:    true,
+			Filter:    input.Filter,
+			Templates: input.Templates,
+		})
+		if err != nil {
+			return
+		}
+		updated, err = c.ApplyAlertTemplates(context.TODO(), input)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		Action       AlertTemplateAction
+		DefinitionID string
+		Name         string
+	}
+	results := func(r *AlertTemplateReport) []result {
+		var got []result
+		for _, res := range r.Results {
+			if res.Err != nil {
+				t.Errorf("%s: %v", res.Name, res.Err)
+			}
+			got = append(got, result{Action: res.Action, DefinitionID: res.DefinitionID, Name: res.Name})
+		}
+		return got
+	}
+	want := []result{
+		{Action: AlertTemplateCreate, DefinitionID: "1sKq7dNbVz3XmPw8RtLc5H", Name: "shop: 5xx ratio"},
+		{Action: AlertTemplateCreate, DefinitionID: "4HjT2wQeLm9PzXv6NbKd1S", Name: "shop: origin latency"},
+		{Action: AlertTemplateCreate, DefinitionID: "6RnVb3KsXq8TzLw2MhPd4C", Name: "blog: 5xx ratio"},
+		{Action: AlertTemplateCreate, DefinitionID: "8DzLm5QwNt1KpXv7RbHs3J", Name: "blog: origin latency"},
+	}
+	if diff := cmp.Diff(want, results(created)); diff != "" {
+		t.Errorf("unexpected results (-want +got):\n%s", diff)
+	}
+
+	// The definitions decoded from the API hold float64 numbers, which must
+	// not be reported as drift.
+	wantDrift := []AlertTemplateResult{
+		{Action: AlertTemplateUpdate, DefinitionID: "4HjT2wQeLm9PzXv6NbKd1S", Drift: []string{"source"}, Err: ErrAlertSourceChanged, Name: "shop: origin latency", ServiceID: "2mCkD8rWzQe5LnVbXt7HsG"},
+		{Action: AlertTemplateUpdate, DefinitionID: "6RnVb3KsXq8TzLw2MhPd4C", Drift: []string{"description"}, Name: "blog: 5xx ratio", ServiceID: "7YpNf3KsLq9TbVw1RxHc2J"},
+	}
+	for _, r := range []*AlertTemplateReport{dryRun, updated} {
+		if diff := cmp.Diff(wantDrift, r.Drifted(), cmpopts.EquateErrors()); diff != "" {
+			t.Errorf("unexpected drift (-want +got):\n%s", diff)
+		}
+		if len(r.Failed()) != 1 {
+			t.Errorf("unexpected failures: %+v", r.Failed())
+		}
+	}
+	if !dryRun.DryRun || updated.DryRun {
+		t.Errorf("unexpected DryRun: %t, %t", dryRun.DryRun, updated.DryRun)
+	}
+}
+
+func TestApplyAlertTemplates_testFailure(t *testing.T) {
+	t.Parallel()
+
+	var (
+		report *AlertTemplateReport
+		err    error
+	)
+	// The test request is rejected, so no definition is created.
+	RecordMatchBody(t, "alert_templates/apply_test_failure", func(c *Client) {
+		report, err = c.ApplyAlertTemplates(context.TODO(), &ApplyAlertTemplatesInput{Templates: testAlertTemplates()[:1]})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := report.Failed()
+	var httpErr *HTTPError
+	if len(failed) != 1 || !errors.As(failed[0].Err, &httpErr) || httpErr.StatusCode != 400 || failed[0].DefinitionID != "" {
+		t.Errorf("expected a failed result without a definition, got %+v", report.Results)
+	}
+
+	_, err = TestClient.ApplyAlertTemplates(context.TODO(), &ApplyAlertTemplatesInput{})
+	if !errors.Is(err, ErrMissingTemplates) {
+		t.Errorf("expected ErrMissingTemplates, got %v", err)
+	}
+}
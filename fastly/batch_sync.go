@@ -0,0 +1,121 @@
+package fastly
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/fastly/go-fastly/v17/internal/batch"
+)
+
+const (
+	// DefaultSyncMaxRetries is the number of times a failed batch request is
+	// retried by the Sync* functions when the input does not specify a value.
+	DefaultSyncMaxRetries = 3
+
+	// syncRetryBaseDelay is the delay before the first retry of a failed
+	// batch. Each subsequent retry doubles the delay.
+	syncRetryBaseDelay = time.Second
+)
+
+// newSyncRetryPolicy returns the retry policy for an optional MaxRetries input
+// field.
+func newSyncRetryPolicy(maxRetries *int) batch.RetryPolicy {
+	p := batch.RetryPolicy{
+		BaseDelay:  syncRetryBaseDelay,
+		MaxRetries: DefaultSyncMaxRetries,
+		Retryable:  IsRetryableBatchError,
+	}
+	if maxRetries != nil {
+		p.MaxRetries = *maxRetries
+	}
+	return p
+}
+
+// SyncReport describes the changes made (or, for a dry run, the changes that
+// would be made) by one of the Sync* functions.
+type SyncReport struct {
+	// Batches is the number of batch requests sent to the API. This is always
+	// zero for a dry run.
+	Batches int
+	// Created is the list of keys that were created.
+	Created []string
+	// Deleted is the list of keys that were deleted.
+	Deleted []string
+	// DryRun indicates the changes were computed but not applied.
+	DryRun bool
+	// Unchanged is the number of keys that already matched the desired state.
+	Unchanged int
+	// Updated is the list of keys whose value was changed.
+	Updated []string
+}
+
+// HasChanges returns true if the report contains at least one create, update
+// or delete.
+func (r *SyncReport) HasChanges() bool {
+	return len(r.Created)+len(r.Updated)+len(r.Deleted) > 0
+}
+
+// syncChange is a single key-level change computed by diffStringMaps.
+type syncChange struct {
+	Key       string
+	Operation BatchOperation
+	Value     string
+}
+
+// diffStringMaps computes the minimal set of changes needed to turn current
+// into desired. The changes are sorted by key so batches are deterministic.
+//
+// Creates and updates are both emitted with the upsert operation so that a
+// retried batch is idempotent; the report still distinguishes them.
+func diffStringMaps(current, desired map[string]string) ([]syncChange, *SyncReport) {
+	report := &SyncReport{}
+	var changes []syncChange
+
+	for k, v := range desired {
+		cv, ok := current[k]
+		switch {
+		case !ok:
+			report.Created = append(report.Created, k)
+			changes = append(changes, syncChange{Key: k, Operation: UpsertBatchOperation, Value: v})
+		case cv != v:
+			report.Updated = append(report.Updated, k)
+			changes = append(changes, syncChange{Key: k, Operation: UpsertBatchOperation, Value: v})
+		default:
+			report.Unchanged++
+		}
+	}
+	for k := range current {
+		if _, ok := desired[k]; !ok {
+			report.Deleted = append(report.Deleted, k)
+			changes = append(changes, syncChange{Key: k, Operation: DeleteBatchOperation})
+		}
+	}
+
+	sort.Strings(report.Created)
+	sort.Strings(report.Updated)
+	sort.Strings(report.Deleted)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes, report
+}
+
+// syncBatchSize validates an optional batch size, returning the API maximum
+// when size is nil.
+func syncBatchSize(size *int) (int, error) {
+	n, ok := batch.Size(size, BatchModifyMaximumOperations)
+	if !ok {
+		return 0, ErrInvalidBatchSize
+	}
+	return n, nil
+}
+
+// IsRetryableBatchError reports whether err is an API error caused by rate
+// limiting or a server-side failure, in which case the batch request that
+// returned it may succeed if sent again.
+func IsRetryableBatchError(err error) bool {
+	var herr *HTTPError
+	return errors.As(err, &herr) && herr.IsRetryable()
+}
@@ -0,0 +1,177 @@
+package fastly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffStringMaps(t *testing.T) {
+	t.Parallel()
+
+	current := map[string]string{
+		"keep":   "same",
+		"change": "old",
+		"remove": "gone",
+	}
+	desired := map[string]string{
+		"keep":   "same",
+		"change": "new",
+		"add":    "fresh",
+	}
+
+	changes, report := diffStringMaps(current, desired)
+
+	wantChanges := []syncChange{
+		{Key: "add", Operation: UpsertBatchOperation, Value: "fresh"},
+		{Key: "change", Operation: UpsertBatchOperation, Value: "new"},
+		{Key: "remove", Operation: DeleteBatchOperation},
+	}
+	if diff := cmp.Diff(wantChanges, changes); diff != "" {
+		t.Errorf("unexpected changes (-want +got):\n%s", diff)
+	}
+
+	wantReport := &SyncReport{
+		Created:   []string{"add"},
+		Deleted:   []string{"remove"},
+		Unchanged: 1,
+		Updated:   []string{"change"},
+	}
+	if diff := cmp.Diff(wantReport, report); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+	if !report.HasChanges() {
+		t.Error("expected report to have changes")
+	}
+
+	_, report = diffStringMaps(desired, desired)
+	if report.HasChanges() {
+		t.Errorf("expected no changes, got %+v", report)
+	}
+}
+
+func TestSyncBatchSize(t *testing.T) {
+	t.Parallel()
+
+	if got, err := syncBatchSize(nil); err != nil || got != BatchModifyMaximumOperations {
+		t.Errorf("expected default batch size, got %d (%v)", got, err)
+	}
+	if got, err := syncBatchSize(ToPointer(10)); err != nil || got != 10 {
+		t.Errorf("expected batch size 10, got %d (%v)", got, err)
+	}
+	for _, size := range []int{0, BatchModifyMaximumOperations + 1} {
+		if _, err := syncBatchSize(ToPointer(size)); !errors.Is(err, ErrInvalidBatchSize) {
+			t.Errorf("expected ErrInvalidBatchSize for %d, got %v", size, err)
+		}
+	}
+}
+
+func TestIsRetryableBatchError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &HTTPError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{err: &HTTPError{StatusCode: http.StatusBadGateway}, want: true},
+		{err: &HTTPError{StatusCode: http.StatusNotFound}, want: false},
+		{err: errors.New("boom"), want: false},
+	}
+	for _, tc := range tests {
+		if got := IsRetryableBatchError(tc.err); got != tc.want {
+			t.Errorf("IsRetryableBatchError(%v) = %t, want %t", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestClient_SyncDictionary(t *testing.T) {
+	t.Parallel()
+
+	var (
+		report *SyncReport
+		err    error
+	)
+	RecordMatchBody(t, "dictionary_item_sync/sync", func(c *Client) {
+		report, err = c.SyncDictionary(context.TODO(), &SyncDictionaryInput{
+			BatchSize:    ToPointer(2),
+			DictionaryID: "kL8Xhq4rwcwSQDgKqb5Yn1",
+			Items: map[string]string{
+				"/keep":   "/same",
+				"/change": "/new",
+				"/add":    "/fresh",
+			},
+			ServiceID:      "7i6HN3TK9wS159v2gPAZ8A",
+			ServiceVersion: 3,
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &SyncReport{
+		Batches:   2,
+		Created:   []string{"/add"},
+		Deleted:   []string{"/remove"},
+		Unchanged: 1,
+		Updated:   []string{"/change"},
+	}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+
+	RecordMatchBody(t, "dictionary_item_sync/write_only", func(c *Client) {
+		_, err = c.SyncDictionary(context.TODO(), &SyncDictionaryInput{
+			DictionaryID:   "Vr1j2Ew0ZIBmdUm9eLm6A1",
+			Items:          map[string]string{"key": "value"},
+			ServiceID:      "7i6HN3TK9wS159v2gPAZ8A",
+			ServiceVersion: 3,
+		})
+	})
+	if !errors.Is(err, ErrDictionaryWriteOnly) {
+		t.Errorf("expected ErrDictionaryWriteOnly, got %v", err)
+	}
+
+	_, err = TestClient.SyncDictionary(context.TODO(), &SyncDictionaryInput{
+		DictionaryID: "kL8Xhq4rwcwSQDgKqb5Yn1",
+		ServiceID:    "7i6HN3TK9wS159v2gPAZ8A",
+	})
+	if !errors.Is(err, ErrMissingServiceVersion) {
+		t.Errorf("expected ErrMissingServiceVersion, got %v", err)
+	}
+}
+
+func TestClient_SyncConfigStore(t *testing.T) {
+	t.Parallel()
+
+	var (
+		report *SyncReport
+		err    error
+	)
+	RecordMatchBody(t, "config_store_item_sync/sync", func(c *Client) {
+		report, err = c.SyncConfigStore(context.TODO(), &SyncConfigStoreInput{
+			BatchSize: ToPointer(2),
+			Items: map[string]string{
+				"keep":   "same",
+				"change": "new",
+				"add":    "fresh",
+			},
+			StoreID: "LO53FrkHHmWWropCH2ICN2",
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &SyncReport{
+		Batches:   2,
+		Created:   []string{"add"},
+		Deleted:   []string{"remove"},
+		Unchanged: 1,
+		Updated:   []string{"change"},
+	}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+}
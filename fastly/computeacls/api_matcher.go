@@ -0,0 +1,87 @@
+package computeacls
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+	"github.com/fastly/go-fastly/v17/internal/cidr"
+)
+
+// Matcher evaluates IP addresses against the entries of a compute ACL
+// locally, without calling the API.
+//
+// Matching mirrors the Lookup() function: the entry with the longest prefix
+// containing the address is returned, or nil if no entry contains it.
+type Matcher struct {
+	trie cidr.Trie[*ComputeACLEntry]
+}
+
+// Match is the result of matching an address with a Matcher.
+type Match struct {
+	// Addr is the address that was matched.
+	Addr netip.Addr
+	// Entry is the longest-prefix entry containing Addr, or nil if no entry
+	// contains it.
+	Entry *ComputeACLEntry
+}
+
+// Blocked indicates whether the matching entry has the BLOCK action.
+func (m Match) Blocked() bool {
+	return m.Entry != nil && m.Entry.Action == ActionBlock
+}
+
+// NewMatcher builds a Matcher from a list of compute ACL entries.
+func NewMatcher(entries []ComputeACLEntry) (*Matcher, error) {
+	m := &Matcher{}
+	for _, e := range entries {
+		p, err := cidr.ParseAddrOrPrefix(e.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", fastly.ErrInvalidACLPrefix, e.Prefix)
+		}
+		m.trie.Insert(p, &e)
+	}
+	return m, nil
+}
+
+// LoadMatcherInput specifies the information needed for the LoadMatcher()
+// function to perform the operation.
+type LoadMatcherInput struct {
+	// ComputeACLID is an ACL Identifier (required).
+	ComputeACLID *string
+}
+
+// LoadMatcher retrieves all entries of a compute ACL and builds a Matcher
+// from them.
+func LoadMatcher(ctx context.Context, c *fastly.Client, i *LoadMatcherInput) (*Matcher, error) {
+	if i.ComputeACLID == nil {
+		return nil, fastly.ErrMissingComputeACLID
+	}
+
+	entries, err := listAllEntries(ctx, c, *i.ComputeACLID)
+	if err != nil {
+		return nil, err
+	}
+	return NewMatcher(entries)
+}
+
+// Len returns the number of distinct prefixes held by the matcher.
+func (m *Matcher) Len() int {
+	return m.trie.Len()
+}
+
+// Match evaluates a single address.
+func (m *Matcher) Match(addr netip.Addr) Match {
+	e, _, _ := m.trie.Lookup(addr)
+	return Match{Addr: addr, Entry: e}
+}
+
+// MatchAll evaluates each address, returning the results in the same order.
+func (m *Matcher) MatchAll(addrs []netip.Addr) []Match {
+	results := make([]Match, len(addrs))
+	for n, addr := range addrs {
+		results[n] = m.Match(addr)
+	}
+	return results
+}
@@ -0,0 +1,44 @@
+package computeacls
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewMatcher([]ComputeACLEntry{
+		{Prefix: "10.0.0.0/8", Action: ActionBlock},
+		{Prefix: "10.1.0.0/16", Action: ActionAllow},
+		{Prefix: "2001:db8::1/128", Action: ActionBlock},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		addr    string
+		prefix  string
+		blocked bool
+	}{
+		{addr: "10.2.3.4", prefix: "10.0.0.0/8", blocked: true},
+		{addr: "10.1.3.4", prefix: "10.1.0.0/16", blocked: false},
+		{addr: "2001:db8::1", prefix: "2001:db8::1/128", blocked: true},
+		{addr: "192.0.2.1"},
+	}
+	for _, tc := range tests {
+		got := m.Match(netip.MustParseAddr(tc.addr))
+		var prefix string
+		if got.Entry != nil {
+			prefix = got.Entry.Prefix
+		}
+		if prefix != tc.prefix || got.Blocked() != tc.blocked {
+			t.Errorf("Match(%s) = %q, %t; want %q, %t", tc.addr, prefix, got.Blocked(), tc.prefix, tc.blocked)
+		}
+	}
+
+	if _, err := NewMatcher([]ComputeACLEntry{{Prefix: "bogus"}}); err == nil {
+		t.Error("expected an error for an invalid prefix")
+	}
+}
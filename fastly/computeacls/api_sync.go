@@ -0,0 +1,186 @@
+package computeacls
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+	"github.com/fastly/go-fastly/v17/internal/batch"
+	"github.com/fastly/go-fastly/v17/internal/cidr"
+)
+
+const (
+	// ActionAllow is the action of a compute ACL entry that allows a prefix.
+	ActionAllow = "ALLOW"
+	// ActionBlock is the action of a compute ACL entry that blocks a prefix.
+	ActionBlock = "BLOCK"
+
+	// syncRetryBaseDelay is the delay before the first retry of a failed
+	// batch. Each subsequent retry doubles the delay.
+	syncRetryBaseDelay = time.Second
+)
+
+// SyncInput specifies the information needed for the Sync() function to
+// perform the operation.
+type SyncInput struct {
+	// Allow is the list of IPv4 or IPv6 prefixes with the ALLOW action.
+	Allow []netip.Prefix
+	// BatchSize is the maximum number of operations sent per update request
+	// (default: fastly.BatchModifyMaximumOperations).
+	BatchSize *int
+	// Block is the list of IPv4 or IPv6 prefixes with the BLOCK action.
+	Block []netip.Prefix
+	// ComputeACLID is an ACL Identifier (required).
+	ComputeACLID *string
+	// DryRun, if true, computes the changes without applying them.
+	DryRun bool
+	// MaxRetries is the number of times a failed update request is retried
+	// (default: fastly.DefaultSyncMaxRetries).
+	MaxRetries *int
+}
+
+// Sync makes the entries of a compute ACL match the given prefixes using the
+// minimal number of create, update and delete operations.
+//
+// Overlapping and adjacent prefixes with the same action are collapsed
+// first, so the ACL ends up with the smallest set of entries that matches
+// exactly the same addresses under longest-prefix-match semantics.
+func Sync(ctx context.Context, c *fastly.Client, i *SyncInput) (*fastly.SyncReport, error) {
+	if i.ComputeACLID == nil {
+		return nil, fastly.ErrMissingComputeACLID
+	}
+	size, ok := batch.Size(i.BatchSize, fastly.BatchModifyMaximumOperations)
+	if !ok {
+		return nil, fastly.ErrInvalidBatchSize
+	}
+
+	desired, err := collapsePrefixes(i.Allow, i.Block)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := listAllEntries(ctx, c, *i.ComputeACLID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, report := diffEntries(current, desired)
+	if i.DryRun {
+		report.DryRun = true
+		return report, nil
+	}
+
+	policy := batch.RetryPolicy{
+		BaseDelay:  syncRetryBaseDelay,
+		MaxRetries: fastly.DefaultSyncMaxRetries,
+		Retryable:  fastly.IsRetryableBatchError,
+	}
+	if i.MaxRetries != nil {
+		policy.MaxRetries = *i.MaxRetries
+	}
+
+	report.Batches, err = batch.Apply(ctx, changes, size, policy, func(ctx context.Context, chunk []*BatchComputeACLEntry) error {
+		return Update(ctx, c, &UpdateInput{
+			ComputeACLID: i.ComputeACLID,
+			Entries:      chunk,
+		})
+	})
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// listAllEntries follows the pagination cursor to retrieve every entry of a
+// compute ACL.
+func listAllEntries(ctx context.Context, c *fastly.Client, id string) ([]ComputeACLEntry, error) {
+	var (
+		cursor  string
+		entries []ComputeACLEntry
+	)
+	for {
+		page, err := ListEntries(ctx, c, &ListEntriesInput{
+			ComputeACLID: &id,
+			Cursor:       &cursor,
+		})
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page.Entries...)
+		if page.Meta.NextCursor == "" || page.Meta.NextCursor == cursor {
+			return entries, nil
+		}
+		cursor = page.Meta.NextCursor
+	}
+}
+
+// collapsePrefixes returns the minimal set of prefixes, mapped to their
+// action, equivalent to the given allowed and blocked prefixes.
+func collapsePrefixes(allow, block []netip.Prefix) (map[netip.Prefix]string, error) {
+	entries := make(map[netip.Prefix]string, len(allow)+len(block))
+	for _, p := range allow {
+		if !p.IsValid() {
+			return nil, fmt.Errorf("%w: %s", fastly.ErrInvalidACLPrefix, p)
+		}
+		entries[cidr.Normalize(p)] = ActionAllow
+	}
+	for _, p := range block {
+		if !p.IsValid() {
+			return nil, fmt.Errorf("%w: %s", fastly.ErrInvalidACLPrefix, p)
+		}
+		p = cidr.Normalize(p)
+		if a, ok := entries[p]; ok && a != ActionBlock {
+			return nil, fmt.Errorf("%w: %s", fastly.ErrConflictingACLPrefix, p)
+		}
+		entries[p] = ActionBlock
+	}
+
+	return cidr.Collapse(entries, nil), nil
+}
+
+// diffEntries computes the update operations needed to turn the current
+// entries into the desired prefixes, ordered as described by cidr.Diff.
+func diffEntries(current []ComputeACLEntry, desired map[netip.Prefix]string) ([]*BatchComputeACLEntry, *fastly.SyncReport) {
+	entries := make([]cidr.Entry[string], len(current))
+	for n, e := range current {
+		p, _ := cidr.ParseAddrOrPrefix(e.Prefix)
+		entries[n] = cidr.Entry[string]{Prefix: p, Value: e.Action}
+	}
+
+	diff, unchanged := cidr.Diff(entries, desired)
+	report := &fastly.SyncReport{Unchanged: unchanged}
+	changes := make([]*BatchComputeACLEntry, 0, len(diff))
+	for _, d := range diff {
+		switch d.Operation {
+		case cidr.Delete:
+			report.Deleted = append(report.Deleted, current[d.Index].Prefix)
+			changes = append(changes, &BatchComputeACLEntry{
+				Operation: fastly.ToPointer("delete"),
+				Prefix:    fastly.ToPointer(current[d.Index].Prefix),
+			})
+		case cidr.Update:
+			report.Updated = append(report.Updated, d.Prefix.String())
+			changes = append(changes, &BatchComputeACLEntry{
+				Action:    fastly.ToPointer(d.Value),
+				Operation: fastly.ToPointer("update"),
+				Prefix:    fastly.ToPointer(current[d.Index].Prefix),
+			})
+		case cidr.Create:
+			report.Created = append(report.Created, d.Prefix.String())
+			changes = append(changes, &BatchComputeACLEntry{
+				Action:    fastly.ToPointer(d.Value),
+				Operation: fastly.ToPointer("create"),
+				Prefix:    fastly.ToPointer(d.Prefix.String()),
+			})
+		}
+	}
+
+	sort.Strings(report.Created)
+	sort.Strings(report.Updated)
+	sort.Strings(report.Deleted)
+	return changes, report
+}
@@ -0,0 +1,159 @@
+package computeacls
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+func TestCollapsePrefixes(t *testing.T) {
+	t.Parallel()
+
+	got, err := collapsePrefixes(
+		[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		[]netip.Prefix{
+			netip.MustParsePrefix("10.1.0.0/17"),
+			netip.MustParsePrefix("10.1.128.0/17"),
+			netip.MustParsePrefix("10.1.2.0/24"),
+			netip.MustParsePrefix("192.0.2.0/24"),
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[netip.Prefix]string{
+		netip.MustParsePrefix("10.0.0.0/8"):   ActionAllow,
+		netip.MustParsePrefix("10.1.0.0/16"):  ActionBlock,
+		netip.MustParsePrefix("192.0.2.0/24"): ActionBlock,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected prefixes (-want +got):\n%s", diff)
+	}
+
+	_, err = collapsePrefixes(
+		[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	)
+	if !errors.Is(err, fastly.ErrConflictingACLPrefix) {
+		t.Errorf("expected ErrConflictingACLPrefix, got %v", err)
+	}
+}
+
+func TestDiffEntries(t *testing.T) {
+	t.Parallel()
+
+	current := []ComputeACLEntry{
+		{Prefix: "10.0.0.0/8", Action: ActionAllow},
+		{Prefix: "10.1.0.0/16", Action: ActionAllow},
+		{Prefix: "203.0.113.0/24", Action: ActionBlock},
+	}
+	desired := map[netip.Prefix]string{
+		netip.MustParsePrefix("10.0.0.0/8"):    ActionAllow,
+		netip.MustParsePrefix("10.1.0.0/16"):   ActionBlock,
+		netip.MustParsePrefix("2001:db8::/32"): ActionBlock,
+	}
+
+	changes, report := diffEntries(current, desired)
+
+	wantReport := &fastly.SyncReport{
+		Created:   []string{"2001:db8::/32"},
+		Deleted:   []string{"203.0.113.0/24"},
+		Unchanged: 1,
+		Updated:   []string{"10.1.0.0/16"},
+	}
+	if diff := cmp.Diff(wantReport, report); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+
+	wantChanges := []*BatchComputeACLEntry{
+		{Operation: fastly.ToPointer("delete"), Prefix: fastly.ToPointer("203.0.113.0/24")},
+		{Action: fastly.ToPointer(ActionBlock), Operation: fastly.ToPointer("update"), Prefix: fastly.ToPointer("10.1.0.0/16")},
+		{Action: fastly.ToPointer(ActionBlock), Operation: fastly.ToPointer("create"), Prefix: fastly.ToPointer("2001:db8::/32")},
+	}
+	if diff := cmp.Diff(wantChanges, changes); diff != "" {
+		t.Errorf("unexpected changes (-want +got):\n%s", diff)
+	}
+}
+
+// patchBodies records the bodies of the PATCH requests sent through it.
+type patchBodies struct {
+	bodies [][]byte
+	next   http.RoundTripper
+}
+
+func (p *patchBodies) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method == http.MethodPatch && r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		p.bodies = append(p.bodies, body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return p.next.RoundTrip(r)
+}
+
+func TestClient_Sync(t *testing.T) {
+	t.Parallel()
+
+	var (
+		batches patchBodies
+		report  *fastly.SyncReport
+		err     error
+	)
+	fastly.RecordMatchBody(t, "sync", func(c *fastly.Client) {
+		batches.next = c.HTTPClient.Transport
+		c.HTTPClient.Transport = &batches
+		report, err = Sync(context.TODO(), c, &SyncInput{
+			Allow:        []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")},
+			BatchSize:    fastly.ToPointer(2),
+			Block:        []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")},
+			ComputeACLID: fastly.ToPointer("jJ7qWmGKbpeniWBQxW5V68"),
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantReport := &fastly.SyncReport{
+		Batches: 3,
+		Created: []string{"10.0.0.0/24", "198.51.100.0/24"},
+		Deleted: []string{"10.0.0.0/25", "10.0.0.128/25", "23.23.23.23/32"},
+	}
+	if diff := cmp.Diff(wantReport, report); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+
+	// The /24 replacing the two /25 entries is created before they are
+	// deleted, so 10.0.0.0/24 stays blocked between requests.
+	var got [][]string
+	for _, body := range batches.bodies {
+		var input UpdateInput
+		if err := json.Unmarshal(body, &input); err != nil {
+			t.Fatal(err)
+		}
+		var ops []string
+		for _, e := range input.Entries {
+			ops = append(ops, *e.Operation+" "+*e.Prefix)
+		}
+		got = append(got, ops)
+	}
+	want := [][]string{
+		{"delete 23.23.23.23/32", "create 10.0.0.0/24"},
+		{"delete 10.0.0.0/25", "delete 10.0.0.128/25"},
+		{"create 198.51.100.0/24"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected batches (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,73 @@
+package fastly
+
+import (
+	"context"
+
+	"github.com/fastly/go-fastly/v17/internal/batch"
+)
+
+// SyncConfigStoreInput is the input to the SyncConfigStore function.
+type SyncConfigStoreInput struct {
+	// BatchSize is the maximum number of operations sent per batch request
+	// (default: BatchModifyMaximumOperations).
+	BatchSize *int
+	// DryRun, if true, computes the changes without applying them.
+	DryRun bool
+	// Items is the desired content of the config store. Keys not present in
+	// Items are deleted from the store.
+	Items map[string]string
+	// MaxRetries is the number of times a failed batch request is retried
+	// (default: DefaultSyncMaxRetries).
+	MaxRetries *int
+	// StoreID is the ID of the config store (required).
+	StoreID string
+}
+
+// SyncConfigStore makes the content of a config store match the given items
+// using the minimal number of create, update and delete operations.
+func (c *Client) SyncConfigStore(ctx context.Context, i *SyncConfigStoreInput) (*SyncReport, error) {
+	if i.StoreID == "" {
+		return nil, ErrMissingStoreID
+	}
+	size, err := syncBatchSize(i.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := c.ListConfigStoreItems(ctx, &ListConfigStoreItemsInput{
+		StoreID: i.StoreID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]string, len(items))
+	for _, item := range items {
+		current[item.Key] = item.Value
+	}
+
+	changes, report := diffStringMaps(current, i.Items)
+	if i.DryRun {
+		report.DryRun = true
+		return report, nil
+	}
+
+	report.Batches, err = batch.Apply(ctx, changes, size, newSyncRetryPolicy(i.MaxRetries), func(ctx context.Context, chunk []syncChange) error {
+		batchItems := make([]*BatchConfigStoreItem, 0, len(chunk))
+		for _, change := range chunk {
+			batchItems = append(batchItems, &BatchConfigStoreItem{
+				ItemKey:   change.Key,
+				ItemValue: change.Value,
+				Operation: change.Operation,
+			})
+		}
+		return c.BatchModifyConfigStoreItems(ctx, &BatchModifyConfigStoreItemsInput{
+			Items:   batchItems,
+			StoreID: i.StoreID,
+		})
+	})
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
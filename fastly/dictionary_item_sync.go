@@ -0,0 +1,103 @@
+package fastly
+
+import (
+	"context"
+
+	"github.com/fastly/go-fastly/v17/internal/batch"
+)
+
+// SyncDictionaryInput is the input to the SyncDictionary function.
+type SyncDictionaryInput struct {
+	// BatchSize is the maximum number of operations sent per batch request
+	// (default: BatchModifyMaximumOperations).
+	BatchSize *int
+	// DictionaryID is the ID of the dictionary (required).
+	DictionaryID string
+	// DryRun, if true, computes the changes without applying them.
+	DryRun bool
+	// Items is the desired content of the dictionary. Keys not present in
+	// Items are deleted from the dictionary.
+	Items map[string]string
+	// MaxRetries is the number of times a failed batch request is retried
+	// (default: DefaultSyncMaxRetries).
+	MaxRetries *int
+	// ServiceID is the ID of the service (required).
+	ServiceID string
+	// ServiceVersion is a configuration version containing the dictionary,
+	// used to check that the dictionary is not write-only (required).
+	ServiceVersion int
+}
+
+// SyncDictionary makes the content of a dictionary match the given items
+// using the minimal number of create, update and delete operations.
+//
+// The items of write-only dictionaries cannot be read, so they cannot be
+// synced: ErrDictionaryWriteOnly is returned for them.
+func (c *Client) SyncDictionary(ctx context.Context, i *SyncDictionaryInput) (*SyncReport, error) {
+	if i.DictionaryID == "" {
+		return nil, ErrMissingDictionaryID
+	}
+	if i.ServiceID == "" {
+		return nil, ErrMissingServiceID
+	}
+	if i.ServiceVersion == 0 {
+		return nil, ErrMissingServiceVersion
+	}
+	if len(i.Items) > MaximumDictionarySize {
+		return nil, ErrMaxExceededDictionarySize
+	}
+	size, err := syncBatchSize(i.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	dictionary, err := c.findDictionary(ctx, i.ServiceID, i.ServiceVersion, i.DictionaryID)
+	if err != nil {
+		return nil, err
+	}
+	if ToValue(dictionary.WriteOnly) {
+		return nil, ErrDictionaryWriteOnly
+	}
+
+	items, err := c.ListDictionaryItems(ctx, &ListDictionaryItemsInput{
+		DictionaryID: i.DictionaryID,
+		ServiceID:    i.ServiceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]string, len(items))
+	for _, item := range items {
+		current[ToValue(item.ItemKey)] = ToValue(item.ItemValue)
+	}
+
+	changes, report := diffStringMaps(current, i.Items)
+	if i.DryRun {
+		report.DryRun = true
+		return report, nil
+	}
+
+	report.Batches, err = batch.Apply(ctx, changes, size, newSyncRetryPolicy(i.MaxRetries), func(ctx context.Context, chunk []syncChange) error {
+		batchItems := make([]*BatchDictionaryItem, 0, len(chunk))
+		for _, change := range chunk {
+			item := &BatchDictionaryItem{
+				ItemKey:   ToPointer(change.Key),
+				Operation: ToPointer(change.Operation),
+			}
+			if change.Operation != DeleteBatchOperation {
+				item.ItemValue = ToPointer(change.Value)
+			}
+			batchItems = append(batchItems, item)
+		}
+		return c.BatchModifyDictionaryItems(ctx, &BatchModifyDictionaryItemsInput{
+			DictionaryID: i.DictionaryID,
+			Items:        batchItems,
+			ServiceID:    i.ServiceID,
+		})
+	})
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
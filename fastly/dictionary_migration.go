@@ -0,0 +1,225 @@
+package fastly
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// MigrateDictionaryToConfigStoreInput is used as input to the
+// MigrateDictionaryToConfigStore function.
+type MigrateDictionaryToConfigStoreInput struct {
+	// BatchSize is the maximum number of items copied per batch request
+	// (default: BatchModifyMaximumOperations).
+	BatchSize *int
+	// DeleteDictionary, if true, deletes the dictionary from the cloned
+	// version the config store is linked to.
+	DeleteDictionary bool
+	// DictionaryID is the ID of the dictionary to migrate (required).
+	DictionaryID string
+	// LinkName is the name the config store is linked to the service under
+	// (default: the dictionary name). Unless DeleteDictionary is set, it must
+	// differ from the dictionary name.
+	LinkName *string
+	// MaxRetries is the number of times a failed batch request is retried
+	// (default: DefaultSyncMaxRetries).
+	MaxRetries *int
+	// ServiceID is the ID of the service (required).
+	ServiceID string
+	// ServiceVersion is the configuration version holding the dictionary. It
+	// is cloned, and left unchanged (required).
+	ServiceVersion int
+	// StoreName is the name of the config store to create (default: the
+	// dictionary name).
+	StoreName *string
+}
+
+// DictionaryMigration describes the outcome of MigrateDictionaryToConfigStore.
+type DictionaryMigration struct {
+	// ConfigStore is the config store that was created.
+	ConfigStore *ConfigStore
+	// Copy is the report of the items copied into the config store.
+	Copy *SyncReport
+	// Dictionary is the dictionary that was migrated.
+	Dictionary *Dictionary
+	// ItemCount is the number of items in the dictionary.
+	ItemCount int
+	// ItemsHash is a SHA-256 digest of the dictionary items, as computed by
+	// HashItems, which the config store items were verified against.
+	ItemsHash string
+	// Resource is the link between the config store and Version.
+	Resource *Resource
+	// Version is the clone of ServiceVersion the config store is linked to,
+	// and the dictionary deleted from if DeleteDictionary was set.
+	Version *Version
+}
+
+// MigrateDictionaryToConfigStore copies the items of an edge dictionary into a
+// new config store and links the store to a clone of the service version.
+//
+// The items are copied in batches, then read back and compared with the
+// dictionary by count and by HashItems. If they do not match,
+// ErrMigrationVerificationFailed is returned along with the partial result and
+// no version is cloned. The config store is not deleted on failure, so it can
+// be inspected.
+//
+// Once verified, ServiceVersion is cloned, the dictionary is deleted from the
+// clone if DeleteDictionary is set, and the config store is then linked to
+// the clone. The link and the dictionary are never in the same version under
+// the same name, which the API would reject.
+func (c *Client) MigrateDictionaryToConfigStore(ctx context.Context, i *MigrateDictionaryToConfigStoreInput) (*DictionaryMigration, error) {
+	if i.DictionaryID == "" {
+		return nil, ErrMissingDictionaryID
+	}
+	if i.ServiceID == "" {
+		return nil, ErrMissingServiceID
+	}
+	if i.ServiceVersion == 0 {
+		return nil, ErrMissingServiceVersion
+	}
+
+	dictionary, err := c.findDictionary(ctx, i.ServiceID, i.ServiceVersion, i.DictionaryID)
+	if err != nil {
+		return nil, err
+	}
+	if ToValue(dictionary.WriteOnly) {
+		return nil, ErrDictionaryWriteOnly
+	}
+	name := ToValue(dictionary.Name)
+	linkName := name
+	if i.LinkName != nil {
+		linkName = *i.LinkName
+	}
+	if linkName == name && !i.DeleteDictionary {
+		return nil, fmt.Errorf("%w: %q", ErrMigrationLinkNameConflict, name)
+	}
+
+	dictItems, err := c.ListDictionaryItems(ctx, &ListDictionaryItemsInput{
+		DictionaryID: i.DictionaryID,
+		ServiceID:    i.ServiceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[string]string, len(dictItems))
+	for _, item := range dictItems {
+		items[ToValue(item.ItemKey)] = ToValue(item.ItemValue)
+	}
+
+	result := &DictionaryMigration{
+		Dictionary: dictionary,
+		ItemCount:  len(items),
+		ItemsHash:  HashItems(items),
+	}
+
+	storeName := name
+	if i.StoreName != nil {
+		storeName = *i.StoreName
+	}
+	result.ConfigStore, err = c.CreateConfigStore(ctx, &CreateConfigStoreInput{
+		Name: storeName,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Copy, err = c.SyncConfigStore(ctx, &SyncConfigStoreInput{
+		BatchSize:  i.BatchSize,
+		Items:      items,
+		MaxRetries: i.MaxRetries,
+		StoreID:    result.ConfigStore.StoreID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	storeItems, err := c.ListConfigStoreItems(ctx, &ListConfigStoreItemsInput{
+		StoreID: result.ConfigStore.StoreID,
+	})
+	if err != nil {
+		return result, err
+	}
+	copied := make(map[string]string, len(storeItems))
+	for _, item := range storeItems {
+		copied[item.Key] = item.Value
+	}
+	if len(copied) != result.ItemCount {
+		return result, fmt.Errorf("%w: dictionary has %d items, config store has %d", ErrMigrationVerificationFailed, result.ItemCount, len(copied))
+	}
+	if h := HashItems(copied); h != result.ItemsHash {
+		return result, fmt.Errorf("%w: dictionary hash %s, config store hash %s", ErrMigrationVerificationFailed, result.ItemsHash, h)
+	}
+
+	result.Version, err = c.CloneVersion(ctx, &CloneVersionInput{
+		ServiceID:      i.ServiceID,
+		ServiceVersion: i.ServiceVersion,
+	})
+	if err != nil {
+		return result, err
+	}
+	version := ToValue(result.Version.Number)
+
+	if i.DeleteDictionary {
+		err = c.DeleteDictionary(ctx, &DeleteDictionaryInput{
+			Name:           name,
+			ServiceID:      i.ServiceID,
+			ServiceVersion: version,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	result.Resource, err = c.CreateResource(ctx, &CreateResourceInput{
+		Name:           &linkName,
+		ResourceID:     &result.ConfigStore.StoreID,
+		ServiceID:      i.ServiceID,
+		ServiceVersion: version,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// findDictionary returns the dictionary with the given ID in a service
+// version.
+func (c *Client) findDictionary(ctx context.Context, serviceID string, serviceVersion int, dictionaryID string) (*Dictionary, error) {
+	dictionaries, err := c.ListDictionaries(ctx, &ListDictionariesInput{
+		ServiceID:      serviceID,
+		ServiceVersion: serviceVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dictionaries {
+		if ToValue(d.DictionaryID) == dictionaryID {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("dictionary %s not found in service %s version %d", dictionaryID, serviceID, serviceVersion)
+}
+
+// HashItems returns a hex-encoded SHA-256 digest of a set of key/value items
+// that does not depend on their order. It can be used to compare the content
+// of dictionaries and config stores.
+func HashItems(items map[string]string) string {
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		// Length-prefix each field so that no two distinct sets of items
+		// produce the same input.
+		v := items[k]
+		h.Write([]byte(strconv.Itoa(len(k)) + ":" + k + strconv.Itoa(len(v)) + ":" + v))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
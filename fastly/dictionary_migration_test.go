@@ -0,0 +1,111 @@
+package fastly
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHashItems(t *testing.T) {
+	t.Parallel()
+
+	a := HashItems(map[string]string{"a": "1", "b": "2"})
+	if b := HashItems(map[string]string{"b": "2", "a": "1"}); a != b {
+		t.Errorf("hash depends on insertion order: %s != %s", a, b)
+	}
+	if HashItems(nil) != HashItems(map[string]string{}) {
+		t.Error("nil and empty maps hash differently")
+	}
+
+	distinct := []map[string]string{
+		{"a": "1", "b": "2"},
+		{"a": "1", "b": "3"},
+		{"a": "1"},
+		{"ab": "", "c": ""},
+		{"a": "bc"},
+		{"a": "b", "c": ""},
+		{},
+	}
+	seen := make(map[string]int)
+	for n, items := range distinct {
+		h := HashItems(items)
+		if prev, ok := seen[h]; ok {
+			t.Errorf("items %d and %d have the same hash %s", prev, n, h)
+		}
+		seen[h] = n
+	}
+}
+
+func TestClient_MigrateDictionaryToConfigStore_validation(t *testing.T) {
+	t.Parallel()
+
+	_, err := TestClient.MigrateDictionaryToConfigStore(context.TODO(), &MigrateDictionaryToConfigStoreInput{
+		ServiceID:      "foo",
+		ServiceVersion: 1,
+	})
+	if !errors.Is(err, ErrMissingDictionaryID) {
+		t.Errorf("bad error: %s", err)
+	}
+
+	_, err = TestClient.MigrateDictionaryToConfigStore(context.TODO(), &MigrateDictionaryToConfigStoreInput{
+		DictionaryID:   "bar",
+		ServiceVersion: 1,
+	})
+	if !errors.Is(err, ErrMissingServiceID) {
+		t.Errorf("bad error: %s", err)
+	}
+
+	_, err = TestClient.MigrateDictionaryToConfigStore(context.TODO(), &MigrateDictionaryToConfigStoreInput{
+		DictionaryID: "bar",
+		ServiceID:    "foo",
+	})
+	if !errors.Is(err, ErrMissingServiceVersion) {
+		t.Errorf("bad error: %s", err)
+	}
+}
+
+func TestClient_MigrateDictionaryToConfigStore(t *testing.T) {
+	t.Parallel()
+
+	var (
+		migration *DictionaryMigration
+		err       error
+	)
+	RecordMatchBody(t, "dictionary_migration/migrate", func(c *Client) {
+		migration, err = c.MigrateDictionaryToConfigStore(context.TODO(), &MigrateDictionaryToConfigStoreInput{
+			DeleteDictionary: true,
+			DictionaryID:     "kL8Xhq4rwcwSQDgKqb5Yn1",
+			ServiceID:        "7i6HN3TK9wS159v2gPAZ8A",
+			ServiceVersion:   3,
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migration.ItemCount != 2 || migration.ItemsHash != HashItems(map[string]string{"/a": "/b", "/old": "/new"}) {
+		t.Errorf("bad items: %d %s", migration.ItemCount, migration.ItemsHash)
+	}
+	if migration.ConfigStore.StoreID != "pS86koqiZWkt6VJgvNuUe3" || len(migration.Copy.Created) != 2 {
+		t.Errorf("bad copy: %+v", migration.Copy)
+	}
+	// The store is linked to the clone the dictionary was deleted from, not
+	// to the version still holding the dictionary.
+	if got := ToValue(migration.Version.Number); got != 4 {
+		t.Errorf("bad version: %d", got)
+	}
+	if got := ToValue(migration.Resource.ServiceVersion); got != 4 || ToValue(migration.Resource.Name) != "redirects" {
+		t.Errorf("bad resource: version %d, name %q", got, ToValue(migration.Resource.Name))
+	}
+
+	// Keeping the dictionary requires another link name.
+	RecordMatchBody(t, "dictionary_migration/link_conflict", func(c *Client) {
+		_, err = c.MigrateDictionaryToConfigStore(context.TODO(), &MigrateDictionaryToConfigStoreInput{
+			DictionaryID:   "kL8Xhq4rwcwSQDgKqb5Yn1",
+			ServiceID:      "7i6HN3TK9wS159v2gPAZ8A",
+			ServiceVersion: 3,
+		})
+	})
+	if !errors.Is(err, ErrMigrationLinkNameConflict) {
+		t.Errorf("expected ErrMigrationLinkNameConflict, got %v", err)
+	}
+}
@@ -0,0 +1,364 @@
+package fastly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDynamicSnippetHistory is the default number of revisions a
+	// DynamicSnippetManager keeps per snippet.
+	DefaultDynamicSnippetHistory = 20
+	// DefaultDynamicSnippetMaxSize is the default largest content, in bytes,
+	// a DynamicSnippetManager pushes.
+	DefaultDynamicSnippetMaxSize = 1 << 20
+	// DefaultDynamicSnippetRollbackTimeout is the default time a
+	// DynamicSnippetManager allows for rolling back a failed Apply.
+	DefaultDynamicSnippetRollbackTimeout = 30 * time.Second
+)
+
+// DynamicSnippetRevision is a prior content of a dynamic snippet.
+type DynamicSnippetRevision struct {
+	// Content is the content of the snippet.
+	Content string `json:"content"`
+	// ReplacedAt is when the content was replaced.
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// DynamicSnippetHistoryStore persists the history of dynamic snippets.
+type DynamicSnippetHistoryStore interface {
+	// Load returns the revisions of a snippet, oldest first, or nil if there
+	// are none.
+	Load(ctx context.Context, serviceID, snippetID string) ([]DynamicSnippetRevision, error)
+	// Save replaces the revisions of a snippet.
+	Save(ctx context.Context, serviceID, snippetID string, revisions []DynamicSnippetRevision) error
+}
+
+// MemoryDynamicSnippetHistoryStore keeps the history of dynamic snippets in
+// memory. The zero value is ready to use.
+type MemoryDynamicSnippetHistoryStore struct {
+	mu        sync.Mutex
+	revisions map[string][]DynamicSnippetRevision
+}
+
+// Load implements DynamicSnippetHistoryStore.
+func (s *MemoryDynamicSnippetHistoryStore) Load(_ context.Context, serviceID, snippetID string) ([]DynamicSnippetRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.revisions[serviceID+"/"+snippetID]), nil
+}
+
+// Save implements DynamicSnippetHistoryStore.
+func (s *MemoryDynamicSnippetHistoryStore) Save(_ context.Context, serviceID, snippetID string, revisions []DynamicSnippetRevision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.revisions == nil {
+		s.revisions = make(map[string][]DynamicSnippetRevision)
+	}
+	s.revisions[serviceID+"/"+snippetID] = slices.Clone(revisions)
+	return nil
+}
+
+// FileDynamicSnippetHistoryStore stores the history of each dynamic snippet
+// as a JSON file named <Dir>/<service ID>/<snippet ID>.json.
+type FileDynamicSnippetHistoryStore struct {
+	// Dir is the directory of the history files.
+	Dir string
+}
+
+// path returns the path of the history file of a snippet.
+func (s *FileDynamicSnippetHistoryStore) path(serviceID, snippetID string) string {
+	return filepath.Join(s.Dir, filepath.Base(serviceID), filepath.Base(snippetID)+".json")
+}
+
+// Load implements DynamicSnippetHistoryStore. A missing file means there is
+// no history.
+func (s *FileDynamicSnippetHistoryStore) Load(_ context.Context, serviceID, snippetID string) ([]DynamicSnippetRevision, error) {
+	path := s.path(serviceID, snippetID)
+	// #nosec G304 -- the history directory is chosen by the caller
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var revisions []DynamicSnippetRevision
+	if err := json.Unmarshal(data, &revisions); err != nil {
+		return nil, fmt.Errorf("reading snippet history %s: %w", path, err)
+	}
+	return revisions, nil
+}
+
+// Save implements DynamicSnippetHistoryStore. The file is replaced
+// atomically.
+func (s *FileDynamicSnippetHistoryStore) Save(_ context.Context, serviceID, snippetID string, revisions []DynamicSnippetRevision) error {
+	path := s.path(serviceID, snippetID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	data, err := json.Marshal(revisions)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// DynamicSnippetValidator checks the content of a dynamic snippet before it is
+// pushed. The vcl package provides a parser-based validator.
+type DynamicSnippetValidator func(content string) error
+
+// DynamicSnippetSizeValidator returns a validator rejecting content larger
+// than maxSize bytes.
+func DynamicSnippetSizeValidator(maxSize int) DynamicSnippetValidator {
+	return func(content string) error {
+		if len(content) > maxSize {
+			return fmt.Errorf("snippet content is %d bytes, more than the maximum of %d", len(content), maxSize)
+		}
+		return nil
+	}
+}
+
+// DynamicSnippetUpdate is a change of content of a dynamic snippet.
+type DynamicSnippetUpdate struct {
+	// Content is the new content of the snippet.
+	Content string
+	// SnippetID is the ID of the snippet (required).
+	SnippetID string
+}
+
+// DynamicSnippetUpdateError is returned when one of the updates applied by a
+// DynamicSnippetManager fails after others succeeded.
+type DynamicSnippetUpdateError struct {
+	// Err is the error of the failed update.
+	Err error
+	// RollbackErr is the error restoring the snippets updated before the
+	// failure, or nil if they were all restored.
+	RollbackErr error
+	// RolledBack are the IDs of the snippets restored to their prior content.
+	RolledBack []string
+	// SnippetID is the ID of the snippet whose update failed.
+	SnippetID string
+}
+
+// Error implements the error interface.
+func (e *DynamicSnippetUpdateError) Error() string {
+	msg := fmt.Sprintf("updating dynamic snippet %s: %v", e.SnippetID, e.Err)
+	if e.RollbackErr != nil {
+		msg += fmt.Sprintf(" (rollback failed: %v)", e.RollbackErr)
+	}
+	return msg
+}
+
+// Unwrap returns the errors of the update and of the rollback.
+func (e *DynamicSnippetUpdateError) Unwrap() []error {
+	return []error{e.Err, e.RollbackErr}
+}
+
+// DynamicSnippetManager updates the dynamic snippets of a service while
+// keeping a history of their prior content, so that changes, which do not
+// create a new service version, can be rolled back.
+//
+// The fields may be changed before first use. The methods of a manager are
+// safe for concurrent use and run one at a time.
+type DynamicSnippetManager struct {
+	// MaxHistory is the number of revisions kept per snippet (default:
+	// DefaultDynamicSnippetHistory).
+	MaxHistory int
+	// RollbackTimeout bounds the rollback of a failed Apply, which runs even
+	// if the context of Apply is cancelled (default:
+	// DefaultDynamicSnippetRollbackTimeout).
+	RollbackTimeout time.Duration
+	// ServiceID is the ID of the service of the snippets.
+	ServiceID string
+	// Store stores the prior contents of each snippet (default: a
+	// MemoryDynamicSnippetHistoryStore).
+	Store DynamicSnippetHistoryStore
+	// Validate checks the content of each update before anything is pushed
+	// (default: DynamicSnippetSizeValidator(DefaultDynamicSnippetMaxSize)).
+	Validate DynamicSnippetValidator
+
+	client *Client
+	mu     sync.Mutex
+}
+
+// NewDynamicSnippetManager returns a manager for the dynamic snippets of a
+// service, with an in-memory history and a size check.
+func NewDynamicSnippetManager(c *Client, serviceID string) *DynamicSnippetManager {
+	return &DynamicSnippetManager{
+		MaxHistory:      DefaultDynamicSnippetHistory,
+		RollbackTimeout: DefaultDynamicSnippetRollbackTimeout,
+		ServiceID:       serviceID,
+		Store:           &MemoryDynamicSnippetHistoryStore{},
+		Validate:        DynamicSnippetSizeValidator(DefaultDynamicSnippetMaxSize),
+		client:          c,
+	}
+}
+
+// Update validates and pushes the content of one dynamic snippet.
+func (m *DynamicSnippetManager) Update(ctx context.Context, snippetID, content string) (*DynamicSnippet, error) {
+	snippets, err := m.Apply(ctx, DynamicSnippetUpdate{Content: content, SnippetID: snippetID})
+	if err != nil {
+		return nil, err
+	}
+	return snippets[0], nil
+}
+
+// Apply validates and pushes several dynamic snippets as a unit.
+//
+// Every content is validated before anything is pushed. The prior content of
+// each snippet is then read and the updates are pushed in order. If one fails,
+// the snippets already updated are restored to their prior content, in
+// reverse order, and a *DynamicSnippetUpdateError is returned. Once every
+// update succeeded, the prior contents are added to the history; if that
+// fails, the updated snippets are returned along with the error.
+func (m *DynamicSnippetManager) Apply(ctx context.Context, updates ...DynamicSnippetUpdate) ([]*DynamicSnippet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.apply(ctx, updates, true)
+}
+
+// apply implements Apply, validating the contents if validate is true.
+func (m *DynamicSnippetManager) apply(ctx context.Context, updates []DynamicSnippetUpdate, validate bool) ([]*DynamicSnippet, error) {
+	if m.ServiceID == "" {
+		return nil, ErrMissingServiceID
+	}
+	for _, u := range updates {
+		if u.SnippetID == "" {
+			return nil, ErrMissingSnippetID
+		}
+		if validate && m.Validate != nil {
+			if err := m.Validate(u.Content); err != nil {
+				return nil, fmt.Errorf("validating dynamic snippet %s: %w", u.SnippetID, err)
+			}
+		}
+	}
+
+	prior := make([]string, len(updates))
+	for n, u := range updates {
+		ds, err := m.client.GetDynamicSnippet(ctx, &GetDynamicSnippetInput{ServiceID: m.ServiceID, SnippetID: u.SnippetID})
+		if err != nil {
+			return nil, err
+		}
+		prior[n] = ToValue(ds.Content)
+	}
+
+	snippets := make([]*DynamicSnippet, 0, len(updates))
+	for n, u := range updates {
+		ds, err := m.client.UpdateDynamicSnippet(ctx, &UpdateDynamicSnippetInput{
+			Content:   ToPointer(u.Content),
+			ServiceID: m.ServiceID,
+			SnippetID: u.SnippetID,
+		})
+		if err != nil {
+			uerr := &DynamicSnippetUpdateError{Err: err, SnippetID: u.SnippetID}
+			uerr.RolledBack, uerr.RollbackErr = m.rollback(ctx, updates[:n], prior)
+			return nil, uerr
+		}
+		snippets = append(snippets, ds)
+	}
+
+	now := time.Now()
+	for n, u := range updates {
+		if prior[n] == u.Content {
+			continue
+		}
+		if err := m.record(ctx, u.SnippetID, DynamicSnippetRevision{Content: prior[n], ReplacedAt: now}); err != nil {
+			return snippets, fmt.Errorf("recording the history of dynamic snippet %s: %w", u.SnippetID, err)
+		}
+	}
+	return snippets, nil
+}
+
+// rollback restores the prior content of the snippets already updated, most
+// recent first, returning the IDs of those restored. It runs even if ctx is
+// cancelled, so that a cancelled Apply does not leave a partial update, and
+// is bounded by RollbackTimeout instead.
+func (m *DynamicSnippetManager) rollback(ctx context.Context, updated []DynamicSnippetUpdate, prior []string) ([]string, error) {
+	timeout := m.RollbackTimeout
+	if timeout <= 0 {
+		timeout = DefaultDynamicSnippetRollbackTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	var (
+		errs       []error
+		rolledBack []string
+	)
+	for k := len(updated) - 1; k >= 0; k-- {
+		id := updated[k].SnippetID
+		_, err := m.client.UpdateDynamicSnippet(ctx, &UpdateDynamicSnippetInput{
+			Content:   ToPointer(prior[k]),
+			ServiceID: m.ServiceID,
+			SnippetID: id,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("restoring dynamic snippet %s: %w", id, err))
+			continue
+		}
+		rolledBack = append(rolledBack, id)
+	}
+	return rolledBack, errors.Join(errs...)
+}
+
+// record adds a revision to the history of a snippet, dropping the oldest
+// revisions beyond MaxHistory.
+func (m *DynamicSnippetManager) record(ctx context.Context, snippetID string, rev DynamicSnippetRevision) error {
+	revisions, err := m.Store.Load(ctx, m.ServiceID, snippetID)
+	if err != nil {
+		return err
+	}
+	revisions = append(revisions, rev)
+	limit := m.MaxHistory
+	if limit <= 0 {
+		limit = DefaultDynamicSnippetHistory
+	}
+	if len(revisions) > limit {
+		revisions = revisions[len(revisions)-limit:]
+	}
+	return m.Store.Save(ctx, m.ServiceID, snippetID, revisions)
+}
+
+// History returns the prior contents of a snippet, oldest first.
+func (m *DynamicSnippetManager) History(ctx context.Context, snippetID string) ([]DynamicSnippetRevision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if snippetID == "" {
+		return nil, ErrMissingSnippetID
+	}
+	return m.Store.Load(ctx, m.ServiceID, snippetID)
+}
+
+// Revert restores the content a snippet had n updates ago: 1 undoes the most
+// recent update. The content being replaced is added to the history, so that
+// a revert can itself be reverted. It returns ErrNoDynamicSnippetRevision if
+// the history holds fewer than n revisions.
+func (m *DynamicSnippetManager) Revert(ctx context.Context, snippetID string, n int) (*DynamicSnippet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if snippetID == "" {
+		return nil, ErrMissingSnippetID
+	}
+	revisions, err := m.Store.Load(ctx, m.ServiceID, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(revisions) {
+		return nil, ErrNoDynamicSnippetRevision
+	}
+	// The content was valid when it was replaced, so it is not validated
+	// again: a stricter validator must not prevent a rollback.
+	snippets, err := m.apply(ctx, []DynamicSnippetUpdate{{Content: revisions[len(revisions)-n].Content, SnippetID: snippetID}}, false)
+	if err != nil {
+		return nil, err
+	}
+	return snippets[0], nil
+}
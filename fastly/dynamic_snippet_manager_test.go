@@ -0,0 +1,229 @@
+package fastly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// The IDs of the dynamic snippets of the fixtures.
+const (
+	testSnippetID1 = "3Yx7pKqNbT2mWz9LcVd4Rh"
+	testSnippetID2 = "5GfT1nQwLp8XvKc3RzMb7J"
+	testSnippetID3 = "8KdR4sVxHq6NtBm2WpLc9F"
+)
+
+// testRelease returns the content of a dynamic snippet of the fixtures.
+func testRelease(release string) string {
+	return `set req.http.X-Release = "` + release + `";`
+}
+
+// cancelTransport cancels a context when an update is sent to path, and fails
+// that request as the cancellation would.
+type cancelTransport struct {
+	cancel context.CancelFunc
+	next   http.RoundTripper
+	path   string
+}
+
+func (t *cancelTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method == http.MethodPut && r.URL.Path == t.path {
+		t.cancel()
+		return nil, r.Context().Err()
+	}
+	return t.next.RoundTrip(r)
+}
+
+// revisionContents returns the contents of revisions.
+func revisionContents(revisions []DynamicSnippetRevision) []string {
+	var contents []string
+	for _, rev := range revisions {
+		contents = append(contents, rev.Content)
+	}
+	return contents
+}
+
+func TestDynamicSnippetManagerRevert(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var (
+		reverted, revertedTwice *DynamicSnippet
+		history                 []DynamicSnippetRevision
+		err                     error
+	)
+	// The snippet holds v0 and is updated to v1, v2, v2 again and v3, then
+	// reverted two updates back to v1, and reverted once more back to v3.
+	RecordMatchBody(t, "dynamic_snippet_manager/revert", func(c *Client) {
+		m := NewDynamicSnippetManager(c, "7i6HN3TK9wS159v2gPAZ8A")
+		for _, release := range []string{"v1", "v2", "v2", "v3"} {
+			if _, err = m.Update(ctx, testSnippetID1, testRelease(release)); err != nil {
+				return
+			}
+		}
+		// Pushing unchanged content does not add a revision.
+		if history, err = m.History(ctx, testSnippetID1); err != nil {
+			return
+		}
+		if reverted, err = m.Revert(ctx, testSnippetID1, 2); err != nil {
+			return
+		}
+		// The reverted content is itself in the history.
+		if revertedTwice, err = m.Revert(ctx, testSnippetID1, 1); err != nil {
+			return
+		}
+		if _, err := m.Revert(ctx, testSnippetID1, 10); !errors.Is(err, ErrNoDynamicSnippetRevision) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{testRelease("v0"), testRelease("v1"), testRelease("v2")}, revisionContents(history)); diff != "" {
+		t.Errorf("bad history: %s", diff)
+	}
+	if got := ToValue(reverted.Content); got != testRelease("v1") {
+		t.Errorf("bad revert: %q", got)
+	}
+	if got := ToValue(revertedTwice.Content); got != testRelease("v3") {
+		t.Errorf("bad revert of the revert: %q", got)
+	}
+
+	if _, err := NewDynamicSnippetManager(TestClient, "7i6HN3TK9wS159v2gPAZ8A").Revert(ctx, "", 1); !errors.Is(err, ErrMissingSnippetID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDynamicSnippetManagerMaxHistory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := &FileDynamicSnippetHistoryStore{Dir: t.TempDir()}
+	start := time.Now()
+	var err error
+	// The snippet holds v0 and is updated to v1, v2 and v3.
+	RecordMatchBody(t, "dynamic_snippet_manager/max_history", func(c *Client) {
+		m := NewDynamicSnippetManager(c, "7i6HN3TK9wS159v2gPAZ8A")
+		m.MaxHistory = 2
+		m.Store = store
+		for _, release := range []string{"v1", "v2", "v3"} {
+			if _, err = m.Update(ctx, testSnippetID1, testRelease(release)); err != nil {
+				return
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := store.Load(ctx, "7i6HN3TK9wS159v2gPAZ8A", testSnippetID1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{testRelease("v1"), testRelease("v2")}, revisionContents(history)); diff != "" {
+		t.Errorf("bad history: %s", diff)
+	}
+	for _, rev := range history {
+		if rev.ReplacedAt.Before(start) || rev.ReplacedAt.After(time.Now()) {
+			t.Errorf("bad replacement time %v", rev.ReplacedAt)
+		}
+	}
+}
+
+func TestDynamicSnippetManagerApplyRollback(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	updates := []DynamicSnippetUpdate{
+		{Content: testRelease("a1"), SnippetID: testSnippetID1},
+		{Content: testRelease("b1"), SnippetID: testSnippetID2},
+		{Content: testRelease("c1"), SnippetID: testSnippetID3},
+	}
+	var (
+		first, second error
+		history       []DynamicSnippetRevision
+	)
+	// The snippets hold a0, b0 and c0. The update of the third is rejected
+	// twice. The first time, the first two are restored; the second time,
+	// restoring the first fails with a 503.
+	RecordMatchBody(t, "dynamic_snippet_manager/rollback", func(c *Client) {
+		m := NewDynamicSnippetManager(c, "7i6HN3TK9wS159v2gPAZ8A")
+		_, first = m.Apply(ctx, updates...)
+		history, _ = m.History(ctx, testSnippetID1)
+		_, second = m.Apply(ctx, updates...)
+	})
+
+	var uerr *DynamicSnippetUpdateError
+	var herr *HTTPError
+	if !errors.As(first, &uerr) || uerr.SnippetID != testSnippetID3 || uerr.RollbackErr != nil || !errors.As(first, &herr) || herr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected error: %v", first)
+	}
+	if diff := cmp.Diff([]string{testSnippetID2, testSnippetID1}, uerr.RolledBack); diff != "" {
+		t.Errorf("bad rollback: %s", diff)
+	}
+	if len(history) != 0 {
+		t.Errorf("a failed update was recorded: %v", history)
+	}
+
+	// A failed rollback is reported too.
+	if !errors.As(second, &uerr) || uerr.RollbackErr == nil {
+		t.Fatalf("unexpected error: %v", second)
+	}
+	if diff := cmp.Diff([]string{testSnippetID2}, uerr.RolledBack); diff != "" {
+		t.Errorf("bad rollback: %s", diff)
+	}
+	if !strings.Contains(second.Error(), "(rollback failed: restoring dynamic snippet "+testSnippetID1+": ") {
+		t.Errorf("bad message: %s", second)
+	}
+}
+
+func TestDynamicSnippetManagerApplyRollbackCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var err error
+	// The snippets hold a0 and b0. The context is cancelled as the second
+	// update is sent: the first is still rolled back.
+	RecordMatchBody(t, "dynamic_snippet_manager/rollback_cancelled", func(c *Client) {
+		c.HTTPClient.Transport = &cancelTransport{cancel: cancel, next: c.HTTPClient.Transport, path: "/service/7i6HN3TK9wS159v2gPAZ8A/snippet/" + testSnippetID2}
+		_, err = NewDynamicSnippetManager(c, "7i6HN3TK9wS159v2gPAZ8A").Apply(ctx,
+			DynamicSnippetUpdate{Content: testRelease("a1"), SnippetID: testSnippetID1},
+			DynamicSnippetUpdate{Content: testRelease("b1"), SnippetID: testSnippetID2},
+		)
+	})
+
+	var uerr *DynamicSnippetUpdateError
+	if !errors.As(err, &uerr) || uerr.RollbackErr != nil || !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{testSnippetID1}, uerr.RolledBack); diff != "" {
+		t.Errorf("bad rollback: %s", diff)
+	}
+}
+
+func TestDynamicSnippetManagerValidate(t *testing.T) {
+	t.Parallel()
+
+	m := NewDynamicSnippetManager(TestClient, "7i6HN3TK9wS159v2gPAZ8A")
+	m.Validate = DynamicSnippetSizeValidator(4)
+	ctx := context.Background()
+
+	// Validation fails before any request is made.
+	_, err := m.Apply(ctx,
+		DynamicSnippetUpdate{Content: "ok", SnippetID: testSnippetID1},
+		DynamicSnippetUpdate{Content: "too long", SnippetID: testSnippetID2},
+	)
+	if err == nil || err.Error() != "validating dynamic snippet "+testSnippetID2+": snippet content is 8 bytes, more than the maximum of 4" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := m.Update(ctx, "", "x"); !errors.Is(err, ErrMissingSnippetID) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 // EdgeCheck represents an edge check response from the Fastly API.
 type EdgeCheck struct {
 	Hash         *string            `mapstructure:"hash"`
+	POP          *string            `mapstructure:"pop"`
 	Request      *EdgeCheckRequest  `mapstructure:"request"`
 	Response     *EdgeCheckResponse `mapstructure:"response"`
 	ResponseTime *float64           `mapstructure:"response_time"`
@@ -0,0 +1,423 @@
+package fastly
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// DefaultEdgeCheckSlowFactor is the default factor by which the average
+// response time of a POP must exceed the median across POPs for the POP to be
+// flagged as slow.
+const DefaultEdgeCheckSlowFactor = 3.0
+
+// EdgeCheckFlag identifies a way in which a POP departs from the rest of the
+// network in an EdgeCheckReport.
+type EdgeCheckFlag string
+
+const (
+	// EdgeCheckDifferentContent means the POP serves content whose hash
+	// differs from the most common one.
+	EdgeCheckDifferentContent EdgeCheckFlag = "different_content"
+	// EdgeCheckDifferentETag means the POP serves an ETag which differs from
+	// the most common one.
+	EdgeCheckDifferentETag EdgeCheckFlag = "different_etag"
+	// EdgeCheckDifferentStatus means the POP serves a status code which
+	// differs from the most common one.
+	EdgeCheckDifferentStatus EdgeCheckFlag = "different_status"
+	// EdgeCheckInconsistent means the servers of the POP disagree on the
+	// content hash.
+	EdgeCheckInconsistent EdgeCheckFlag = "inconsistent"
+	// EdgeCheckSlow means the POP responds much more slowly than the others.
+	EdgeCheckSlow EdgeCheckFlag = "slow"
+	// EdgeCheckStale means the POP serves content older than the freshness
+	// lifetime.
+	EdgeCheckStale EdgeCheckFlag = "stale"
+)
+
+// EdgeCheckPOP summarizes the edge check responses of the servers of one POP.
+type EdgeCheckPOP struct {
+	// AgeMax is the largest Age header returned, in seconds.
+	AgeMax int `json:"age_max"`
+	// AgeMin is the smallest Age header returned, in seconds.
+	AgeMin int `json:"age_min"`
+	// Code is the POP code, such as "AMS".
+	Code string `json:"code"`
+	// ETags counts the servers returning each ETag header.
+	ETags map[string]int `json:"etags,omitempty"`
+	// Flags lists the ways in which the POP departs from the rest of the
+	// network.
+	Flags []EdgeCheckFlag `json:"flags,omitempty"`
+	// Group is the region of the POP, from AllDatacenters.
+	Group string `json:"group,omitempty"`
+	// Hashes counts the servers returning each content hash.
+	Hashes map[string]int `json:"hashes,omitempty"`
+	// Hits is the number of servers reporting a cache hit.
+	Hits int `json:"hits"`
+	// Misses is the number of servers reporting a cache miss.
+	Misses int `json:"misses"`
+	// Name is the name of the POP, from AllDatacenters.
+	Name string `json:"name,omitempty"`
+	// ResponseTimeAvg is the average response time of the servers, in
+	// seconds.
+	ResponseTimeAvg float64 `json:"response_time_avg"`
+	// ResponseTimeMax is the largest response time of the servers, in
+	// seconds.
+	ResponseTimeMax float64 `json:"response_time_max"`
+	// Servers is the number of servers which responded.
+	Servers int `json:"servers"`
+	// Statuses counts the servers returning each status code.
+	Statuses map[int]int `json:"statuses,omitempty"`
+}
+
+// EdgeCheckReport is an analysis of the responses of EdgeCheck, grouped by
+// POP.
+type EdgeCheckReport struct {
+	// ETag is the most common ETag header across all servers.
+	ETag string `json:"etag,omitempty"`
+	// Hash is the most common content hash across all servers.
+	Hash string `json:"hash,omitempty"`
+	// POPs summarizes each POP, ordered by code.
+	POPs []EdgeCheckPOP `json:"pops"`
+	// Servers is the number of servers which responded.
+	Servers int `json:"servers"`
+	// Status is the most common status code across all servers.
+	Status int `json:"status,omitempty"`
+}
+
+// Outliers returns the POPs with at least one flag.
+func (r *EdgeCheckReport) Outliers() []EdgeCheckPOP {
+	var outliers []EdgeCheckPOP
+	for _, p := range r.POPs {
+		if len(p.Flags) > 0 {
+			outliers = append(outliers, p)
+		}
+	}
+	return outliers
+}
+
+// WriteTable writes the report to w as a table with one row per POP.
+func (r *EdgeCheckReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "POP\tNAME\tSERVERS\tSTATUS\tHIT/MISS\tAGE\tHASHES\tETAGS\tRT AVG\tRT MAX\tFLAGS")
+	for _, p := range r.POPs {
+		flags := make([]string, 0, len(p.Flags))
+		for _, f := range p.Flags {
+			flags = append(flags, string(f))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%d/%d\t%s\t%d\t%d\t%s\t%s\t%s\n",
+			orDash(p.Code), orDash(p.Name), p.Servers, formatEdgeCheckStatuses(p.Statuses), p.Hits, p.Misses,
+			formatEdgeCheckAge(p.AgeMin, p.AgeMax), len(p.Hashes), len(p.ETags),
+			formatEdgeCheckSeconds(p.ResponseTimeAvg), formatEdgeCheckSeconds(p.ResponseTimeMax), orDash(strings.Join(flags, ",")))
+	}
+	return tw.Flush()
+}
+
+// EdgeCheckReportOptions controls how an EdgeCheckReport flags outliers.
+type EdgeCheckReportOptions struct {
+	// SlowFactor is the factor by which the average response time of a POP
+	// must exceed the median across POPs for the POP to be flagged as slow
+	// (default: DefaultEdgeCheckSlowFactor).
+	SlowFactor float64
+	// StaleAfter is the freshness lifetime of the content. POPs returning an
+	// older Age are flagged as stale. When zero, the s-maxage or max-age
+	// directive of the most common Cache-Control header is used, and no POP is
+	// flagged if there is neither.
+	StaleAfter time.Duration
+}
+
+// NewEdgeCheckReport analyzes the responses of EdgeCheck. Servers are grouped
+// by the POP reported by the API, mapped to its code through datacenters,
+// falling back to the POP code found in their X-Served-By header. POPs are
+// named from datacenters, which may be nil.
+func NewEdgeCheckReport(checks []*EdgeCheck, datacenters []Datacenter, opts EdgeCheckReportOptions) *EdgeCheckReport {
+	if opts.SlowFactor <= 0 {
+		opts.SlowFactor = DefaultEdgeCheckSlowFactor
+	}
+	names := make(map[string]Datacenter, len(datacenters))
+	for _, dc := range datacenters {
+		if dc.Code != nil {
+			names[strings.ToUpper(*dc.Code)] = dc
+		}
+	}
+	codes := datacenterCodes(datacenters)
+
+	var (
+		report        = &EdgeCheckReport{}
+		pops          = make(map[string]*EdgeCheckPOP)
+		hashes        = make(map[string]int)
+		etags         = make(map[string]int)
+		statuses      = make(map[string]int)
+		cacheControls = make(map[string]int)
+		ages          = make(map[string][]int)
+	)
+	for _, ec := range checks {
+		if ec == nil {
+			continue
+		}
+		code := edgeCheckPOPCode(ec, codes)
+		p, ok := pops[code]
+		if !ok {
+			p = &EdgeCheckPOP{Code: code, ETags: map[string]int{}, Hashes: map[string]int{}, Statuses: map[int]int{}}
+			if dc, ok := names[code]; ok {
+				p.Name = ToValue(dc.Name)
+				p.Group = ToValue(dc.Group)
+			}
+			pops[code] = p
+		}
+		p.Servers++
+		report.Servers++
+
+		if ec.Hash != nil {
+			p.Hashes[*ec.Hash]++
+			hashes[*ec.Hash]++
+		}
+		if etag := edgeCheckHeader(ec, "ETag"); etag != "" {
+			p.ETags[etag]++
+			etags[etag]++
+		}
+		if ec.Response != nil && ec.Response.Status != nil {
+			p.Statuses[*ec.Response.Status]++
+			statuses[strconv.Itoa(*ec.Response.Status)]++
+		}
+		if cc := edgeCheckHeader(ec, "Cache-Control"); cc != "" {
+			cacheControls[cc]++
+		}
+		switch edgeCheckCacheResult(ec) {
+		case "HIT":
+			p.Hits++
+		case "MISS", "PASS":
+			p.Misses++
+		}
+		if age, ok := edgeCheckAge(ec); ok {
+			ages[code] = append(ages[code], age)
+		}
+		if ec.ResponseTime != nil {
+			p.ResponseTimeAvg += *ec.ResponseTime
+			p.ResponseTimeMax = max(p.ResponseTimeMax, *ec.ResponseTime)
+		}
+	}
+
+	report.Hash = mostCommon(hashes)
+	report.ETag = mostCommon(etags)
+	report.Status, _ = strconv.Atoi(mostCommon(statuses))
+
+	staleAfter := opts.StaleAfter
+	if staleAfter <= 0 {
+		staleAfter = cacheControlMaxAge(mostCommon(cacheControls))
+	}
+
+	var avgs []float64
+	for _, p := range pops {
+		if p.Servers > 0 {
+			p.ResponseTimeAvg /= float64(p.Servers)
+			avgs = append(avgs, p.ResponseTimeAvg)
+		}
+		if a := ages[p.Code]; len(a) > 0 {
+			p.AgeMin, p.AgeMax = slices.Min(a), slices.Max(a)
+		}
+	}
+	sort.Float64s(avgs)
+	median := 0.0
+	if len(avgs) > 0 {
+		median = avgs[len(avgs)/2]
+	}
+
+	for _, code := range slices.Sorted(maps.Keys(pops)) {
+		p := pops[code]
+		if len(p.Hashes) > 1 {
+			p.Flags = append(p.Flags, EdgeCheckInconsistent)
+		}
+		if report.Hash != "" && mostCommon(p.Hashes) != report.Hash {
+			p.Flags = append(p.Flags, EdgeCheckDifferentContent)
+		}
+		if report.ETag != "" && len(p.ETags) > 0 && mostCommon(p.ETags) != report.ETag {
+			p.Flags = append(p.Flags, EdgeCheckDifferentETag)
+		}
+		if report.Status != 0 && len(p.Statuses) > 0 && (len(p.Statuses) > 1 || p.Statuses[report.Status] == 0) {
+			p.Flags = append(p.Flags, EdgeCheckDifferentStatus)
+		}
+		if staleAfter > 0 && time.Duration(p.AgeMax)*time.Second > staleAfter {
+			p.Flags = append(p.Flags, EdgeCheckStale)
+		}
+		if median > 0 && p.ResponseTimeAvg > median*opts.SlowFactor {
+			p.Flags = append(p.Flags, EdgeCheckSlow)
+		}
+		report.POPs = append(report.POPs, *p)
+	}
+	return report
+}
+
+// AnalyzeEdgeCheckInput is used as input to the AnalyzeEdgeCheck function.
+type AnalyzeEdgeCheckInput struct {
+	// Options controls how outliers are flagged.
+	Options EdgeCheckReportOptions
+	// URL is the full URL (host and path) to check on all nodes.
+	// If protocol is omitted, http will be assumed (required).
+	URL string
+}
+
+// AnalyzeEdgeCheck runs EdgeCheck for a URL and analyzes the responses with
+// NewEdgeCheckReport, naming POPs from AllDatacenters.
+func (c *Client) AnalyzeEdgeCheck(ctx context.Context, i *AnalyzeEdgeCheckInput) (*EdgeCheckReport, error) {
+	if i.URL == "" {
+		return nil, ErrMissingURL
+	}
+	checks, err := c.EdgeCheck(ctx, &EdgeCheckInput{URL: i.URL})
+	if err != nil {
+		return nil, err
+	}
+	datacenters, err := c.AllDatacenters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewEdgeCheckReport(checks, datacenters, i.Options), nil
+}
+
+// edgeCheckHeader returns a response header of an edge check, or "" if it is
+// absent. The API returns header names in lower case, so they are matched
+// regardless of case.
+func edgeCheckHeader(ec *EdgeCheck, name string) string {
+	if ec.Response == nil || ec.Response.Headers == nil {
+		return ""
+	}
+	for k, v := range *ec.Response.Headers {
+		if strings.EqualFold(k, name) && len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// datacenterCodes maps the lower-case name and shield of each datacenter to
+// its code, for edgeCheckPOPCode.
+func datacenterCodes(datacenters []Datacenter) map[string]string {
+	codes := make(map[string]string, 2*len(datacenters))
+	for _, dc := range datacenters {
+		if dc.Code == nil {
+			continue
+		}
+		code := strings.ToUpper(*dc.Code)
+		if dc.Name != nil {
+			codes[strings.ToLower(*dc.Name)] = code
+		}
+		if dc.Shield != nil {
+			codes[strings.ToLower(*dc.Shield)] = code
+		}
+	}
+	return codes
+}
+
+// edgeCheckPOPCode returns the code of the POP which served an edge check.
+// It is the POP reported by the API, which may be a code, or a name or shield
+// found in codes, as built by datacenterCodes. Otherwise it is the suffix of
+// the last entry of X-Served-By, which names the edge server even when the
+// request was shielded, such as "AMS" in "cache-ams21020-AMS", or failing
+// that, it is derived from the server name.
+func edgeCheckPOPCode(ec *EdgeCheck, codes map[string]string) string {
+	if pop := strings.TrimSpace(ToValue(ec.POP)); pop != "" {
+		if code, ok := codes[strings.ToLower(pop)]; ok {
+			return code
+		}
+		return strings.ToUpper(pop)
+	}
+	if servedBy := edgeCheckHeader(ec, "X-Served-By"); servedBy != "" {
+		last := strings.TrimSpace(servedBy[strings.LastIndex(servedBy, ",")+1:])
+		if n := strings.LastIndex(last, "-"); n >= 0 && n < len(last)-1 {
+			return strings.ToUpper(last[n+1:])
+		}
+	}
+	// Server names look like "cache-ams21020" or "cache-dfw-kdfw8210104".
+	name := strings.TrimPrefix(ToValue(ec.Server), "cache-")
+	name = strings.SplitN(name, "-", 2)[0]
+	return strings.ToUpper(strings.TrimRight(name, "0123456789"))
+}
+
+// edgeCheckCacheResult returns the cache result of the edge server from the
+// last entry of X-Cache, such as "HIT" in "MISS, HIT".
+func edgeCheckCacheResult(ec *EdgeCheck) string {
+	xc := edgeCheckHeader(ec, "X-Cache")
+	return strings.ToUpper(strings.TrimSpace(xc[strings.LastIndex(xc, ",")+1:]))
+}
+
+// edgeCheckAge returns the Age header of an edge check in seconds.
+func edgeCheckAge(ec *EdgeCheck) (int, bool) {
+	age, err := strconv.Atoi(strings.TrimSpace(edgeCheckHeader(ec, "Age")))
+	return age, err == nil
+}
+
+// cacheControlMaxAge returns the s-maxage or, failing that, max-age directive
+// of a Cache-Control header, or zero if there is neither.
+func cacheControlMaxAge(cc string) time.Duration {
+	var maxAge, sMaxAge time.Duration
+	for _, directive := range strings.Split(cc, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(name) {
+		case "max-age":
+			maxAge = time.Duration(seconds) * time.Second
+		case "s-maxage":
+			sMaxAge = time.Duration(seconds) * time.Second
+		}
+	}
+	if sMaxAge > 0 {
+		return sMaxAge
+	}
+	return maxAge
+}
+
+// mostCommon returns the key with the highest count, breaking ties by the
+// smallest key, or "" if counts is empty.
+func mostCommon(counts map[string]int) string {
+	best, bestCount := "", 0
+	for k, n := range counts {
+		if n > bestCount || (n == bestCount && k < best) {
+			best, bestCount = k, n
+		}
+	}
+	return best
+}
+
+// formatEdgeCheckStatuses formats status counts, such as "200 x3, 503".
+func formatEdgeCheckStatuses(statuses map[int]int) string {
+	var parts []string
+	for _, status := range slices.Sorted(maps.Keys(statuses)) {
+		if n := statuses[status]; n > 1 {
+			parts = append(parts, fmt.Sprintf("%d x%d", status, n))
+		} else {
+			parts = append(parts, strconv.Itoa(status))
+		}
+	}
+	return orDash(strings.Join(parts, ", "))
+}
+
+// formatEdgeCheckAge formats an age range in seconds.
+func formatEdgeCheckAge(lo, hi int) string {
+	if lo == hi {
+		return strconv.Itoa(hi)
+	}
+	return fmt.Sprintf("%d-%d", lo, hi)
+}
+
+// formatEdgeCheckSeconds formats a response time in seconds as milliseconds.
+func formatEdgeCheckSeconds(s float64) string {
+	return fmt.Sprintf("%.0fms", s*1000)
+}
+
+// orDash returns s, or "-" if it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,166 @@
+package fastly
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testEdgeCheck returns an edge check served by the given server with the
+// given response headers, as name/value pairs.
+func testEdgeCheck(server, hash string, status int, rt float64, headers ...string) *EdgeCheck {
+	h := http.Header{}
+	for n := 0; n+1 < len(headers); n += 2 {
+		h.Set(headers[n], headers[n+1])
+	}
+	return &EdgeCheck{
+		Hash:         ToPointer(hash),
+		Response:     &EdgeCheckResponse{Headers: &h, Status: ToPointer(status)},
+		ResponseTime: ToPointer(rt),
+		Server:       ToPointer(server),
+	}
+}
+
+func TestNewEdgeCheckReport(t *testing.T) {
+	t.Parallel()
+
+	checks := []*EdgeCheck{
+		testEdgeCheck("cache-ams1", "aaa", 200, 0.1, "X-Served-By", "cache-ams1-AMS", "X-Cache", "HIT", "Age", "30", "ETag", `"v1"`, "Cache-Control", "max-age=60"),
+		testEdgeCheck("cache-ams2", "aaa", 200, 0.3, "X-Served-By", "cache-ams2-AMS", "X-Cache", "MISS", "Age", "0", "ETag", `"v1"`, "Cache-Control", "max-age=60"),
+		// Shielded through IAD: the edge is the last entry of X-Served-By.
+		testEdgeCheck("cache-lhr1", "aaa", 200, 0.2, "X-Served-By", "cache-iad-kiad1-IAD, cache-lhr1-LHR", "X-Cache", "MISS, HIT", "Age", "90", "ETag", `"v1"`, "Cache-Control", "max-age=60"),
+		testEdgeCheck("cache-syd1", "bbb", 200, 1.5, "X-Served-By", "cache-syd1-SYD", "X-Cache", "HIT", "Age", "10", "ETag", `"v0"`, "Cache-Control", "max-age=60"),
+		// No X-Served-By: the POP comes from the server name.
+		testEdgeCheck("cache-dfw-kdfw1", "aaa", 503, 0.2),
+		testEdgeCheck("cache-dfw-kdfw2", "ccc", 200, 0.2, "X-Cache", "HIT"),
+		nil,
+	}
+	datacenters := []Datacenter{
+		{Code: ToPointer("AMS"), Group: ToPointer("Europe"), Name: ToPointer("Amsterdam"), Shield: ToPointer("amsterdam-nl")},
+		{Code: ToPointer("SYD"), Name: ToPointer("Sydney"), Group: ToPointer("Asia/Pacific")},
+	}
+
+	r := NewEdgeCheckReport(checks, datacenters, EdgeCheckReportOptions{})
+
+	if r.Servers != 6 || r.Hash != "aaa" || r.ETag != `"v1"` || r.Status != 200 {
+		t.Errorf("bad consensus: servers=%d hash=%q etag=%q status=%d", r.Servers, r.Hash, r.ETag, r.Status)
+	}
+
+	var codes []string
+	flags := map[string][]EdgeCheckFlag{}
+	for _, p := range r.POPs {
+		codes = append(codes, p.Code)
+		flags[p.Code] = p.Flags
+	}
+	if diff := cmp.Diff([]string{"AMS", "DFW", "LHR", "SYD"}, codes); diff != "" {
+		t.Errorf("bad POPs: %s", diff)
+	}
+	want := map[string][]EdgeCheckFlag{
+		"AMS": nil,
+		"DFW": {EdgeCheckInconsistent, EdgeCheckDifferentStatus},
+		"LHR": {EdgeCheckStale},
+		"SYD": {EdgeCheckDifferentContent, EdgeCheckDifferentETag, EdgeCheckSlow},
+	}
+	if diff := cmp.Diff(want, flags); diff != "" {
+		t.Errorf("bad flags: %s", diff)
+	}
+
+	ams := r.POPs[0]
+	if ams.Name != "Amsterdam" || ams.Group != "Europe" || ams.Servers != 2 || ams.Hits != 1 || ams.Misses != 1 {
+		t.Errorf("bad AMS summary: %+v", ams)
+	}
+	if ams.AgeMin != 0 || ams.AgeMax != 30 {
+		t.Errorf("bad AMS ages: %d-%d", ams.AgeMin, ams.AgeMax)
+	}
+	if ams.ResponseTimeAvg < 0.199 || ams.ResponseTimeAvg > 0.201 || ams.ResponseTimeMax != 0.3 {
+		t.Errorf("bad AMS response times: %v %v", ams.ResponseTimeAvg, ams.ResponseTimeMax)
+	}
+
+	if diff := cmp.Diff([]string{"DFW", "LHR", "SYD"}, popCodes(r.Outliers())); diff != "" {
+		t.Errorf("bad outliers: %s", diff)
+	}
+}
+
+func TestNewEdgeCheckReportStaleAfter(t *testing.T) {
+	t.Parallel()
+
+	checks := []*EdgeCheck{
+		testEdgeCheck("cache-ams1", "aaa", 200, 0.1, "X-Served-By", "cache-ams1-AMS", "Age", "30", "Cache-Control", "max-age=600, s-maxage=20"),
+		testEdgeCheck("cache-lhr1", "aaa", 200, 0.1, "X-Served-By", "cache-lhr1-LHR", "Age", "10", "Cache-Control", "max-age=600, s-maxage=20"),
+	}
+
+	r := NewEdgeCheckReport(checks, nil, EdgeCheckReportOptions{})
+	if diff := cmp.Diff([]string{"AMS"}, popCodes(r.Outliers())); diff != "" {
+		t.Errorf("s-maxage: %s", diff)
+	}
+
+	r = NewEdgeCheckReport(checks, nil, EdgeCheckReportOptions{StaleAfter: 5 * time.Second})
+	if diff := cmp.Diff([]string{"AMS", "LHR"}, popCodes(r.Outliers())); diff != "" {
+		t.Errorf("StaleAfter: %s", diff)
+	}
+}
+
+func TestEdgeCheckReportWriteTable(t *testing.T) {
+	t.Parallel()
+
+	r := NewEdgeCheckReport([]*EdgeCheck{
+		testEdgeCheck("cache-ams1", "aaa", 200, 0.1, "X-Served-By", "cache-ams1-AMS", "X-Cache", "HIT", "Age", "5"),
+		testEdgeCheck("cache-ams2", "aaa", 200, 0.3, "X-Served-By", "cache-ams2-AMS", "X-Cache", "HIT", "Age", "9"),
+	}, []Datacenter{{Code: ToPointer("AMS"), Name: ToPointer("Amsterdam")}}, EdgeCheckReportOptions{})
+
+	var sb strings.Builder
+	if err := r.WriteTable(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"POP  NAME       SERVERS  STATUS  HIT/MISS  AGE  HASHES  ETAGS  RT AVG  RT MAX  FLAGS\n" +
+		"AMS  Amsterdam  2        200 x2  2/0       5-9  1       0      200ms   300ms   -\n"
+	if diff := cmp.Diff(want, sb.String()); diff != "" {
+		t.Errorf("bad table: %s", diff)
+	}
+}
+
+func popCodes(pops []EdgeCheckPOP) []string {
+	var codes []string
+	for _, p := range pops {
+		codes = append(codes, p.Code)
+	}
+	return codes
+}
+
+func TestNewEdgeCheckReport_pop(t *testing.T) {
+	t.Parallel()
+
+	amsterdam := testEdgeCheck("cache-x1", "aaa", 200, 0.1, "X-Served-By", "cache-x1-XXX")
+	amsterdam.POP = ToPointer("Amsterdam")
+	shield := testEdgeCheck("cache-x3", "aaa", 200, 0.1)
+	shield.POP = ToPointer("amsterdam-nl")
+	lhr := testEdgeCheck("cache-x2", "aaa", 200, 0.1)
+	lhr.POP = ToPointer("lhr")
+	// The API returns header names in lower case.
+	sydney := testEdgeCheck("cache-x4", "aaa", 200, 0.1)
+	sydney.Response.Headers = &http.Header{"x-served-by": {"cache-x4-SYD"}}
+	checks := []*EdgeCheck{
+		amsterdam,
+		shield,
+		lhr,
+		sydney,
+		testEdgeCheck("cache-ams1", "aaa", 200, 0.1, "X-Served-By", "cache-ams1-AMS"),
+	}
+	datacenters := []Datacenter{
+		{Code: ToPointer("AMS"), Group: ToPointer("Europe"), Name: ToPointer("Amsterdam"), Shield: ToPointer("amsterdam-nl")},
+	}
+
+	// The POP reported by the API takes precedence over X-Served-By and is
+	// mapped from a datacenter name or shield to its code.
+	r := NewEdgeCheckReport(checks, datacenters, EdgeCheckReportOptions{})
+	if diff := cmp.Diff([]string{"AMS", "LHR", "SYD"}, popCodes(r.POPs)); diff != "" {
+		t.Errorf("bad POPs: %s", diff)
+	}
+	if r.POPs[0].Servers != 3 || r.POPs[0].Name != "Amsterdam" {
+		t.Errorf("bad AMS summary: servers=%d name=%q", r.POPs[0].Servers, r.POPs[0].Name)
+	}
+}
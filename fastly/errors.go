@@ -107,6 +107,10 @@ var ErrMissingCertBlob = NewFieldError("CertBlob")
 // requires a "CertBundle" key, but one was not set.
 var ErrMissingCertBundle = NewFieldError("CertBundle")
 
+// ErrMissingCertificate is an error that is returned when an input struct
+// requires a "Certificate" key, but one was not set.
+var ErrMissingCertificate = NewFieldError("Certificate")
+
 // ErrMissingComputeACLID is an error that is returned when an input struct
 // requires a "ComputeACLID" key, but one was not set.
 var ErrMissingComputeACLID = NewFieldError("ComputeACLID")
@@ -143,6 +147,10 @@ var ErrMissingIsExpired = NewFieldError("IsExpired")
 // requires a "RedactionID" key, but one was not set.
 var ErrMissingRedactionID = NewFieldError("RedactionID")
 
+// ErrMissingRenew is an error that is returned when an input struct
+// requires a "Renew" key, but one was not set.
+var ErrMissingRenew = NewFieldError("Renew")
+
 // ErrMissingThresholdID is an error that is returned when an input struct
 // requires a "ThresholdID" key, but one was not set.
 var ErrMissingThresholdID = NewFieldError("ThresholdID")
@@ -211,6 +219,10 @@ var ErrMissingScope = NewFieldError("Scope")
 // requires a "DictionaryID" key, but one was not set.
 var ErrMissingDictionaryID = NewFieldError("DictionaryID")
 
+// ErrMissingDir is an error that is returned when an input struct requires a
+// "Dir" key, but one was not set.
+var ErrMissingDir = NewFieldError("Dir")
+
 // ErrMissingDirector is an error that is returned when an input struct
 // requires a "Director" key, but one was not set.
 var ErrMissingDirector = NewFieldError("Director")
@@ -287,6 +299,10 @@ var ErrMissingKey = NewFieldError("Key")
 // requires a "Keys" key, but one was not set.
 var ErrMissingKeys = NewFieldError("Keys")
 
+// ErrMissingMetric is an error that is returned when an input struct
+// requires a "Metric" key, but one was not set.
+var ErrMissingMetric = NewFieldError("Metric")
+
 // ErrMissingMetrics is an error that is returned when an input struct
 // requires a "Metrics" key, but one was not set.
 var ErrMissingMetrics = NewFieldError("Metrics")
@@ -347,10 +363,18 @@ var ErrMissingServiceAuthorizationsService = NewFieldError("Service").Message("S
 // requires a "User" key of type SAUser, but one was not set or was misconfigured.
 var ErrMissingServiceAuthorizationsUser = NewFieldError("User").Message("SAUser requires an ID")
 
+// ErrMissingSink is an error that is returned when an input struct
+// requires a "Sink" key, but one was not set.
+var ErrMissingSink = NewFieldError("Sink")
+
 // ErrMissingSite is an error that is returned when an input struct
 // requires a "Site" key, but one was not set.
 var ErrMissingSite = NewFieldError("Site")
 
+// ErrMissingSource is an error that is returned when an input struct
+// requires a "Source" key, but one was not set.
+var ErrMissingSource = NewFieldError("Source")
+
 // ErrMissingStart is an error that is returned when an input struct
 // requires a "Start" key, but one was not set.
 var ErrMissingStart = NewFieldError("Start")
@@ -391,6 +415,10 @@ var ErrMissingTLSDomain = NewFieldError("TLSDomain")
 // requires that the domain in "CommonName" is also in "Domains".
 var ErrCommonNameNotInDomains = NewFieldError("CommonName").Message("CommonName must be in Domains")
 
+// ErrMissingTemplates is an error that is returned when an input struct
+// requires a "Templates" key, but one was not set.
+var ErrMissingTemplates = NewFieldError("Templates")
+
 // ErrMissingTo is an error that is returned when an input struct
 // requires a "To" key, but one was not set.
 var ErrMissingTo = NewFieldError("To")
@@ -566,6 +594,90 @@ var ErrMissingEventTypes = NewFieldError("EventTypes")
 // requires an "IntegrationIDs" key, but one was not set.
 var ErrMissingIntegrationIDs = NewFieldError("IntegrationIDs")
 
+// ErrInvalidBatchSize is an error that is returned when an input struct
+// specifies a "BatchSize" key value outside of the range accepted by the API.
+var ErrInvalidBatchSize = NewFieldError("BatchSize").Message(fmt.Sprintf("must be between 1 and %d", BatchModifyMaximumOperations))
+
+// ErrMaxExceededDictionarySize is an error that is returned when an input
+// struct specifies more "Items" than an Edge Dictionary can hold.
+var ErrMaxExceededDictionarySize = NewFieldError("Items").Message(fmt.Sprintf("exceeds the maximum dictionary size of %d", MaximumDictionarySize))
+
+// ErrMaxExceededACLSize is an error that is returned when an input struct
+// specifies more "Prefixes" than an ACL can hold.
+var ErrMaxExceededACLSize = NewFieldError("Prefixes").Message(fmt.Sprintf("exceeds the maximum ACL size of %d", MaximumACLSize))
+
+// ErrInvalidACLPrefix is an error that is returned when an ACL entry or input
+// prefix is not a valid IPv4 or IPv6 network.
+var ErrInvalidACLPrefix = NewFieldError("Prefixes").Message("invalid IP prefix")
+
+// ErrConflictingACLPrefix is an error that is returned when an input struct
+// specifies the same prefix more than once with different negation or action
+// values.
+var ErrConflictingACLPrefix = NewFieldError("Prefixes").Message("prefix listed with conflicting values")
+
+// ErrInvalidClientKeySignature is an error that is returned when a Secret
+// Store client key's signature does not verify against the signing key.
+var ErrInvalidClientKeySignature = errors.New("client key signature does not match the signing key")
+
+// ErrDictionaryWriteOnly is an error that is returned when the items of a
+// write-only dictionary would need to be read.
+var ErrDictionaryWriteOnly = errors.New("dictionary is write-only and its items cannot be read")
+
+// ErrMigrationLinkNameConflict is an error that is returned when a config
+// store would be linked under the name of a dictionary kept in the same
+// service version.
+var ErrMigrationLinkNameConflict = errors.New("config store link name conflicts with the dictionary name")
+
+// ErrMigrationVerificationFailed is an error that is returned when the items
+// copied by a migration do not match the source.
+var ErrMigrationVerificationFailed = errors.New("migrated items do not match the source")
+
+// ErrInvalidStatsBy is an error that is returned when an input struct
+// specifies an unsupported stats sample window.
+var ErrInvalidStatsBy = NewFieldError("By").Message("must be one of minute, hour or day")
+
+// ErrInvalidStatsRange is an error that is returned when an input struct
+// specifies a stats time range that does not end after it starts.
+var ErrInvalidStatsRange = NewFieldError("From").Message("must be before To")
+
+// ErrStatsRangeTooLong is an error that is returned when a stats time range
+// would need too many requests at the requested sample window.
+var ErrStatsRangeTooLong = NewFieldError("By").Message("too fine for the requested time range")
+
+// ErrInvalidLogExportFormat is an error that is returned when an input struct
+// specifies an unsupported log export format.
+var ErrInvalidLogExportFormat = NewFieldError("Format").Message("must be ndjson or csv")
+
+// ErrUnsupportedLogExplorerContains is an error that is returned when a
+// contains filter, which the Log Explorer API does not support, is passed to
+// GetLogRecords.
+var ErrUnsupportedLogExplorerContains = NewFieldError("Filters").Message("contains filters are only supported by ExportLogRecords")
+
+// ErrDuplicateDashboardName is an error that is returned when several
+// dashboards or dashboard definitions share a name.
+var ErrDuplicateDashboardName = errors.New("duplicate dashboard name")
+
+// ErrAlertSourceChanged is an error that is returned when an existing alert
+// definition has a different source than its template, which cannot be
+// changed by an update.
+var ErrAlertSourceChanged = errors.New("alert definition source cannot be updated")
+
+// ErrInvalidTimelineRange is an error that is returned when an input struct
+// specifies a timeline range that does not end after it starts.
+var ErrInvalidTimelineRange = NewFieldError("From").Message("must be before To")
+
+// ErrNoDynamicSnippetRevision is an error that is returned when a dynamic
+// snippet is reverted further back than its history goes.
+var ErrNoDynamicSnippetRevision = errors.New("no such revision in the dynamic snippet history")
+
+// ErrTLSRenewalUnsupported is an error that is returned when renewing a
+// certificate which is not a custom or bulk certificate.
+var ErrTLSRenewalUnsupported = errors.New("only custom and bulk certificates can be renewed")
+
+// ErrTLSSerialMismatch is an error that is returned when the certificate read
+// back after a renewal is not the one which was uploaded.
+var ErrTLSSerialMismatch = errors.New("certificate serial number does not match the renewed certificate")
+
 // Ensure HTTPError is, in fact, an error.
 var _ error = (*HTTPError)(nil)
 
@@ -778,3 +890,9 @@ func (e *HTTPError) IsNotFound() bool {
 func (e *HTTPError) IsPreconditionFailed() bool {
 	return e.StatusCode == http.StatusPreconditionFailed
 }
+
+// IsRetryable returns true if the HTTP status code is 429 or 5xx, false
+// otherwise.
+func (e *HTTPError) IsRetryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,4 @@
+// Package exporter writes Fastly realtime and historical stats in the
+// OpenMetrics text format, for scraping by Prometheus-compatible systems
+// without depending on a Prometheus client library.
+package exporter
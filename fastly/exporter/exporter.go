@@ -0,0 +1,404 @@
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+const (
+	// ContentType is the media type of the output of Write.
+	ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	// DefaultNamespace is the default prefix of every metric name.
+	DefaultNamespace = "fastly"
+)
+
+// Subsystems of the metric names, identifying where the data came from.
+const (
+	subsystemHistorical = "historical"
+	subsystemRealtime   = "rt"
+)
+
+// namespacePattern matches valid metric name prefixes.
+var namespacePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Options configures an Exporter.
+type Options struct {
+	// AggregateOnly, if true, exports the realtime stats aggregated across all
+	// POPs rather than one series per POP.
+	AggregateOnly bool
+	// Fields is an allow-list of the stats fields to export, named by their
+	// mapstructure tags (see Fields). All fields are exported if empty.
+	Fields []string
+	// Namespace is the prefix of every metric name (default:
+	// DefaultNamespace).
+	Namespace string
+}
+
+// Exporter accumulates Fastly stats and writes them as OpenMetrics text.
+//
+// Each exported stats field becomes a metric family named
+// <namespace>_<subsystem>_<field>, where subsystem is "rt" for realtime data
+// and "historical" for historical data. Count fields are exported as counters
+// that sum every record added, ratio fields as gauges holding the most recent
+// value, and miss_histogram as a histogram in seconds. Series are labelled
+// with service_id and, for per-POP realtime data, datacenter.
+//
+// An Exporter is safe for concurrent use.
+type Exporter struct {
+	aggregateOnly bool
+	fields        []field
+	namespace     string
+
+	mu sync.Mutex
+	// latest holds the most recent record timestamp added per subsystem and
+	// service, so that records are not counted twice.
+	latest map[sourceKey]uint64
+	series map[string]map[seriesKey]*series
+}
+
+// sourceKey identifies the records of a service from one subsystem.
+type sourceKey struct {
+	serviceID string
+	subsystem string
+}
+
+// seriesKey identifies the label values of a series.
+type seriesKey struct {
+	datacenter string
+	serviceID  string
+}
+
+// series holds the accumulated values of one set of labels.
+type series struct {
+	histograms map[string]map[int]uint64
+	values     map[string]float64
+}
+
+// New returns an Exporter configured by opts, which may be nil.
+func New(opts *Options) (*Exporter, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	e := &Exporter{
+		aggregateOnly: opts.AggregateOnly,
+		fields:        statsFields,
+		latest:        make(map[sourceKey]uint64),
+		namespace:     opts.Namespace,
+		series:        make(map[string]map[seriesKey]*series),
+	}
+	if e.namespace == "" {
+		e.namespace = DefaultNamespace
+	}
+	if !namespacePattern.MatchString(e.namespace) {
+		return nil, fmt.Errorf("invalid metric namespace %q", e.namespace)
+	}
+
+	if len(opts.Fields) > 0 {
+		allowed := make(map[string]bool, len(opts.Fields))
+		for _, name := range opts.Fields {
+			allowed[name] = true
+		}
+		e.fields = nil
+		for _, f := range statsFields {
+			if allowed[f.name] {
+				e.fields = append(e.fields, f)
+				delete(allowed, f.name)
+			}
+		}
+		if len(allowed) > 0 {
+			unknown := make([]string, 0, len(allowed))
+			for name := range allowed {
+				unknown = append(unknown, name)
+			}
+			sort.Strings(unknown)
+			return nil, fmt.Errorf("unknown stats fields: %s", strings.Join(unknown, ", "))
+		}
+	}
+
+	return e, nil
+}
+
+// AddRealtime adds one second of realtime stats for a service. Records that
+// are not newer than the last one added for the service are ignored.
+func (e *Exporter) AddRealtime(serviceID string, d *fastly.RealtimeData) {
+	if d == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.advance(sourceKey{serviceID: serviceID, subsystem: subsystemRealtime}, fastly.ToValue(d.Recorded)) {
+		return
+	}
+	if e.aggregateOnly {
+		e.add(subsystemRealtime, seriesKey{serviceID: serviceID}, d.Aggregated)
+		return
+	}
+	for dc, s := range d.Datacenter {
+		e.add(subsystemRealtime, seriesKey{datacenter: dc, serviceID: serviceID}, s)
+	}
+}
+
+// AddHistorical adds historical stats records for a service. Records that do
+// not start after the last one added for the service are ignored.
+func (e *Exporter) AddHistorical(serviceID string, stats []*fastly.Stats) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, s := range stats {
+		if s == nil {
+			continue
+		}
+		if !e.advance(sourceKey{serviceID: serviceID, subsystem: subsystemHistorical}, fastly.ToValue(s.StartTime)) {
+			continue
+		}
+		e.add(subsystemHistorical, seriesKey{serviceID: serviceID}, s)
+	}
+}
+
+// advance records ts as the latest timestamp for key and reports whether it
+// is newer than the previous one. A zero ts is always accepted.
+func (e *Exporter) advance(key sourceKey, ts uint64) bool {
+	if ts == 0 {
+		return true
+	}
+	if ts <= e.latest[key] {
+		return false
+	}
+	e.latest[key] = ts
+	return true
+}
+
+// add accumulates the fields of s into the series identified by subsystem
+// and key.
+func (e *Exporter) add(subsystem string, key seriesKey, s *fastly.Stats) {
+	if s == nil {
+		return
+	}
+
+	bySeries, ok := e.series[subsystem]
+	if !ok {
+		bySeries = make(map[seriesKey]*series)
+		e.series[subsystem] = bySeries
+	}
+	ser, ok := bySeries[key]
+	if !ok {
+		ser = &series{
+			histograms: make(map[string]map[int]uint64),
+			values:     make(map[string]float64),
+		}
+		bySeries[key] = ser
+	}
+
+	v := reflect.ValueOf(s).Elem()
+	for _, f := range e.fields {
+		fv := v.Field(f.index)
+		if f.kind == kindHistogram {
+			if fv.Len() == 0 {
+				continue
+			}
+			buckets, ok := ser.histograms[f.name]
+			if !ok {
+				buckets = make(map[int]uint64)
+				ser.histograms[f.name] = buckets
+			}
+			for k, c := range fv.Interface().(map[int]int) {
+				if c > 0 {
+					buckets[k] += uint64(c)
+				}
+			}
+			continue
+		}
+
+		if fv.IsNil() {
+			continue
+		}
+		// statsFields only selects *uint64 and *float64 fields.
+		var value float64
+		if elem := fv.Elem(); elem.Kind() == reflect.Uint64 {
+			value = float64(elem.Uint())
+		} else {
+			value = elem.Float()
+		}
+		if f.kind == kindGauge {
+			ser.values[f.name] = value
+		} else {
+			ser.values[f.name] += value
+		}
+	}
+}
+
+// Reset discards all accumulated values.
+func (e *Exporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.latest = make(map[sourceKey]uint64)
+	e.series = make(map[string]map[seriesKey]*series)
+}
+
+// Write writes the accumulated metrics to w in the OpenMetrics text format.
+// Metric families are written in field order and series are sorted by
+// service and POP, so the output is deterministic.
+func (e *Exporter) Write(w io.Writer) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, subsystem := range []string{subsystemRealtime, subsystemHistorical} {
+		bySeries := e.series[subsystem]
+		keys := make([]seriesKey, 0, len(bySeries))
+		for k := range bySeries {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(a, b int) bool {
+			if keys[a].serviceID != keys[b].serviceID {
+				return keys[a].serviceID < keys[b].serviceID
+			}
+			return keys[a].datacenter < keys[b].datacenter
+		})
+
+		for _, f := range e.fields {
+			e.writeFamily(bw, e.namespace+"_"+subsystem+"_"+f.name, f, keys, bySeries)
+		}
+	}
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+// writeFamily writes one metric family, if any series has a value for it.
+func (e *Exporter) writeFamily(w *bufio.Writer, name string, f field, keys []seriesKey, bySeries map[seriesKey]*series) {
+	wroteType := false
+	writeType := func(typ string) {
+		if !wroteType {
+			fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+			wroteType = true
+		}
+	}
+
+	for _, k := range keys {
+		ser := bySeries[k]
+		labels := seriesLabels(k)
+
+		switch f.kind {
+		case kindCounter:
+			if v, ok := ser.values[f.name]; ok {
+				writeType("counter")
+				fmt.Fprintf(w, "%s_total{%s} %s\n", name, labels, formatFloat(v))
+			}
+		case kindGauge:
+			if v, ok := ser.values[f.name]; ok {
+				writeType("gauge")
+				fmt.Fprintf(w, "%s{%s} %s\n", name, labels, formatFloat(v))
+			}
+		case kindHistogram:
+			buckets, ok := ser.histograms[f.name]
+			if !ok {
+				continue
+			}
+			writeType("histogram")
+			bounds := make([]int, 0, len(buckets))
+			for b := range buckets {
+				bounds = append(bounds, b)
+			}
+			sort.Ints(bounds)
+			// Buckets are keyed by their lower bound in milliseconds, so
+			// the observations below a key are those of the buckets
+			// before it. The last bucket has no known upper bound and is
+			// only counted in +Inf.
+			var count uint64
+			for n, b := range bounds {
+				count += buckets[b]
+				if n+1 == len(bounds) {
+					break
+				}
+				le := formatFloat(float64(bounds[n+1]) / 1000)
+				fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, le, count)
+			}
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, count)
+			fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, count)
+		}
+	}
+}
+
+// ServeHTTP writes the accumulated metrics as an HTTP response, so that an
+// Exporter can be mounted as a scrape endpoint.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_ = e.Write(w)
+}
+
+// RunRealtime subscribes to the realtime stats of the given services and adds
+// every record to the exporter until ctx is cancelled or every subscription
+// ends. Subscription errors are passed to onError, which may be nil.
+func (e *Exporter) RunRealtime(ctx context.Context, c *fastly.RTSClient, serviceIDs []string, opts *fastly.SubscribeOptions, onError func(error)) {
+	data, errs := c.SubscribeMany(ctx, serviceIDs, opts)
+	for data != nil || errs != nil {
+		select {
+		case d, ok := <-data:
+			if !ok {
+				data = nil
+				continue
+			}
+			e.AddRealtime(d.ServiceID, d.Data)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// CollectHistorical retrieves historical stats for a single service with
+// GetStats and adds them to the exporter. i.Service is required.
+func (e *Exporter) CollectHistorical(ctx context.Context, c *fastly.Client, i *fastly.GetStatsInput) error {
+	if i.Service == nil || *i.Service == "" {
+		return fastly.ErrMissingServiceID
+	}
+
+	resp, err := c.GetStats(ctx, i)
+	if err != nil {
+		return err
+	}
+	e.AddHistorical(*i.Service, resp.Data)
+	return nil
+}
+
+// seriesLabels formats the labels of a series, without braces.
+func seriesLabels(k seriesKey) string {
+	labels := `service_id="` + escapeLabel(k.serviceID) + `"`
+	if k.datacenter != "" {
+		labels += `,datacenter="` + escapeLabel(k.datacenter) + `"`
+	}
+	return labels
+}
+
+// labelEscaper escapes label values as required by the text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value.
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+// formatFloat formats a sample value.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,141 @@
+package exporter
+
+import (
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+func TestFields(t *testing.T) {
+	t.Parallel()
+
+	fields := Fields()
+	for _, name := range []string{"all_status_5xx", "bereq_body_bytes", "hit_ratio", "miss_histogram", "requests"} {
+		if !slices.Contains(fields, name) {
+			t.Errorf("Fields() is missing %q", name)
+		}
+	}
+	if slices.Contains(fields, "start_time") {
+		t.Error("Fields() includes start_time")
+	}
+	if !slices.IsSorted(fields) {
+		t.Error("Fields() is not sorted")
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(&Options{Fields: []string{"requests", "nope", "also_nope"}}); err == nil || !strings.Contains(err.Error(), "also_nope, nope") {
+		t.Errorf("unexpected error for unknown fields: %v", err)
+	}
+	if _, err := New(&Options{Namespace: "bad-name"}); err == nil {
+		t.Error("expected error for invalid namespace")
+	}
+	if _, err := New(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestExporter_Write(t *testing.T) {
+	t.Parallel()
+
+	e, err := New(&Options{Fields: []string{"hit_ratio", "miss_histogram", "requests"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.AddRealtime("svc", &fastly.RealtimeData{
+		Datacenter: map[string]*fastly.Stats{
+			"LHR": {HitRatio: fastly.ToPointer(0.5), Requests: fastly.ToPointer(uint64(10))},
+			"SJC": {MissHistogram: map[int]int{10: 2, 100: 1}, Requests: fastly.ToPointer(uint64(4))},
+		},
+		Recorded: fastly.ToPointer(uint64(100)),
+	})
+	e.AddRealtime("svc", &fastly.RealtimeData{
+		Datacenter: map[string]*fastly.Stats{
+			"LHR": {HitRatio: fastly.ToPointer(0.75), Requests: fastly.ToPointer(uint64(5))},
+			"SJC": {MissHistogram: map[int]int{10: 1, 20: 3}},
+		},
+		Recorded: fastly.ToPointer(uint64(101)),
+	})
+	// A second that was already added is ignored.
+	e.AddRealtime("svc", &fastly.RealtimeData{
+		Datacenter: map[string]*fastly.Stats{
+			"LHR": {Requests: fastly.ToPointer(uint64(1000))},
+		},
+		Recorded: fastly.ToPointer(uint64(101)),
+	})
+	e.AddHistorical(`a"b`, []*fastly.Stats{
+		{Requests: fastly.ToPointer(uint64(7)), StartTime: fastly.ToPointer(uint64(60))},
+		{Requests: fastly.ToPointer(uint64(3)), StartTime: fastly.ToPointer(uint64(120))},
+		{Requests: fastly.ToPointer(uint64(3)), StartTime: fastly.ToPointer(uint64(120))},
+	})
+
+	// Histogram keys are the lower bounds of the buckets, so each bucket is
+	// counted below the key that follows it.
+	want := `# TYPE fastly_rt_hit_ratio gauge
+fastly_rt_hit_ratio{service_id="svc",datacenter="LHR"} 0.75
+# TYPE fastly_rt_miss_histogram histogram
+fastly_rt_miss_histogram_bucket{service_id="svc",datacenter="SJC",le="0.02"} 3
+fastly_rt_miss_histogram_bucket{service_id="svc",datacenter="SJC",le="0.1"} 6
+fastly_rt_miss_histogram_bucket{service_id="svc",datacenter="SJC",le="+Inf"} 7
+fastly_rt_miss_histogram_count{service_id="svc",datacenter="SJC"} 7
+# TYPE fastly_rt_requests counter
+fastly_rt_requests_total{service_id="svc",datacenter="LHR"} 15
+fastly_rt_requests_total{service_id="svc",datacenter="SJC"} 4
+# TYPE fastly_historical_requests counter
+fastly_historical_requests_total{service_id="a\"b"} 10
+# EOF
+`
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("got Content-Type %q", ct)
+	}
+	if diff := cmp.Diff(want, rec.Body.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+
+	e.Reset()
+	var b strings.Builder
+	if err := e.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "# EOF\n" {
+		t.Errorf("unexpected output after Reset: %q", b.String())
+	}
+}
+
+func TestExporter_AggregateOnly(t *testing.T) {
+	t.Parallel()
+
+	e, err := New(&Options{AggregateOnly: true, Fields: []string{"requests"}, Namespace: "cdn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.AddRealtime("svc", &fastly.RealtimeData{
+		Aggregated: &fastly.Stats{Requests: fastly.ToPointer(uint64(3))},
+		Datacenter: map[string]*fastly.Stats{
+			"LHR": {Requests: fastly.ToPointer(uint64(3))},
+		},
+	})
+
+	var b strings.Builder
+	if err := e.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# TYPE cdn_rt_requests counter
+cdn_rt_requests_total{service_id="svc"} 3
+# EOF
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+// metricKind is the OpenMetrics type a Stats field is exported as.
+type metricKind int
+
+const (
+	// kindCounter fields are summed across records.
+	kindCounter metricKind = iota
+	// kindGauge fields hold the most recent value.
+	kindGauge
+	// kindHistogram fields are bucket counts summed across records.
+	kindHistogram
+)
+
+// field describes an exportable field of fastly.Stats.
+type field struct {
+	index int
+	kind  metricKind
+	name  string
+}
+
+// gaugeFields are numeric fields that are ratios rather than counts.
+var gaugeFields = map[string]bool{
+	"hit_ratio":      true,
+	"origin_offload": true,
+}
+
+// skippedFields are fields that are not measurements.
+var skippedFields = map[string]bool{
+	"start_time": true,
+}
+
+// statsFields lists the exportable fields of fastly.Stats in declaration
+// order.
+var statsFields = func() []field {
+	t := reflect.TypeFor[fastly.Stats]()
+	fields := make([]field, 0, t.NumField())
+	for n := range t.NumField() {
+		f := t.Field(n)
+		name := f.Tag.Get("mapstructure")
+		if name == "" || skippedFields[name] {
+			continue
+		}
+
+		var kind metricKind
+		switch {
+		case f.Type == reflect.TypeFor[map[int]int]():
+			kind = kindHistogram
+		case gaugeFields[name]:
+			kind = kindGauge
+		case f.Type == reflect.TypeFor[*uint64](), f.Type == reflect.TypeFor[*float64]():
+			kind = kindCounter
+		default:
+			continue
+		}
+		fields = append(fields, field{index: n, kind: kind, name: name})
+	}
+	return fields
+}()
+
+// Fields returns the names of all exportable stats fields, which are the
+// mapstructure tags of fastly.Stats, sorted alphabetically.
+func Fields() []string {
+	names := make([]string, len(statsFields))
+	for n, f := range statsFields {
+		names[n] = f.name
+	}
+	sort.Strings(names)
+	return names
+}
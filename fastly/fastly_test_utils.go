@@ -1,9 +1,16 @@
 package fastly
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 
@@ -107,6 +114,56 @@ func RecordIgnoreBody(t *testing.T, fixture string, f func(*Client)) {
 	}
 }
 
+// RecordMatchBody is like Record, but a request only matches a recorded
+// interaction if its body matches too, so that the fixture checks what the
+// client sends. JSON and form bodies are compared by value.
+func RecordMatchBody(t *testing.T, fixture string, f func(*Client)) {
+	client := DefaultClient()
+
+	if vcrDisabled() {
+		f(client)
+	} else {
+		r := getRecorder(t, fixture)
+		defer stopRecorder(t, r)
+		r.SetMatcher(matchBody)
+		client.HTTPClient.Transport = r
+		f(client)
+	}
+}
+
+// matchBody matches a request to a recorded interaction on its method, URL
+// and body.
+func matchBody(r *http.Request, i cassette.Request) bool {
+	if !cassette.DefaultMatcher(r, i) {
+		return false
+	}
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return false
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if string(body) == i.Body {
+		return true
+	}
+
+	var got, want any
+	if json.Unmarshal(body, &got) == nil && json.Unmarshal([]byte(i.Body), &want) == nil {
+		return reflect.DeepEqual(got, want)
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		gotForm, err := url.ParseQuery(string(body))
+		if err != nil {
+			return false
+		}
+		wantForm, err := url.ParseQuery(i.Body)
+		return err == nil && reflect.DeepEqual(gotForm, wantForm)
+	}
+	return false
+}
+
 func getRecorder(t *testing.T, fixture string) *recorder.Recorder {
 	r, err := recorder.New("fixtures/" + fixture)
 	if err != nil {
@@ -0,0 +1,462 @@
+package fastly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDashboardItemSpan is the span given to dashboard items whose
+// definition does not set one.
+const DefaultDashboardItemSpan = 4
+
+// DashboardSourceTypes is a list of supported dashboard data sources.
+var DashboardSourceTypes = []DashboardSourceType{
+	SourceTypeStatsEdge,
+	SourceTypeStatsDomain,
+	SourceTypeStatsOrigin,
+}
+
+// PlotTypes is a list of supported dashboard plot types.
+var PlotTypes = []PlotType{
+	PlotTypeLine,
+	PlotTypeBar,
+	PlotTypeDonut,
+	PlotTypeSingleMetric,
+}
+
+// VisualizationFormats is a list of supported dashboard visualization formats.
+var VisualizationFormats = []VisualizationFormat{
+	VisualizationFormatNumber,
+	VisualizationFormatBytes,
+	VisualizationFormatPercent,
+	VisualizationFormatRequests,
+	VisualizationFormatResponses,
+	VisualizationFormatSeconds,
+	VisualizationFormatMilliseconds,
+	VisualizationFormatRatio,
+	VisualizationFormatBitrate,
+}
+
+// CalculationMethods is a list of supported dashboard calculation methods.
+var CalculationMethods = []CalculationMethod{
+	CalculationMethodAvg,
+	CalculationMethodSum,
+	CalculationMethodMin,
+	CalculationMethodMax,
+	CalculationMethodLatest,
+}
+
+// dashboardMetricTypes maps each data source to the struct whose
+// mapstructure tags name its metrics.
+var dashboardMetricTypes = map[DashboardSourceType]reflect.Type{
+	SourceTypeStatsEdge:   reflect.TypeFor[Stats](),
+	SourceTypeStatsDomain: reflect.TypeFor[DomainMetrics](),
+	SourceTypeStatsOrigin: reflect.TypeFor[OriginMetrics](),
+}
+
+// DashboardMetrics returns the sorted metric names known for a data source, or
+// nil if the source is not supported.
+func DashboardMetrics(sourceType DashboardSourceType) []string {
+	t, ok := dashboardMetricTypes[sourceType]
+	if !ok {
+		return nil
+	}
+	var metrics []string
+	for n := range t.NumField() {
+		if tag := t.Field(n).Tag.Get("mapstructure"); tag != "" {
+			metrics = append(metrics, tag)
+		}
+	}
+	sort.Strings(metrics)
+	return metrics
+}
+
+// DashboardDefinition is the desired state of a custom dashboard, as kept in
+// a YAML or JSON file. Its keys are those of the API, for example:
+//
+//	name: Edge overview
+//	description: Requests and errors across all services
+//	items:
+//	  - title: Requests
+//	    data_source:
+//	      type: stats.edge
+//	      config:
+//	        metrics: [requests]
+//	    visualization:
+//	      config:
+//	        plot_type: line
+//
+// Dashboards are matched to their definition by name.
+type DashboardDefinition struct {
+	// Description is a short description of the dashboard.
+	Description string `json:"description"`
+	// Items is the list of items of the dashboard, in display order.
+	Items []DashboardItem `json:"items"`
+	// Name is the name of the dashboard (required).
+	Name string `json:"name"`
+}
+
+// DashboardDefinitionError describes the problems found by
+// DashboardDefinition.Validate.
+type DashboardDefinitionError struct {
+	// Name is the name of the invalid definition.
+	Name string
+	// Problems lists each problem found.
+	Problems []string
+}
+
+// Error implements the error interface.
+func (e *DashboardDefinitionError) Error() string {
+	return fmt.Sprintf("invalid dashboard %q: %s", e.Name, strings.Join(e.Problems, "; "))
+}
+
+// ParseDashboardDefinition decodes a definition from YAML or JSON, which is a
+// subset of YAML. Unknown keys are rejected, missing spans default to
+// DefaultDashboardItemSpan and missing visualization types to
+// VisualizationTypeChart. The definition is not validated.
+func ParseDashboardDefinition(data []byte) (*DashboardDefinition, error) {
+	// Decode YAML generically and re-encode it as JSON so that both formats
+	// share the JSON field names of the API types.
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+	var d DashboardDefinition
+	if err := dec.Decode(&d); err != nil {
+		return nil, err
+	}
+	if d.Items == nil {
+		d.Items = []DashboardItem{}
+	}
+	for n := range d.Items {
+		item := &d.Items[n]
+		if item.Span == 0 {
+			item.Span = DefaultDashboardItemSpan
+		}
+		if item.Visualization.Type == "" {
+			item.Visualization.Type = VisualizationTypeChart
+		}
+	}
+	return &d, nil
+}
+
+// LoadDashboardDefinitions reads and validates every .yaml, .yml and .json
+// file of a directory, in file name order. Names must be unique across the
+// directory.
+func LoadDashboardDefinitions(dir string) ([]*DashboardDefinition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []*DashboardDefinition
+	files := make(map[string]string)
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if e.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+		// #nosec G304 -- reading the definitions of the caller's directory is the purpose of this function
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		d, err := ParseDashboardDefinition(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if err := d.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if other, ok := files[d.Name]; ok {
+			return nil, fmt.Errorf("%s: %w %q, also defined in %s", path, ErrDuplicateDashboardName, d.Name, other)
+		}
+		files[d.Name] = path
+		defs = append(defs, d)
+	}
+	return defs, nil
+}
+
+// Validate checks a definition against the data sources, metrics, plot
+// types, formats and calculation methods supported by the API, returning a
+// *DashboardDefinitionError listing every problem found.
+func (d *DashboardDefinition) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if d.Name == "" {
+		add("name is required")
+	}
+	for n, item := range d.Items {
+		prefix := fmt.Sprintf("item %d", n+1)
+		if item.Title != "" {
+			prefix = fmt.Sprintf("item %d (%s)", n+1, item.Title)
+		}
+
+		sourceType := item.DataSource.Type
+		if known := DashboardMetrics(sourceType); known == nil {
+			add("%s: unknown data source type %q", prefix, sourceType)
+		} else {
+			if len(item.DataSource.Config.Metrics) == 0 {
+				add("%s: at least one metric is required", prefix)
+			}
+			for _, m := range item.DataSource.Config.Metrics {
+				if _, ok := slices.BinarySearch(known, m); !ok {
+					add("%s: unknown %s metric %q", prefix, sourceType, m)
+				}
+			}
+		}
+
+		if item.Span < 1 || item.Span > 12 {
+			add("%s: span must be between 1 and 12", prefix)
+		}
+		if item.Visualization.Type != VisualizationTypeChart {
+			add("%s: unsupported visualization type %q", prefix, item.Visualization.Type)
+		}
+		cfg := item.Visualization.Config
+		if !slices.Contains(PlotTypes, cfg.PlotType) {
+			add("%s: unknown plot type %q", prefix, cfg.PlotType)
+		}
+		if cfg.Format != nil && !slices.Contains(VisualizationFormats, *cfg.Format) {
+			add("%s: unknown format %q", prefix, *cfg.Format)
+		}
+		if cfg.CalculationMethod != nil && !slices.Contains(CalculationMethods, *cfg.CalculationMethod) {
+			add("%s: unknown calculation method %q", prefix, *cfg.CalculationMethod)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &DashboardDefinitionError{Name: d.Name, Problems: problems}
+	}
+	return nil
+}
+
+// DashboardSyncReport describes the changes made (or, for a dry run, the
+// changes that would be made) by SyncDashboards. Keys are dashboard names.
+type DashboardSyncReport struct {
+	SyncReport
+	// Diffs holds a line diff of the JSON form of each created, updated or
+	// deleted dashboard, keyed by name. Lines are prefixed with "-" when
+	// removed, "+" when added and " " when unchanged.
+	Diffs map[string]string
+}
+
+// SyncDashboardsInput is used as input to the SyncDashboards function.
+type SyncDashboardsInput struct {
+	// Dir is the directory holding the dashboard definitions (required).
+	Dir string
+	// DryRun computes the changes without applying them.
+	DryRun bool
+	// Prune deletes dashboards whose name has no definition. By default they
+	// are left untouched.
+	Prune bool
+}
+
+// SyncDashboards makes the custom dashboards of the account match the
+// definitions of a directory, as loaded by LoadDashboardDefinitions.
+//
+// Dashboards are matched by name: missing dashboards are created, those that
+// differ from their definition are updated and, with Prune, dashboards without
+// a definition are deleted. Syncing twice in a row makes no changes the second
+// time. An error is returned if several existing dashboards share a name.
+func (c *Client) SyncDashboards(ctx context.Context, i *SyncDashboardsInput) (*DashboardSyncReport, error) {
+	if i.Dir == "" {
+		return nil, ErrMissingDir
+	}
+	defs, err := LoadDashboardDefinitions(i.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := c.listDashboardsByName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DashboardSyncReport{
+		SyncReport: SyncReport{DryRun: i.DryRun},
+		Diffs:      make(map[string]string),
+	}
+	desired := make(map[string]bool, len(defs))
+	for _, d := range defs {
+		desired[d.Name] = true
+		want := dashboardJSON(d)
+
+		existing, ok := current[d.Name]
+		if !ok {
+			report.Created = append(report.Created, d.Name)
+			report.Diffs[d.Name] = diffLines("", want)
+			if !i.DryRun {
+				if _, err := c.CreateObservabilityCustomDashboard(ctx, &CreateObservabilityCustomDashboardInput{
+					Description: ToPointer(d.Description),
+					Items:       d.Items,
+					Name:        d.Name,
+				}); err != nil {
+					return report, fmt.Errorf("creating dashboard %q: %w", d.Name, err)
+				}
+			}
+			continue
+		}
+
+		have := dashboardJSON(existingDashboardDefinition(existing))
+		if have == want {
+			report.Unchanged++
+			continue
+		}
+		report.Updated = append(report.Updated, d.Name)
+		report.Diffs[d.Name] = diffLines(have, want)
+		if !i.DryRun {
+			if _, err := c.UpdateObservabilityCustomDashboard(ctx, &UpdateObservabilityCustomDashboardInput{
+				Description: ToPointer(d.Description),
+				ID:          ToPointer(existing.ID),
+				Items:       &d.Items,
+				Name:        ToPointer(d.Name),
+			}); err != nil {
+				return report, fmt.Errorf("updating dashboard %q: %w", d.Name, err)
+			}
+		}
+	}
+
+	if i.Prune {
+		names := make([]string, 0, len(current))
+		for name := range current {
+			if !desired[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			existing := current[name]
+			report.Deleted = append(report.Deleted, name)
+			report.Diffs[name] = diffLines(dashboardJSON(existingDashboardDefinition(existing)), "")
+			if !i.DryRun {
+				if err := c.DeleteObservabilityCustomDashboard(ctx, &DeleteObservabilityCustomDashboardInput{
+					ID: ToPointer(existing.ID),
+				}); err != nil {
+					return report, fmt.Errorf("deleting dashboard %q: %w", name, err)
+				}
+			}
+		}
+	}
+
+	sort.Strings(report.Created)
+	sort.Strings(report.Updated)
+	return report, nil
+}
+
+// listDashboardsByName lists every dashboard of the account, following
+// pagination cursors, keyed by name.
+func (c *Client) listDashboardsByName(ctx context.Context) (map[string]*ObservabilityCustomDashboard, error) {
+	byName := make(map[string]*ObservabilityCustomDashboard)
+	input := &ListObservabilityCustomDashboardsInput{}
+	for {
+		resp, err := c.ListObservabilityCustomDashboards(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for n := range resp.Data {
+			d := &resp.Data[n]
+			if _, ok := byName[d.Name]; ok {
+				return nil, fmt.Errorf("%w %q", ErrDuplicateDashboardName, d.Name)
+			}
+			byName[d.Name] = d
+		}
+		if resp.Meta.NextCursor == "" || (input.Cursor != nil && *input.Cursor == resp.Meta.NextCursor) {
+			return byName, nil
+		}
+		input = &ListObservabilityCustomDashboardsInput{Cursor: ToPointer(resp.Meta.NextCursor)}
+	}
+}
+
+// existingDashboardDefinition converts a dashboard to a definition, dropping
+// the read-only item IDs so it can be compared with the desired state.
+func existingDashboardDefinition(d *ObservabilityCustomDashboard) *DashboardDefinition {
+	items := make([]DashboardItem, len(d.Items))
+	for n, item := range d.Items {
+		item.ID = ""
+		items[n] = item
+	}
+	return &DashboardDefinition{Description: d.Description, Items: items, Name: d.Name}
+}
+
+// dashboardJSON returns the indented JSON form of a definition, used both to
+// compare definitions and to render diffs.
+func dashboardJSON(d *DashboardDefinition) string {
+	if d.Items == nil {
+		d = &DashboardDefinition{Description: d.Description, Items: []DashboardItem{}, Name: d.Name}
+	}
+	// DashboardDefinition only holds types that always marshal.
+	js, _ := json.MarshalIndent(d, "", "  ")
+	return string(js)
+}
+
+// diffLines returns a line diff turning a into b, based on their longest
+// common subsequence of lines.
+func diffLines(a, b string) string {
+	var al, bl []string
+	if a != "" {
+		al = strings.Split(a, "\n")
+	}
+	if b != "" {
+		bl = strings.Split(b, "\n")
+	}
+
+	// lcs[x][y] is the length of the longest common subsequence of al[x:] and
+	// bl[y:].
+	lcs := make([][]int, len(al)+1)
+	for x := range lcs {
+		lcs[x] = make([]int, len(bl)+1)
+	}
+	for x := len(al) - 1; x >= 0; x-- {
+		for y := len(bl) - 1; y >= 0; y-- {
+			if al[x] == bl[y] {
+				lcs[x][y] = lcs[x+1][y+1] + 1
+			} else {
+				lcs[x][y] = max(lcs[x+1][y], lcs[x][y+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	x, y := 0, 0
+	for x < len(al) || y < len(bl) {
+		switch {
+		case x < len(al) && y < len(bl) && al[x] == bl[y]:
+			sb.WriteString(" " + al[x] + "\n")
+			x++
+			y++
+		case x < len(al) && (y == len(bl) || lcs[x+1][y] >= lcs[x][y+1]):
+			sb.WriteString("-" + al[x] + "\n")
+			x++
+		default:
+			sb.WriteString("+" + bl[y] + "\n")
+			y++
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,237 @@
+package fastly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testDashboardYAML = `
+name: Edge overview
+description: Requests and errors
+items:
+  - title: Requests
+    data_source:
+      type: stats.edge
+      config:
+        metrics: [requests, status_5xx]
+    visualization:
+      config:
+        plot_type: line
+        format: requests
+`
+
+func TestParseDashboardDefinition(t *testing.T) {
+	t.Parallel()
+
+	want := &DashboardDefinition{
+		Description: "Requests and errors",
+		Items: []DashboardItem{NewDashboardItem(
+			SourceTypeStatsEdge,
+			[]string{"requests", "status_5xx"},
+			PlotTypeLine,
+			WithTitle("Requests"),
+			WithSpan(DefaultDashboardItemSpan),
+			WithFormat(VisualizationFormatRequests),
+		)},
+		Name: "Edge overview",
+	}
+
+	got, err := ParseDashboardDefinition([]byte(testDashboardYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected YAML definition (-want +got):\n%s", diff)
+	}
+	if err := got.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	got, err = ParseDashboardDefinition([]byte(dashboardJSON(want)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected JSON definition (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseDashboardDefinition([]byte("name: x\ncolour: red\n")); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
+
+func TestDashboardDefinition_Validate(t *testing.T) {
+	t.Parallel()
+
+	d := &DashboardDefinition{
+		Items: []DashboardItem{
+			NewDashboardItem(SourceTypeStatsEdge, []string{"requests", "nope"}, "pie", WithSpan(13), WithFormat("furlongs")),
+			NewDashboardItem("stats.nope", nil, PlotTypeBar, WithSpan(4), WithCalculationMethod("median")),
+			NewDashboardItem(SourceTypeStatsOrigin, nil, PlotTypeBar, WithSpan(4), WithTitle("Origins")),
+			NewDashboardItem(SourceTypeStatsDomain, []string{"edge_requests"}, PlotTypeDonut, WithSpan(12)),
+		},
+	}
+
+	err := d.Validate()
+	var derr *DashboardDefinitionError
+	if !errors.As(err, &derr) {
+		t.Fatalf("expected a *DashboardDefinitionError, got %v", err)
+	}
+	want := []string{
+		"name is required",
+		`item 1: unknown stats.edge metric "nope"`,
+		"item 1: span must be between 1 and 12",
+		`item 1: unknown plot type "pie"`,
+		`item 1: unknown format "furlongs"`,
+		`item 2: unknown data source type "stats.nope"`,
+		`item 2: unknown calculation method "median"`,
+		"item 3 (Origins): at least one metric is required",
+	}
+	if diff := cmp.Diff(want, derr.Problems); diff != "" {
+		t.Errorf("unexpected problems (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadDashboardDefinitions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("b.yaml", testDashboardYAML)
+	write("a.json", `{"name": "Empty"}`)
+	write("README.md", "ignored")
+
+	defs, err := LoadDashboardDefinitions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 2 || defs[0].Name != "Empty" || defs[1].Name != "Edge overview" {
+		t.Errorf("unexpected definitions: %+v", defs)
+	}
+
+	write("c.yml", testDashboardYAML)
+	if _, err := LoadDashboardDefinitions(dir); !errors.Is(err, ErrDuplicateDashboardName) {
+		t.Errorf("expected ErrDuplicateDashboardName, got %v", err)
+	}
+}
+
+// testDashboardDefinitions returns the definitions synced by
+// TestSyncDashboards.
+func testDashboardDefinitions() []*DashboardDefinition {
+	return []*DashboardDefinition{
+		{Description: "new", Items: []DashboardItem{NewDashboardItem(SourceTypeStatsEdge, []string{"requests"}, PlotTypeLine, WithSpan(4))}, Name: "Changed"},
+		{Description: "old", Items: []DashboardItem{NewDashboardItem(SourceTypeStatsEdge, []string{"requests"}, PlotTypeLine, WithSpan(4))}, Name: "Unchanged"},
+		{Description: "created", Name: "New"},
+	}
+}
+
+// writeDashboardDefinitions writes defs as JSON files to a temporary
+// directory and returns it.
+func writeDashboardDefinitions(t *testing.T, defs []*DashboardDefinition) string {
+	dir := t.TempDir()
+	for n, d := range defs {
+		data, err := json.Marshal(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.json", n)), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSyncDashboards(t *testing.T) {
+	t.Parallel()
+
+	defs := testDashboardDefinitions()
+	dir := writeDashboardDefinitions(t, defs)
+
+	var (
+		dryRun, report, again, kept *DashboardSyncReport
+		err                         error
+	)
+	// The account starts with the dashboards Changed, Stale and Unchanged.
+	// A request made by a dry run, or by a sync that has nothing to do,
+	// would not match the fixture.
+	RecordMatchBody(t, "observability_custom_dashboards/sync", func(c *Client) {
+		if dryRun, err = c.SyncDashboards(context.TODO(), &SyncDashboardsInput{Dir: dir, DryRun: true, Prune: true}); err != nil {
+			return
+		}
+		if report, err = c.SyncDashboards(context.TODO(), &SyncDashboardsInput{Dir: dir, Prune: true}); err != nil {
+			return
+		}
+		if again, err = c.SyncDashboards(context.TODO(), &SyncDashboardsInput{Dir: dir, Prune: true}); err != nil {
+			return
+		}
+		// Without Prune, dashboards without a definition are kept.
+		kept, err = c.SyncDashboards(context.TODO(), &SyncDashboardsInput{Dir: writeDashboardDefinitions(t, defs[:1])})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := SyncReport{
+		Created:   []string{"New"},
+		Deleted:   []string{"Stale"},
+		DryRun:    true,
+		Unchanged: 1,
+		Updated:   []string{"Changed"},
+	}
+	if diff := cmp.Diff(want, dryRun.SyncReport); diff != "" {
+		t.Errorf("unexpected dry-run report (-want +got):\n%s", diff)
+	}
+	wantDiff := " {\n-  \"description\": \"old\",\n+  \"description\": \"new\",\n"
+	if got := dryRun.Diffs["Changed"]; len(got) < len(wantDiff) || got[:len(wantDiff)] != wantDiff {
+		t.Errorf("unexpected diff:\n%s", got)
+	}
+
+	want.DryRun = false
+	if diff := cmp.Diff(want, report.SyncReport); diff != "" {
+		t.Errorf("unexpected report (-want +got):\n%s", diff)
+	}
+
+	// Syncing again is a no-op.
+	if again.HasChanges() || again.Unchanged != 3 {
+		t.Errorf("unexpected second sync: %+v", again.SyncReport)
+	}
+	if kept.HasChanges() || kept.Unchanged != 1 {
+		t.Errorf("unexpected sync without prune: %+v", kept.SyncReport)
+	}
+}
+
+func TestSyncDashboards_duplicateNames(t *testing.T) {
+	t.Parallel()
+
+	var err error
+	Record(t, "observability_custom_dashboards/sync_duplicate_names", func(c *Client) {
+		_, err = c.SyncDashboards(context.TODO(), &SyncDashboardsInput{Dir: t.TempDir()})
+	})
+	if !errors.Is(err, ErrDuplicateDashboardName) {
+		t.Errorf("expected ErrDuplicateDashboardName, got %v", err)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	t.Parallel()
+
+	got := diffLines("a\nb\nc", "a\nc\nd")
+	want := " a\n-b\n c\n+d\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := diffLines("", "x"); got != "+x\n" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -195,6 +195,9 @@ func (c *Client) GetLogRecords(ctx context.Context, i *GetLogRecordsInput) (*Log
 		if filter.Operator == "" {
 			return nil, ErrMissingOperator
 		}
+		if filter.Operator == LogExplorerFilterOperatorContains {
+			return nil, ErrUnsupportedLogExplorerContains
+		}
 
 		key := fmt.Sprintf("filter[%s][%s]", filter.Field, filter.Operator)
 		if value, ok := requestOptions.Params[key]; ok && value != filter.Value {
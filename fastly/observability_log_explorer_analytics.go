@@ -0,0 +1,226 @@
+package fastly
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LogRecordKey extracts the value records are grouped by, reporting false for
+// records that have no value and should be left out.
+type LogRecordKey func(*LogRecord) (string, bool)
+
+// LogRecordByPOP groups records by Fastly POP.
+func LogRecordByPOP(r *LogRecord) (string, bool) {
+	return stringLogRecordKey(r.FastlyPOP)
+}
+
+// LogRecordByCountry groups records by client country code.
+func LogRecordByCountry(r *LogRecord) (string, bool) {
+	return stringLogRecordKey(r.ClientCountryCode)
+}
+
+// LogRecordByHost groups records by request host.
+func LogRecordByHost(r *LogRecord) (string, bool) {
+	return stringLogRecordKey(r.RequestHost)
+}
+
+// LogRecordByStatus groups records by HTTP response status.
+func LogRecordByStatus(r *LogRecord) (string, bool) {
+	if r.ResponseStatus == nil {
+		return "", false
+	}
+	return strconv.Itoa(*r.ResponseStatus), true
+}
+
+// LogRecordByStatusClass groups records by HTTP response status class, such
+// as 2xx or 5xx.
+func LogRecordByStatusClass(r *LogRecord) (string, bool) {
+	if r.ResponseStatus == nil {
+		return "", false
+	}
+	return fmt.Sprintf("%dxx", *r.ResponseStatus/100), true
+}
+
+// LogRecordByPathPrefix returns a key grouping records by the first segments
+// of their request path, ignoring any query string. For example, with two
+// segments /api/v1/users?id=1 is grouped under /api/v1.
+func LogRecordByPathPrefix(segments int) LogRecordKey {
+	return func(r *LogRecord) (string, bool) {
+		if r.RequestPath == nil {
+			return "", false
+		}
+		path, _, _ := strings.Cut(*r.RequestPath, "?")
+		parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		if len(parts) > segments {
+			parts = parts[:segments]
+		}
+		return "/" + strings.Join(parts, "/"), true
+	}
+}
+
+// LogRecordByField returns a key grouping records by the LogRecord field
+// with the given JSON name, such as client_browser_name or is_shield.
+func LogRecordByField(name string) (LogRecordKey, error) {
+	for n, column := range logRecordColumns {
+		if column == name {
+			return func(r *LogRecord) (string, bool) {
+				return logRecordFieldString(reflect.ValueOf(r).Elem().Field(n))
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown log record field %q", name)
+}
+
+// stringLogRecordKey returns the value of an optional string field.
+func stringLogRecordKey(s *string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	return *s, true
+}
+
+// LogRecordStats is an aggregate over a set of log records.
+type LogRecordStats struct {
+	// CacheHitRatio is the ratio of cache hits to records with a known cache
+	// state. It is zero when no record has a known cache state.
+	CacheHitRatio float64
+	// CacheHits is the number of records served from cache.
+	CacheHits int
+	// CacheMisses is the number of records not served from cache.
+	CacheMisses int
+	// Count is the number of records.
+	Count int
+	// Errors is the number of records with a 5xx response status.
+	Errors int
+	// ResponseBytes is the total number of response header and body bytes.
+	ResponseBytes uint64
+	// ResponseTimeMax is the highest response time.
+	ResponseTimeMax float64
+	// ResponseTimeP50 is the median response time.
+	ResponseTimeP50 float64
+	// ResponseTimeP90 is the 90th percentile response time.
+	ResponseTimeP90 float64
+	// ResponseTimeP95 is the 95th percentile response time.
+	ResponseTimeP95 float64
+	// ResponseTimeP99 is the 99th percentile response time.
+	ResponseTimeP99 float64
+}
+
+// LogRecordGroup is the aggregate of the records sharing a key.
+type LogRecordGroup struct {
+	LogRecordStats
+	// Key is the value the records were grouped by.
+	Key string
+}
+
+// LogRecordTable is a list of groups, as returned by GroupLogRecords.
+type LogRecordTable []LogRecordGroup
+
+// SummarizeLogRecords aggregates records into a single set of stats. Response
+// time percentiles only consider records with a response time and are
+// interpolated linearly between the closest ranks.
+func SummarizeLogRecords(records []*LogRecord) LogRecordStats {
+	var (
+		s     LogRecordStats
+		times []float64
+	)
+	for _, r := range records {
+		if r == nil {
+			continue
+		}
+		s.Count++
+		if r.IsCacheHit != nil {
+			if *r.IsCacheHit {
+				s.CacheHits++
+			} else {
+				s.CacheMisses++
+			}
+		}
+		if r.ResponseStatus != nil && *r.ResponseStatus >= 500 && *r.ResponseStatus < 600 {
+			s.Errors++
+		}
+		s.ResponseBytes += ToValue(r.ResponseBytesHeader) + ToValue(r.ResponseBytesBody)
+		if r.ResponseTime != nil {
+			times = append(times, *r.ResponseTime)
+		}
+	}
+
+	if known := s.CacheHits + s.CacheMisses; known > 0 {
+		s.CacheHitRatio = float64(s.CacheHits) / float64(known)
+	}
+	if len(times) > 0 {
+		sort.Float64s(times)
+		s.ResponseTimeMax = times[len(times)-1]
+		s.ResponseTimeP50 = logRecordPercentile(times, 0.5)
+		s.ResponseTimeP90 = logRecordPercentile(times, 0.9)
+		s.ResponseTimeP95 = logRecordPercentile(times, 0.95)
+		s.ResponseTimeP99 = logRecordPercentile(times, 0.99)
+	}
+	return s
+}
+
+// GroupLogRecords aggregates records by key. Records for which key reports
+// false are left out. The table is ordered by descending Count, then by Key.
+func GroupLogRecords(records []*LogRecord, key LogRecordKey) LogRecordTable {
+	groups := make(map[string][]*LogRecord)
+	for _, r := range records {
+		if r == nil {
+			continue
+		}
+		if k, ok := key(r); ok {
+			groups[k] = append(groups[k], r)
+		}
+	}
+
+	table := make(LogRecordTable, 0, len(groups))
+	for k, rs := range groups {
+		table = append(table, LogRecordGroup{Key: k, LogRecordStats: SummarizeLogRecords(rs)})
+	}
+	sort.Slice(table, func(a, b int) bool {
+		if table[a].Count != table[b].Count {
+			return table[a].Count > table[b].Count
+		}
+		return table[a].Key < table[b].Key
+	})
+	return table
+}
+
+// Sort returns a copy of the table ordered by less, keeping the existing order
+// of equal groups. For example, to list the slowest POPs first:
+//
+//	table.Sort(func(a, b LogRecordGroup) bool { return a.ResponseTimeP95 > b.ResponseTimeP95 })
+func (t LogRecordTable) Sort(less func(a, b LogRecordGroup) bool) LogRecordTable {
+	sorted := append(LogRecordTable(nil), t...)
+	sort.SliceStable(sorted, func(a, b int) bool { return less(sorted[a], sorted[b]) })
+	return sorted
+}
+
+// Top returns the first n groups of the table.
+func (t LogRecordTable) Top(n int) LogRecordTable {
+	if n < len(t) {
+		return t[:max(n, 0)]
+	}
+	return t
+}
+
+// Keys returns the key of each group, in table order.
+func (t LogRecordTable) Keys() []string {
+	keys := make([]string, len(t))
+	for n, g := range t {
+		keys[n] = g.Key
+	}
+	return keys
+}
+
+// logRecordPercentile returns the p-th quantile of sorted values, interpolating
+// linearly between the closest ranks.
+func logRecordPercentile(sorted []float64, p float64) float64 {
+	rank := p * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
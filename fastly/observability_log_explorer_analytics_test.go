@@ -0,0 +1,94 @@
+package fastly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func analyticsLogRecords() []*LogRecord {
+	record := func(pop, path string, status int, hit bool, responseTime float64) *LogRecord {
+		return &LogRecord{
+			ClientBrowserName: ToPointer("Firefox"),
+			FastlyPOP:         ToPointer(pop),
+			IsCacheHit:        ToPointer(hit),
+			RequestPath:       ToPointer(path),
+			ResponseBytesBody: ToPointer(uint64(100)),
+			ResponseStatus:    ToPointer(status),
+			ResponseTime:      ToPointer(responseTime),
+		}
+	}
+	return []*LogRecord{
+		record("LHR", "/api/v1/users?id=1", 200, true, 0.01),
+		record("LHR", "/api/v1/orders", 200, true, 0.02),
+		record("LHR", "/api/v2/users", 503, false, 0.5),
+		record("JFK", "/static/app.js", 200, false, 0.1),
+		record("JFK", "/", 504, false, 1.0),
+		record("SYD", "/api/v1/users", 200, true, 0.03),
+		nil,
+		{ResponseStatus: ToPointer(404)},
+	}
+}
+
+func TestSummarizeLogRecords(t *testing.T) {
+	t.Parallel()
+
+	s := SummarizeLogRecords(analyticsLogRecords())
+	assert.Equal(t, 7, s.Count)
+	assert.Equal(t, 3, s.CacheHits)
+	assert.Equal(t, 3, s.CacheMisses)
+	assert.InDelta(t, 0.5, s.CacheHitRatio, 1e-9)
+	assert.Equal(t, 2, s.Errors)
+	assert.Equal(t, uint64(600), s.ResponseBytes)
+	assert.InDelta(t, 0.065, s.ResponseTimeP50, 1e-9)
+	assert.InDelta(t, 0.875, s.ResponseTimeP95, 1e-9)
+	assert.InDelta(t, 1.0, s.ResponseTimeMax, 1e-9)
+
+	assert.Equal(t, LogRecordStats{}, SummarizeLogRecords(nil))
+}
+
+func TestGroupLogRecords(t *testing.T) {
+	t.Parallel()
+
+	records := analyticsLogRecords()
+
+	byPOP := GroupLogRecords(records, LogRecordByPOP)
+	assert.Equal(t, []string{"LHR", "JFK", "SYD"}, byPOP.Keys())
+	assert.Equal(t, 3, byPOP[0].Count)
+	assert.InDelta(t, 2.0/3.0, byPOP[0].CacheHitRatio, 1e-9)
+	assert.Equal(t, []string{"LHR"}, byPOP.Top(1).Keys())
+	assert.Empty(t, byPOP.Top(-1))
+	assert.Len(t, byPOP.Top(10), 3)
+
+	slowest := byPOP.Sort(func(a, b LogRecordGroup) bool { return a.ResponseTimeP50 > b.ResponseTimeP50 })
+	assert.Equal(t, []string{"JFK", "SYD", "LHR"}, slowest.Keys())
+	assert.Equal(t, []string{"LHR", "JFK", "SYD"}, byPOP.Keys(), "Sort must not modify the table")
+
+	byPrefix := GroupLogRecords(records, LogRecordByPathPrefix(2))
+	assert.Equal(t, []string{"/api/v1", "/", "/api/v2", "/static/app.js"}, byPrefix.Keys())
+	assert.Equal(t, 3, byPrefix[0].Count)
+
+	byClass := GroupLogRecords(records, LogRecordByStatusClass)
+	assert.Equal(t, []string{"2xx", "5xx", "4xx"}, byClass.Keys())
+
+	byStatus := GroupLogRecords(records, LogRecordByStatus)
+	assert.Equal(t, []string{"200", "404", "503", "504"}, byStatus.Keys())
+}
+
+func TestLogRecordByField(t *testing.T) {
+	t.Parallel()
+
+	key, err := LogRecordByField("client_browser_name")
+	require.NoError(t, err)
+	table := GroupLogRecords(analyticsLogRecords(), key)
+	assert.Equal(t, []string{"Firefox"}, table.Keys())
+	assert.Equal(t, 6, table[0].Count)
+
+	key, err = LogRecordByField("is_cache_hit")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"false", "true"}, GroupLogRecords(analyticsLogRecords(), key).Keys())
+
+	_, err = LogRecordByField("nope")
+	assert.Error(t, err)
+}
@@ -0,0 +1,295 @@
+package fastly
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogExportFormat is an output format of ExportLogRecords.
+type LogExportFormat string
+
+const (
+	// LogExportFormatCSV writes a header row followed by one row per record.
+	LogExportFormatCSV LogExportFormat = "csv"
+	// LogExportFormatNDJSON writes one JSON object per line.
+	LogExportFormatNDJSON LogExportFormat = "ndjson"
+)
+
+const (
+	// LogExplorerMaximumLimit is the maximum number of records the Log
+	// Explorer API returns per request.
+	LogExplorerMaximumLimit = 100
+	// DefaultLogExportWindow is the default length of the time windows
+	// ExportLogRecords queries.
+	DefaultLogExportWindow = time.Hour
+)
+
+// ExportLogRecordsInput is used as input to the ExportLogRecords function.
+type ExportLogRecordsInput struct {
+	// End is the exclusive end of the time range (required).
+	End time.Time
+	// Filters limits the export to records matching all of the filters. They
+	// are combined with those of Query.
+	Filters []LogExplorerFilter
+	// Format is the output format (default: LogExportFormatNDJSON).
+	Format LogExportFormat
+	// Query is a query in the language accepted by ParseLogExplorerQuery.
+	Query string
+	// ServiceID is the ID of the service (required).
+	ServiceID string
+	// Start is the inclusive start of the time range (required).
+	Start time.Time
+	// Window is the length of the time windows the range is split into
+	// (default: DefaultLogExportWindow). Windows that fill a whole page
+	// without returning a cursor are split further, down to one second.
+	Window time.Duration
+}
+
+// ExportLogRecords streams every log record matching the input to w, in time
+// window order, and returns the number of records written.
+//
+// The range is split into windows, each of which is paged through by
+// following the cursor returned by the API. If a window returns a full page
+// of LogExplorerMaximumLimit records without a cursor, its results may be
+// truncated, so it is split in half and queried again.
+//
+// Filters using LogExplorerFilterOperatorContains are not sent to the API:
+// each page is filtered on the client instead, comparing the filter value
+// with the corresponding string field of the records.
+func (c *Client) ExportLogRecords(ctx context.Context, i *ExportLogRecordsInput, w io.Writer) (int, error) {
+	if i.ServiceID == "" {
+		return 0, ErrMissingServiceID
+	}
+	if i.Start.IsZero() {
+		return 0, ErrMissingStart
+	}
+	if i.End.IsZero() {
+		return 0, ErrMissingEnd
+	}
+
+	filters := i.Filters
+	if i.Query != "" {
+		parsed, err := ParseLogExplorerQuery(i.Query)
+		if err != nil {
+			return 0, err
+		}
+		filters = append(append([]LogExplorerFilter(nil), filters...), parsed...)
+	}
+	var api, contains []LogExplorerFilter
+	for _, f := range filters {
+		if f.Operator != LogExplorerFilterOperatorContains {
+			api = append(api, f)
+			continue
+		}
+		if _, ok := logRecordStringFields[f.Field]; !ok {
+			return 0, fmt.Errorf("%w: %s does not hold strings", ErrUnsupportedLogExplorerContains, f.Field)
+		}
+		contains = append(contains, f)
+	}
+
+	var out logRecordWriter
+	switch i.Format {
+	case "", LogExportFormatNDJSON:
+		out = &ndjsonLogWriter{enc: json.NewEncoder(w)}
+	case LogExportFormatCSV:
+		out = &csvLogWriter{w: csv.NewWriter(w)}
+	default:
+		return 0, ErrInvalidLogExportFormat
+	}
+
+	window := i.Window
+	if window <= 0 {
+		window = DefaultLogExportWindow
+	}
+
+	e := &logExporter{c: c, contains: contains, filters: api, out: out, serviceID: i.ServiceID}
+	start, end := i.Start.UTC().Truncate(time.Second), i.End.UTC()
+	for ws := start; ws.Before(end); ws = ws.Add(window) {
+		we := ws.Add(window)
+		if we.After(end) {
+			we = end
+		}
+		if err := e.window(ctx, ws, we); err != nil {
+			return e.written, err
+		}
+	}
+	return e.written, out.flush()
+}
+
+// logRecordWriter writes log records in an export format.
+type logRecordWriter interface {
+	write(*LogRecord) error
+	flush() error
+}
+
+// logRecordStringFields maps the string fields that filters apply to, to the
+// corresponding LogRecord field.
+var logRecordStringFields = map[LogExplorerFilterField]func(*LogRecord) *string{
+	LogExplorerFilterFieldClientBrowserName: func(r *LogRecord) *string { return r.ClientBrowserName },
+	LogExplorerFilterFieldClientDeviceType:  func(r *LogRecord) *string { return r.ClientDeviceType },
+	LogExplorerFilterFieldClientOSName:      func(r *LogRecord) *string { return r.ClientOSName },
+	LogExplorerFilterFieldDomain:            func(r *LogRecord) *string { return r.RequestHost },
+	LogExplorerFilterFieldFastlyPOP:         func(r *LogRecord) *string { return r.FastlyPOP },
+	LogExplorerFilterFieldRequestPath:       func(r *LogRecord) *string { return r.RequestPath },
+}
+
+// logExporter holds the state of an export.
+type logExporter struct {
+	c *Client
+	// contains holds the filters applied to the records of each page.
+	contains  []LogExplorerFilter
+	filters   []LogExplorerFilter
+	out       logRecordWriter
+	serviceID string
+	written   int
+}
+
+// window exports the records of one time window, writing each page as it
+// arrives.
+func (e *logExporter) window(ctx context.Context, start, end time.Time) error {
+	var cursor *string
+	for {
+		resp, err := e.c.GetLogRecords(ctx, &GetLogRecordsInput{
+			End:        end.Format(time.RFC3339),
+			Filters:    e.filters,
+			Limit:      ToPointer(LogExplorerMaximumLimit),
+			NextCursor: cursor,
+			ServiceID:  e.serviceID,
+			Start:      start.Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+		if resp == nil {
+			return nil
+		}
+
+		var next string
+		if resp.Meta != nil {
+			next = ToValue(resp.Meta.NextCursor)
+		}
+		if cursor == nil && next == "" && len(resp.Data) >= LogExplorerMaximumLimit {
+			// The API only accepts whole seconds, so a window that cannot
+			// be split on a second boundary is exported as returned.
+			mid := start.Add((end.Sub(start) / 2).Truncate(time.Second))
+			if mid.After(start) && mid.Before(end) {
+				if err := e.window(ctx, start, mid); err != nil {
+					return err
+				}
+				return e.window(ctx, mid, end)
+			}
+		}
+
+		for _, r := range resp.Data {
+			if r == nil || !e.matches(r) {
+				continue
+			}
+			if err := e.out.write(r); err != nil {
+				return err
+			}
+			e.written++
+		}
+		if next == "" || (cursor != nil && next == *cursor) {
+			return nil
+		}
+		cursor = &next
+	}
+}
+
+// matches reports whether r satisfies every contains filter.
+func (e *logExporter) matches(r *LogRecord) bool {
+	for _, f := range e.contains {
+		v := logRecordStringFields[f.Field](r)
+		if v == nil || !strings.Contains(*v, f.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// ndjsonLogWriter writes records as newline-delimited JSON.
+type ndjsonLogWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonLogWriter) write(r *LogRecord) error {
+	return n.enc.Encode(r)
+}
+
+func (n *ndjsonLogWriter) flush() error {
+	return nil
+}
+
+// csvLogWriter writes records as CSV, with columns named after the JSON
+// fields of LogRecord.
+type csvLogWriter struct {
+	header bool
+	w      *csv.Writer
+}
+
+// logRecordColumns lists the JSON field names of LogRecord in declaration
+// order.
+var logRecordColumns = func() []string {
+	t := reflect.TypeFor[LogRecord]()
+	columns := make([]string, t.NumField())
+	for n := range t.NumField() {
+		columns[n], _, _ = strings.Cut(t.Field(n).Tag.Get("json"), ",")
+	}
+	return columns
+}()
+
+func (c *csvLogWriter) write(r *LogRecord) error {
+	if !c.header {
+		if err := c.w.Write(logRecordColumns); err != nil {
+			return err
+		}
+		c.header = true
+	}
+
+	v := reflect.ValueOf(r).Elem()
+	row := make([]string, v.NumField())
+	for n := range row {
+		row[n], _ = logRecordFieldString(v.Field(n))
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvLogWriter) flush() error {
+	if !c.header {
+		if err := c.w.Write(logRecordColumns); err != nil {
+			return err
+		}
+		c.header = true
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// logRecordFieldString formats a LogRecord field, reporting false if it is
+// nil.
+func logRecordFieldString(f reflect.Value) (string, bool) {
+	if f.IsNil() {
+		return "", false
+	}
+	switch v := f.Interface().(type) {
+	case *string:
+		return *v, true
+	case *bool:
+		return strconv.FormatBool(*v), true
+	case *int:
+		return strconv.Itoa(*v), true
+	case *uint64:
+		return strconv.FormatUint(*v, 10), true
+	case *float64:
+		return strconv.FormatFloat(*v, 'f', -1, 64), true
+	default:
+		return fmt.Sprint(f.Elem().Interface()), true
+	}
+}
@@ -0,0 +1,193 @@
+package fastly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func logRecordsEverySecond(start time.Time, n int) []*LogRecord {
+	records := make([]*LogRecord, n)
+	for i := range records {
+		records[i] = &LogRecord{
+			ResponseStatus: ToPointer(200),
+			Timestamp:      ToPointer(start.Add(time.Duration(i) * time.Second).Format(time.RFC3339)),
+		}
+	}
+	return records
+}
+
+func TestExportLogRecords_cursors(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	var (
+		buf bytes.Buffer
+		n   int
+		err error
+	)
+	// The status filter is sent with each of the three pages.
+	Record(t, "observability_log_explorer/export_cursors", func(c *Client) {
+		n, err = c.ExportLogRecords(context.TODO(), &ExportLogRecordsInput{
+			End:       start.Add(time.Hour),
+			Query:     "status=200",
+			ServiceID: "kKJb5bOFI47uHeBVluGfX1",
+			Start:     start,
+		}, &buf)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 250, n)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 250)
+	var last LogRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[249]), &last))
+	assert.Equal(t, "2026-08-12T15:04:09Z", ToValue(last.Timestamp))
+}
+
+func TestExportLogRecords_splitsWindows(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	var (
+		buf bytes.Buffer
+		n   int
+		err error
+	)
+	// Full pages without a cursor are split until each window fits in a
+	// page.
+	Record(t, "observability_log_explorer/export_split", func(c *Client) {
+		n, err = c.ExportLogRecords(context.TODO(), &ExportLogRecordsInput{
+			End:       start.Add(10 * time.Minute),
+			ServiceID: "kKJb5bOFI47uHeBVluGfX1",
+			Start:     start,
+		}, &buf)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 250, n)
+
+	// Records must be written in time order, each exactly once.
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 250)
+	for i, line := range lines {
+		var r LogRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &r))
+		assert.Equal(t, start.Add(time.Duration(i)*time.Second).Format(time.RFC3339), *r.Timestamp)
+	}
+}
+
+func TestExportLogRecords_contains(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	var (
+		buf bytes.Buffer
+		n   int
+		err error
+	)
+	// Only the status filter is sent: the path is matched on the client.
+	Record(t, "observability_log_explorer/export_contains", func(c *Client) {
+		n, err = c.ExportLogRecords(context.TODO(), &ExportLogRecordsInput{
+			End:       start.Add(time.Minute),
+			Query:     `status=200 AND path~"/api/"`,
+			ServiceID: "kKJb5bOFI47uHeBVluGfX1",
+			Start:     start,
+		}, &buf)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	var paths []string
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		var r LogRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &r))
+		paths = append(paths, ToValue(r.RequestPath))
+	}
+	assert.Equal(t, []string{"/api/v1/users", "/v2/api/"}, paths)
+
+	_, err = TestClient.ExportLogRecords(context.TODO(), &ExportLogRecordsInput{
+		End:       start.Add(time.Minute),
+		Query:     "status=200",
+		Filters:   []LogExplorerFilter{{Field: LogExplorerFilterFieldResponseStatus, Operator: LogExplorerFilterOperatorContains, Value: "5"}},
+		ServiceID: "kKJb5bOFI47uHeBVluGfX1",
+		Start:     start,
+	}, &buf)
+	assert.ErrorIs(t, err, ErrUnsupportedLogExplorerContains)
+}
+
+func TestExportLogRecords_csv(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	var (
+		buf bytes.Buffer
+		err error
+	)
+	Record(t, "observability_log_explorer/export_csv", func(c *Client) {
+		_, err = c.ExportLogRecords(context.TODO(), &ExportLogRecordsInput{
+			End:       start.Add(time.Minute),
+			Format:    LogExportFormatCSV,
+			ServiceID: "kKJb5bOFI47uHeBVluGfX1",
+			Start:     start,
+		}, &buf)
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, strings.Join(logRecordColumns, ","), lines[0])
+	assert.Equal(t, `,,,,,,,,,LHR,true,,,,,,"/a,b",,,,,,,,503,0.25,,,2026-08-12T15:00:00Z`, lines[1])
+}
+
+func TestExportLogRecords_validation(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+
+	_, err := TestClient.ExportLogRecords(context.TODO(), &ExportLogRecordsInput{Start: start, End: start}, &buf)
+	assert.ErrorIs(t, err, ErrMissingServiceID)
+
+	_, err = TestClient.ExportLogRecords(context.TODO(), &ExportLogRecordsInput{ServiceID: "svc", End: start}, &buf)
+	assert.ErrorIs(t, err, ErrMissingStart)
+
+	_, err = TestClient.ExportLogRecords(context.TODO(), &ExportLogRecordsInput{ServiceID: "svc", Start: start}, &buf)
+	assert.ErrorIs(t, err, ErrMissingEnd)
+
+	_, err = TestClient.ExportLogRecords(context.TODO(), &ExportLogRecordsInput{ServiceID: "svc", Start: start, End: start, Format: "xml"}, &buf)
+	assert.ErrorIs(t, err, ErrInvalidLogExportFormat)
+
+	_, err = TestClient.ExportLogRecords(context.TODO(), &ExportLogRecordsInput{ServiceID: "svc", Start: start, End: start, Query: "nope"}, &buf)
+	var qerr *LogExplorerQueryError
+	assert.ErrorAs(t, err, &qerr)
+	assert.Zero(t, buf.Len())
+}
+
+func TestExportLogRecords_fullSubSecondSplit(t *testing.T) {
+	t.Parallel()
+
+	// The request returns a full page without a cursor. A 1.5s window
+	// cannot be split on a second boundary, so it must be exported as is
+	// rather than split forever: the fixture holds a single request.
+	start := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	var (
+		buf bytes.Buffer
+		n   int
+		err error
+	)
+	Record(t, "observability_log_explorer/export_sub_second", func(c *Client) {
+		n, err = c.ExportLogRecords(context.TODO(), &ExportLogRecordsInput{
+			End:       start.Add(1500 * time.Millisecond),
+			ServiceID: "kKJb5bOFI47uHeBVluGfX1",
+			Start:     start,
+		}, &buf)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, LogExplorerMaximumLimit, n)
+}
@@ -0,0 +1,383 @@
+package fastly
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// logExplorerFieldKind is the type of values a Log Explorer field holds.
+type logExplorerFieldKind int
+
+const (
+	logExplorerString logExplorerFieldKind = iota
+	logExplorerNumber
+	logExplorerBool
+)
+
+// logExplorerQueryFields maps the field names accepted by
+// ParseLogExplorerQuery, including short aliases, to their API field.
+var logExplorerQueryFields = map[string]LogExplorerFilterField{
+	"browser":   LogExplorerFilterFieldClientBrowserName,
+	"cache_hit": LogExplorerFilterFieldFastlyIsCacheHit,
+	"device":    LogExplorerFilterFieldClientDeviceType,
+	"edge":      LogExplorerFilterFieldFastlyIsEdge,
+	"hit":       LogExplorerFilterFieldFastlyIsCacheHit,
+	"os":        LogExplorerFilterFieldClientOSName,
+	"path":      LogExplorerFilterFieldRequestPath,
+	"pop":       LogExplorerFilterFieldFastlyPOP,
+	"shield":    LogExplorerFilterFieldFastlyIsShield,
+	"status":    LogExplorerFilterFieldResponseStatus,
+	"time":      LogExplorerFilterFieldResponseTime,
+}
+
+// logExplorerFieldKinds holds the value type of the fields that do not hold
+// strings.
+var logExplorerFieldKinds = map[LogExplorerFilterField]logExplorerFieldKind{
+	LogExplorerFilterFieldFastlyIsCacheHit: logExplorerBool,
+	LogExplorerFilterFieldFastlyIsEdge:     logExplorerBool,
+	LogExplorerFilterFieldFastlyIsShield:   logExplorerBool,
+	LogExplorerFilterFieldResponseStatus:   logExplorerNumber,
+	LogExplorerFilterFieldResponseTime:     logExplorerNumber,
+}
+
+// LogExplorerFilterOperatorContains matches string values containing the
+// filter value. The Log Explorer API has no such operator: ExportLogRecords
+// applies these filters to the records it receives instead of sending them,
+// and GetLogRecords rejects them.
+const LogExplorerFilterOperatorContains LogExplorerFilterOperator = "contains"
+
+// logExplorerQueryOperators maps the comparison operators of the query
+// language to API operators.
+var logExplorerQueryOperators = map[string]LogExplorerFilterOperator{
+	"=":  LogExplorerFilterOperatorEq,
+	"==": LogExplorerFilterOperatorEq,
+	"!=": LogExplorerFilterOperatorNotIn,
+	">":  LogExplorerFilterOperatorGT,
+	">=": LogExplorerFilterOperatorGTE,
+	"<":  LogExplorerFilterOperatorLT,
+	"<=": LogExplorerFilterOperatorLTE,
+	"~":  LogExplorerFilterOperatorContains,
+}
+
+// LogExplorerQueryError describes a syntax or validation error in a Log
+// Explorer query.
+type LogExplorerQueryError struct {
+	// Message describes the error.
+	Message string
+	// Offset is the byte offset in the query at which the error was found.
+	Offset int
+}
+
+// Error implements the error interface.
+func (e *LogExplorerQueryError) Error() string {
+	return fmt.Sprintf("invalid log query at offset %d: %s", e.Offset, e.Message)
+}
+
+// ParseLogExplorerQuery compiles a query into Log Explorer filters.
+//
+// A query is a list of comparisons joined by AND, such as:
+//
+//	status>=500 AND pop=LHR AND path~"/api/"
+//
+// Each comparison is a field, an operator and a value. Fields are the
+// LogExplorerFilterField names or the aliases browser, cache_hit (or hit),
+// device, edge, os, path, pop, shield, status and time. The operators are =,
+// !=, >, >=, <, <=, ~ (contains), ENDS-WITH, IN (...) and NOT IN (...).
+// Values are bare words or double-quoted strings. Keywords are not case
+// sensitive. ~ compiles to LogExplorerFilterOperatorContains, which only
+// ExportLogRecords supports.
+//
+// Comparisons are checked against the type of their field: ordering operators
+// only apply to status and time, ~ and ENDS-WITH only to string fields, and
+// boolean fields only accept true or false.
+func ParseLogExplorerQuery(query string) ([]LogExplorerFilter, error) {
+	p := &logQueryParser{input: query}
+	p.next()
+
+	var filters []LogExplorerFilter
+	seen := make(map[string]bool)
+	for p.tok.kind != logTokenEOF {
+		if len(filters) > 0 {
+			if !p.keyword("AND") {
+				return nil, p.errorf("expected AND, found %s", p.tok)
+			}
+			p.next()
+		}
+
+		offset := p.tok.offset
+		f, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		if p.err != nil {
+			return nil, p.err
+		}
+		key := string(f.Field) + "/" + string(f.Operator)
+		if seen[key] {
+			return nil, &LogExplorerQueryError{Message: fmt.Sprintf("%s %s used more than once", f.Field, f.Operator), Offset: offset}
+		}
+		seen[key] = true
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// logTokenKind is the kind of a query token.
+type logTokenKind int
+
+const (
+	logTokenEOF logTokenKind = iota
+	logTokenWord
+	logTokenString
+	logTokenOperator
+	logTokenPunct
+)
+
+// logToken is a lexical token of a query.
+type logToken struct {
+	kind   logTokenKind
+	offset int
+	text   string
+}
+
+// String describes the token for error messages.
+func (t logToken) String() string {
+	if t.kind == logTokenEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+// logQueryParser is a recursive-descent parser for Log Explorer queries.
+type logQueryParser struct {
+	err   *LogExplorerQueryError
+	input string
+	pos   int
+	tok   logToken
+}
+
+// errorf returns an error at the current token.
+func (p *logQueryParser) errorf(format string, args ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	return &LogExplorerQueryError{Message: fmt.Sprintf(format, args...), Offset: p.tok.offset}
+}
+
+// keyword reports whether the current token is the given keyword.
+func (p *logQueryParser) keyword(k string) bool {
+	return p.tok.kind == logTokenWord && strings.EqualFold(p.tok.text, k)
+}
+
+// comparison parses a single comparison.
+func (p *logQueryParser) comparison() (LogExplorerFilter, error) {
+	var f LogExplorerFilter
+
+	if p.tok.kind != logTokenWord {
+		return f, p.errorf("expected field name, found %s", p.tok)
+	}
+	name := strings.ToLower(p.tok.text)
+	field, ok := logExplorerQueryFields[name]
+	if !ok {
+		for _, known := range LogExplorerFilterFields {
+			if string(known) == name {
+				field, ok = known, true
+			}
+		}
+	}
+	if !ok {
+		return f, p.errorf("unknown field %s", p.tok)
+	}
+	f.Field = field
+	kind := logExplorerFieldKinds[field]
+	p.next()
+
+	opOffset := p.tok.offset
+	var values []string
+	switch {
+	case p.tok.kind == logTokenOperator:
+		op := p.tok.text
+		f.Operator = logExplorerQueryOperators[op]
+		p.next()
+		v, err := p.value()
+		if err != nil {
+			return f, err
+		}
+		values = []string{v}
+		if kind == logExplorerBool && op == "!=" {
+			// Negate boolean comparisons rather than use not_in.
+			f.Operator = LogExplorerFilterOperatorEq
+			switch strings.ToLower(v) {
+			case "true":
+				values[0] = "false"
+			case "false":
+				values[0] = "true"
+			}
+		}
+	case p.keyword("ENDS-WITH"):
+		f.Operator = LogExplorerFilterOperatorEndsWith
+		p.next()
+		v, err := p.value()
+		if err != nil {
+			return f, err
+		}
+		values = []string{v}
+	case p.keyword("IN"), p.keyword("NOT"):
+		f.Operator = LogExplorerFilterOperatorIn
+		if p.keyword("NOT") {
+			f.Operator = LogExplorerFilterOperatorNotIn
+			p.next()
+			if !p.keyword("IN") {
+				return f, p.errorf("expected IN after NOT, found %s", p.tok)
+			}
+		}
+		p.next()
+		list, err := p.list()
+		if err != nil {
+			return f, err
+		}
+		values = list
+	default:
+		return f, p.errorf("expected operator after %s, found %s", field, p.tok)
+	}
+
+	if err := validateLogExplorerComparison(field, kind, f.Operator, values); err != nil {
+		return f, &LogExplorerQueryError{Message: err.Error(), Offset: opOffset}
+	}
+	f.Value = strings.Join(values, ",")
+	return f, nil
+}
+
+// value parses a bare word or quoted string.
+func (p *logQueryParser) value() (string, error) {
+	if p.tok.kind != logTokenWord && p.tok.kind != logTokenString {
+		return "", p.errorf("expected value, found %s", p.tok)
+	}
+	v := p.tok.text
+	p.next()
+	return v, nil
+}
+
+// list parses a parenthesized, comma-separated list of values.
+func (p *logQueryParser) list() ([]string, error) {
+	if p.tok.kind != logTokenPunct || p.tok.text != "(" {
+		return nil, p.errorf("expected (, found %s", p.tok)
+	}
+	p.next()
+
+	var values []string
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+
+		if p.tok.kind != logTokenPunct {
+			return nil, p.errorf("expected , or ), found %s", p.tok)
+		}
+		switch p.tok.text {
+		case ",":
+			p.next()
+		case ")":
+			p.next()
+			return values, nil
+		default:
+			return nil, p.errorf("expected , or ), found %s", p.tok)
+		}
+	}
+}
+
+// validateLogExplorerComparison checks that an operator and its values suit
+// the type of a field.
+func validateLogExplorerComparison(field LogExplorerFilterField, kind logExplorerFieldKind, op LogExplorerFilterOperator, values []string) error {
+	switch kind {
+	case logExplorerNumber:
+		if op == LogExplorerFilterOperatorEndsWith || op == LogExplorerFilterOperatorContains {
+			return fmt.Errorf("%s is numeric and does not support ~ or ENDS-WITH", field)
+		}
+		for _, v := range values {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return fmt.Errorf("%s requires a number, found %q", field, v)
+			}
+		}
+	case logExplorerBool:
+		if op != LogExplorerFilterOperatorEq {
+			return fmt.Errorf("%s is boolean and only supports = and !=", field)
+		}
+		if v := strings.ToLower(values[0]); v != "true" && v != "false" {
+			return fmt.Errorf("%s requires true or false, found %q", field, values[0])
+		}
+	case logExplorerString:
+		if op == LogExplorerFilterOperatorGT || op == LogExplorerFilterOperatorGTE || op == LogExplorerFilterOperatorLT || op == LogExplorerFilterOperatorLTE {
+			return fmt.Errorf("%s is a string and does not support ordering", field)
+		}
+	}
+	for _, v := range values {
+		if v == "" {
+			return fmt.Errorf("%s requires a non-empty value", field)
+		}
+		if (op == LogExplorerFilterOperatorIn || op == LogExplorerFilterOperatorNotIn) && strings.Contains(v, ",") {
+			return fmt.Errorf("list values for %s cannot contain commas", field)
+		}
+	}
+	return nil
+}
+
+// next advances to the next token.
+func (p *logQueryParser) next() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.input) {
+		p.tok = logToken{kind: logTokenEOF, offset: start}
+		return
+	}
+
+	switch c := p.input[p.pos]; {
+	case c == '(' || c == ')' || c == ',':
+		p.pos++
+		p.tok = logToken{kind: logTokenPunct, offset: start, text: string(c)}
+	case strings.IndexByte("=!<>~", c) >= 0:
+		p.pos++
+		if p.pos < len(p.input) && p.input[p.pos] == '=' && c != '~' {
+			p.pos++
+		}
+		op := p.input[start:p.pos]
+		if _, ok := logExplorerQueryOperators[op]; !ok {
+			p.err = &LogExplorerQueryError{Message: fmt.Sprintf("unknown operator %q", op), Offset: start}
+		}
+		p.tok = logToken{kind: logTokenOperator, offset: start, text: op}
+	case c == '"':
+		var b strings.Builder
+		for p.pos++; p.pos < len(p.input); p.pos++ {
+			switch ch := p.input[p.pos]; ch {
+			case '"':
+				p.pos++
+				p.tok = logToken{kind: logTokenString, offset: start, text: b.String()}
+				return
+			case '\\':
+				if p.pos+1 < len(p.input) {
+					p.pos++
+				}
+				b.WriteByte(p.input[p.pos])
+			default:
+				b.WriteByte(ch)
+			}
+		}
+		p.err = &LogExplorerQueryError{Message: "unterminated string", Offset: start}
+		p.tok = logToken{kind: logTokenString, offset: start, text: b.String()}
+	default:
+		for p.pos < len(p.input) {
+			ch := p.input[p.pos]
+			if unicode.IsSpace(rune(ch)) || strings.IndexByte(`()=!<>~,"`, ch) >= 0 {
+				break
+			}
+			p.pos++
+		}
+		p.tok = logToken{kind: logTokenWord, offset: start, text: p.input[start:p.pos]}
+	}
+}
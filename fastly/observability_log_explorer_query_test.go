@@ -0,0 +1,121 @@
+package fastly
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogExplorerQuery(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		query string
+		want  []LogExplorerFilter
+	}{
+		{
+			name:  "empty",
+			query: "  ",
+		},
+		{
+			name:  "comparisons",
+			query: `status>=500 and pop=LHR AND path~"/api/"`,
+			want: []LogExplorerFilter{
+				{Field: LogExplorerFilterFieldResponseStatus, Operator: LogExplorerFilterOperatorGTE, Value: "500"},
+				{Field: LogExplorerFilterFieldFastlyPOP, Operator: LogExplorerFilterOperatorEq, Value: "LHR"},
+				{Field: LogExplorerFilterFieldRequestPath, Operator: LogExplorerFilterOperatorContains, Value: "/api/"},
+			},
+		},
+		{
+			name:  "suffix",
+			query: `path ends-with ".json" AND path~v1`,
+			want: []LogExplorerFilter{
+				{Field: LogExplorerFilterFieldRequestPath, Operator: LogExplorerFilterOperatorEndsWith, Value: ".json"},
+				{Field: LogExplorerFilterFieldRequestPath, Operator: LogExplorerFilterOperatorContains, Value: "v1"},
+			},
+		},
+		{
+			name:  "lists",
+			query: `pop IN (LHR, "JFK") AND device NOT IN (bot)`,
+			want: []LogExplorerFilter{
+				{Field: LogExplorerFilterFieldFastlyPOP, Operator: LogExplorerFilterOperatorIn, Value: "LHR,JFK"},
+				{Field: LogExplorerFilterFieldClientDeviceType, Operator: LogExplorerFilterOperatorNotIn, Value: "bot"},
+			},
+		},
+		{
+			name:  "negated boolean",
+			query: "hit!=true",
+			want: []LogExplorerFilter{
+				{Field: LogExplorerFilterFieldFastlyIsCacheHit, Operator: LogExplorerFilterOperatorEq, Value: "false"},
+			},
+		},
+		{
+			name:  "canonical field name",
+			query: `response_time < 0.5 AND status != 200`,
+			want: []LogExplorerFilter{
+				{Field: LogExplorerFilterFieldResponseTime, Operator: LogExplorerFilterOperatorLT, Value: "0.5"},
+				{Field: LogExplorerFilterFieldResponseStatus, Operator: LogExplorerFilterOperatorNotIn, Value: "200"},
+			},
+		},
+		{
+			name:  "escaped quote",
+			query: `path="/a\"b"`,
+			want: []LogExplorerFilter{
+				{Field: LogExplorerFilterFieldRequestPath, Operator: LogExplorerFilterOperatorEq, Value: `/a"b`},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseLogExplorerQuery(tc.query)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseLogExplorerQuery_errors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		query  string
+		offset int
+	}{
+		{query: "colour=red", offset: 0},
+		{query: "status 500", offset: 7},
+		{query: "status=500 pop=LHR", offset: 11},
+		{query: "status=abc", offset: 6},
+		{query: "pop>LHR", offset: 3},
+		{query: "hit=maybe", offset: 3},
+		{query: "edge IN (true)", offset: 5},
+		{query: "status ENDS-WITH 5", offset: 7},
+		{query: "status~50", offset: 6},
+		{query: "hit~true", offset: 3},
+		{query: "path~=x", offset: 5},
+		{query: `path=""`, offset: 4},
+		{query: `pop IN ("a,b")`, offset: 4},
+		{query: "pop IN (LHR", offset: 11},
+		{query: "pop NOT LHR", offset: 8},
+		{query: `path="/api`, offset: 5},
+		{query: "status=>500", offset: 7},
+		{query: "status!500", offset: 6},
+		{query: "status>=500 AND status>=400", offset: 16},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.query, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseLogExplorerQuery(tc.query)
+			var qerr *LogExplorerQueryError
+			require.True(t, errors.As(err, &qerr), "got %v", err)
+			assert.Equal(t, tc.offset, qerr.Offset, qerr.Message)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+// Package all registers every product package with the products
+// registry, for use with products.Inventory and
+// products.ApplyProductState.
+//
+//	import _ "github.com/fastly/go-fastly/v17/fastly/products/all"
+package all
+
+import (
+	_ "github.com/fastly/go-fastly/v17/fastly/products/apidiscovery"
+	_ "github.com/fastly/go-fastly/v17/fastly/products/botmanagement"
+	_ "github.com/fastly/go-fastly/v17/fastly/products/brotlicompression"
+	_ "github.com/fastly/go-fastly/v17/fastly/products/ddosprotection"
+	_ "github.com/fastly/go-fastly/v17/fastly/products/domaininspector"
+	_ "github.com/fastly/go-fastly/v17/fastly/products/fanout"
+	_ "github.com/fastly/go-fastly/v17/fastly/products/imageoptimizer"
+	_ "github.com/fastly/go-fastly/v17/fastly/products/logexplorerinsights"
+	_ "github.com/fastly/go-fastly/v17/fastly/products/ngwaf"
+	_ "github.com/fastly/go-fastly/v17/fastly/products/origininspector"
+	_ "github.com/fastly/go-fastly/v17/fastly/products/websockets"
+)
@@ -0,0 +1,43 @@
+package all_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fastly/go-fastly/v17/fastly/products"
+	_ "github.com/fastly/go-fastly/v17/fastly/products/all"
+	"github.com/fastly/go-fastly/v17/fastly/products/ngwaf"
+	"github.com/fastly/go-fastly/v17/fastly/products/websockets"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	var ids []string
+	for _, p := range products.Registry() {
+		ids = append(ids, p.ID)
+	}
+	require.Equal(t, []string{
+		"api_discovery",
+		"bot_management",
+		"brotli_compression",
+		"ddos_protection",
+		"domain_inspector",
+		"fanout",
+		"image_optimizer",
+		"log_explorer_insights",
+		"ngwaf",
+		"origin_inspector",
+		"websockets",
+	}, ids)
+
+	p, ok := products.Lookup(ngwaf.ProductID)
+	require.True(t, ok)
+	require.Equal(t, ngwaf.ProductName, p.Name)
+	require.True(t, p.Configurable())
+
+	p, ok = products.Lookup(websockets.ProductID)
+	require.True(t, ok)
+	require.False(t, p.Configurable())
+}
@@ -0,0 +1,13 @@
+package apidiscovery
+
+import "github.com/fastly/go-fastly/v17/fastly/products"
+
+func init() {
+	products.Register(products.Product{
+		Disable: Disable,
+		Enable:  products.EnableWithoutInput(Enable),
+		Get:     Get,
+		ID:      ProductID,
+		Name:    ProductName,
+	})
+}
@@ -0,0 +1,198 @@
+package products
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+	"github.com/fastly/go-fastly/v17/internal/productapi"
+)
+
+// ErrUnexpectedProductOutput is the error returned by Client operations
+// when the API response does not refer to the product and service the
+// operation was invoked on.
+var ErrUnexpectedProductOutput = errors.New("unexpected product output")
+
+// Client offers the operations to enable, disable, and configure a
+// product identified by its product ID, for products which do not
+// have a package of their own.
+//
+// EnableIn is the type of the input accepted by the API's 'Enable'
+// operation; use NullInput for products which do not accept one.
+// Config is the type of the product's configuration; use
+// map[string]any to handle it without defining a type. Both are
+// encoded as JSON, so struct types should use 'json' field tags.
+type Client[EnableIn, Config any] struct {
+	client    *fastly.Client
+	productID string
+}
+
+// NewClient returns a Client for the product with the given ID.
+func NewClient[EnableIn, Config any](c *fastly.Client, productID string) *Client[EnableIn, Config] {
+	return &Client[EnableIn, Config]{client: c, productID: productID}
+}
+
+// ConfigurationOutput holds the details returned by the API from
+// 'GetConfiguration' and 'UpdateConfiguration' operations of a
+// Client.
+type ConfigurationOutput[Config any] struct {
+	ConfigureOutput
+	Configuration Config
+}
+
+// configurationOutput is the form a ConfigurationOutput is decoded
+// from, before its configuration is converted to the Config type.
+type configurationOutput struct {
+	ConfigureOutput `mapstructure:",squash"`
+	Configuration   map[string]any `mapstructure:"configuration"`
+}
+
+// ProductID returns the ID of the product.
+func (pc *Client[EnableIn, Config]) ProductID() string {
+	return pc.productID
+}
+
+// Get gets the status of the product on the service.
+func (pc *Client[EnableIn, Config]) Get(ctx context.Context, serviceID string) (EnableOutput, error) {
+	if pc.productID == "" {
+		return EnableOutput{}, fastly.ErrMissingProductID
+	}
+	o, err := productapi.Get[EnableOutput](ctx, &productapi.GetInput{
+		Client:    pc.client,
+		ProductID: pc.productID,
+		ServiceID: serviceID,
+	})
+	if err != nil {
+		return o, err
+	}
+	return o, pc.validateOutput(o, serviceID)
+}
+
+// Enable enables the product on the service.
+func (pc *Client[EnableIn, Config]) Enable(ctx context.Context, serviceID string, i EnableIn) (EnableOutput, error) {
+	if pc.productID == "" {
+		return EnableOutput{}, fastly.ErrMissingProductID
+	}
+	o, err := productapi.Put[EnableOutput](ctx, &productapi.PutInput[EnableIn]{
+		Client:    pc.client,
+		ProductID: pc.productID,
+		ServiceID: serviceID,
+		Input:     i,
+	})
+	if err != nil {
+		return o, err
+	}
+	return o, pc.validateOutput(o, serviceID)
+}
+
+// Disable disables the product on the service.
+func (pc *Client[EnableIn, Config]) Disable(ctx context.Context, serviceID string) error {
+	if pc.productID == "" {
+		return fastly.ErrMissingProductID
+	}
+	return productapi.Delete(ctx, &productapi.DeleteInput{
+		Client:    pc.client,
+		ProductID: pc.productID,
+		ServiceID: serviceID,
+	})
+}
+
+// GetConfiguration gets the configuration of the product on the
+// service.
+func (pc *Client[EnableIn, Config]) GetConfiguration(ctx context.Context, serviceID string) (ConfigurationOutput[Config], error) {
+	if pc.productID == "" {
+		return ConfigurationOutput[Config]{}, fastly.ErrMissingProductID
+	}
+	o, err := productapi.Get[configurationOutput](ctx, &productapi.GetInput{
+		Client:        pc.client,
+		ProductID:     pc.productID,
+		ServiceID:     serviceID,
+		URLComponents: []string{"configuration"},
+	})
+	if err != nil {
+		return ConfigurationOutput[Config]{}, err
+	}
+	return pc.configurationOutput(o, serviceID)
+}
+
+// UpdateConfiguration updates the configuration of the product on
+// the service.
+func (pc *Client[EnableIn, Config]) UpdateConfiguration(ctx context.Context, serviceID string, i Config) (ConfigurationOutput[Config], error) {
+	if pc.productID == "" {
+		return ConfigurationOutput[Config]{}, fastly.ErrMissingProductID
+	}
+	o, err := productapi.Patch[configurationOutput](ctx, &productapi.PatchInput[Config]{
+		Client:        pc.client,
+		ProductID:     pc.productID,
+		ServiceID:     serviceID,
+		URLComponents: []string{"configuration"},
+		Input:         i,
+	})
+	if err != nil {
+		return ConfigurationOutput[Config]{}, err
+	}
+	return pc.configurationOutput(o, serviceID)
+}
+
+// Product returns a description of the product for Register, so that
+// it is included by Inventory and ApplyProductState. The operations
+// of the description use the client passed to them rather than the
+// one pc was created with.
+func (pc *Client[EnableIn, Config]) Product(name string) Product {
+	id := pc.productID
+	with := func(c *fastly.Client) *Client[EnableIn, Config] {
+		return NewClient[EnableIn, Config](c, id)
+	}
+	return Product{
+		Disable: func(ctx context.Context, c *fastly.Client, serviceID string) error {
+			return with(c).Disable(ctx, serviceID)
+		},
+		Enable: EnableWithInput(func(ctx context.Context, c *fastly.Client, serviceID string, i EnableIn) (EnableOutput, error) {
+			return with(c).Enable(ctx, serviceID, i)
+		}),
+		Get: func(ctx context.Context, c *fastly.Client, serviceID string) (EnableOutput, error) {
+			return with(c).Get(ctx, serviceID)
+		},
+		GetConfiguration: func(ctx context.Context, c *fastly.Client, serviceID string) (map[string]any, error) {
+			o, err := with(c).GetConfiguration(ctx, serviceID)
+			if err != nil {
+				return nil, err
+			}
+			return jsonObject(o.Configuration)
+		},
+		ID:   id,
+		Name: name,
+		UpdateConfiguration: UpdateConfigurationWithInput(func(ctx context.Context, c *fastly.Client, serviceID string, i Config) (ConfigurationOutput[Config], error) {
+			return with(c).UpdateConfiguration(ctx, serviceID, i)
+		}),
+	}
+}
+
+// configurationOutput validates a decoded configuration response and
+// converts its configuration to the Config type.
+func (pc *Client[EnableIn, Config]) configurationOutput(o configurationOutput, serviceID string) (ConfigurationOutput[Config], error) {
+	result := ConfigurationOutput[Config]{ConfigureOutput: o.ConfigureOutput}
+	if err := pc.validateOutput(o.ConfigureOutput, serviceID); err != nil {
+		return result, err
+	}
+	data, err := json.Marshal(o.Configuration)
+	if err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(data, &result.Configuration); err != nil {
+		return result, fmt.Errorf("decoding %s configuration: %w", pc.productID, err)
+	}
+	return result, nil
+}
+
+// validateOutput confirms that an API response refers to the product
+// and service the operation was invoked on.
+func (pc *Client[EnableIn, Config]) validateOutput(o ProductOutput, serviceID string) error {
+	if o.ProductID() != pc.productID || o.ServiceID() != serviceID {
+		return fmt.Errorf("%w: got product %q and service %q, want product %q and service %q",
+			ErrUnexpectedProductOutput, o.ProductID(), o.ServiceID(), pc.productID, serviceID)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package products_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+	"github.com/fastly/go-fastly/v17/fastly/products"
+)
+
+type widgetConfig struct {
+	Level string `json:"level,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// newProductServer returns a client for a fake products API which
+// records the requests it receives and answers with the given product
+// ID, so that output validation can be exercised.
+func newProductServer(t *testing.T, answerProductID string) (*fastly.Client, *[]string) {
+	t.Helper()
+
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+
+		out := map[string]any{
+			"product": map[string]any{"object": "product", "id": answerProductID},
+			"service": map[string]any{"object": "service", "id": "svc1"},
+		}
+		switch {
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusOK)
+			return
+		case r.URL.Path == "/enabled-products/v1/widgets/services/svc1/configuration" && r.Method == http.MethodPatch:
+			var cfg map[string]any
+			_ = json.Unmarshal(body, &cfg)
+			out["configuration"] = cfg
+		case r.URL.Path == "/enabled-products/v1/widgets/services/svc1/configuration":
+			out["configuration"] = map[string]any{"level": "high", "limit": 10}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := fastly.NewClientForEndpoint("key", srv.URL)
+	require.NoError(t, err)
+	return c, &requests
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	c, requests := newProductServer(t, "widgets")
+	pc := products.NewClient[products.NullInput, widgetConfig](c, "widgets")
+	ctx := context.Background()
+
+	enabled, err := pc.Enable(ctx, "svc1", products.NullInput{})
+	require.NoError(t, err)
+	require.Equal(t, "widgets", enabled.ProductID())
+	require.Equal(t, "svc1", enabled.ServiceID())
+
+	_, err = pc.Get(ctx, "svc1")
+	require.NoError(t, err)
+
+	cfg, err := pc.GetConfiguration(ctx, "svc1")
+	require.NoError(t, err)
+	require.Equal(t, widgetConfig{Level: "high", Limit: 10}, cfg.Configuration)
+	require.Equal(t, "widgets", cfg.ProductID())
+
+	cfg, err = pc.UpdateConfiguration(ctx, "svc1", widgetConfig{Level: "low"})
+	require.NoError(t, err)
+	require.Equal(t, widgetConfig{Level: "low"}, cfg.Configuration)
+
+	require.NoError(t, pc.Disable(ctx, "svc1"))
+
+	require.Equal(t, []string{
+		"PUT /enabled-products/v1/widgets/services/svc1 {}",
+		"GET /enabled-products/v1/widgets/services/svc1 ",
+		"GET /enabled-products/v1/widgets/services/svc1/configuration ",
+		"PATCH /enabled-products/v1/widgets/services/svc1/configuration {\"level\":\"low\"}",
+		"DELETE /enabled-products/v1/widgets/services/svc1 ",
+	}, *requests)
+}
+
+func TestClientMapConfiguration(t *testing.T) {
+	t.Parallel()
+
+	c, _ := newProductServer(t, "widgets")
+	pc := products.NewClient[map[string]any, map[string]any](c, "widgets")
+
+	cfg, err := pc.GetConfiguration(context.Background(), "svc1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"level": "high", "limit": float64(10)}, cfg.Configuration)
+}
+
+func TestClientUnexpectedOutput(t *testing.T) {
+	t.Parallel()
+
+	c, _ := newProductServer(t, "gadgets")
+	pc := products.NewClient[products.NullInput, map[string]any](c, "widgets")
+
+	_, err := pc.Get(context.Background(), "svc1")
+	require.ErrorIs(t, err, products.ErrUnexpectedProductOutput)
+
+	_, err = pc.GetConfiguration(context.Background(), "svc1")
+	require.ErrorIs(t, err, products.ErrUnexpectedProductOutput)
+}
+
+func TestClientMissingIDs(t *testing.T) {
+	t.Parallel()
+
+	_, err := products.NewClient[products.NullInput, map[string]any](nil, "").Get(context.Background(), "svc1")
+	require.ErrorIs(t, err, fastly.ErrMissingProductID)
+
+	_, err = products.NewClient[products.NullInput, map[string]any](nil, "widgets").Get(context.Background(), "")
+	require.ErrorIs(t, err, fastly.ErrMissingServiceID)
+}
+
+func TestClientProduct(t *testing.T) {
+	t.Parallel()
+
+	c, requests := newProductServer(t, "widgets")
+	p := products.NewClient[products.NullInput, widgetConfig](nil, "widgets").Product("Widgets")
+	require.Equal(t, "widgets", p.ID)
+	require.Equal(t, "Widgets", p.Name)
+	require.True(t, p.Configurable())
+
+	attrs, err := p.GetConfiguration(context.Background(), c, "svc1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"level": "high", "limit": float64(10)}, attrs)
+
+	require.NoError(t, p.UpdateConfiguration(context.Background(), c, "svc1", map[string]any{"limit": 5}))
+	require.Equal(t, "PATCH /enabled-products/v1/widgets/services/svc1/configuration {\"limit\":5}", (*requests)[1])
+}
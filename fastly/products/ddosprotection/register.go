@@ -0,0 +1,15 @@
+package ddosprotection
+
+import "github.com/fastly/go-fastly/v17/fastly/products"
+
+func init() {
+	products.Register(products.Product{
+		Disable:             Disable,
+		Enable:              products.EnableWithInput(Enable),
+		Get:                 Get,
+		GetConfiguration:    products.GetConfigurationWithOutput(GetConfiguration),
+		ID:                  ProductID,
+		Name:                ProductName,
+		UpdateConfiguration: products.UpdateConfigurationWithInput(UpdateConfiguration),
+	})
+}
@@ -1,4 +1,12 @@
 // Package products contains subpackages which offer various
 // operations to enable, disable, and configure Fastly products on a
 // service
+//
+// Each subpackage registers its product when imported, and the
+// Registry, Inventory and ApplyProductState functions operate on the
+// registered products across services. Import the 'all' subpackage to
+// register every product.
+//
+// Products which do not have a subpackage yet can be handled by their
+// product ID with the generic Client type.
 package products
@@ -0,0 +1,317 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+// DefaultInventoryConcurrency is the maximum number of requests Inventory
+// has in flight at once.
+const DefaultInventoryConcurrency = 10
+
+// ProductStatus is the status of one product on one service.
+type ProductStatus struct {
+	// Enabled indicates the product is enabled on the service.
+	Enabled bool
+	// Err is the error returned when probing the product, if any. Enabled is
+	// false when it is set.
+	Err error
+}
+
+// ProductInventory is the enablement status of a set of products across a
+// set of services.
+type ProductInventory struct {
+	// ProductIDs are the IDs of the products probed, in registry order.
+	ProductIDs []string
+	// ServiceIDs are the IDs of the services probed, in the order given.
+	ServiceIDs []string
+	// Status holds the status of each product, keyed by service ID and then
+	// product ID.
+	Status map[string]map[string]ProductStatus
+}
+
+// Enabled reports whether a product is enabled on a service.
+func (inv *ProductInventory) Enabled(serviceID, productID string) bool {
+	return inv.Status[serviceID][productID].Enabled
+}
+
+// EnabledProducts returns the IDs of the products enabled on a service.
+func (inv *ProductInventory) EnabledProducts(serviceID string) []string {
+	var ids []string
+	for _, productID := range inv.ProductIDs {
+		if inv.Enabled(serviceID, productID) {
+			ids = append(ids, productID)
+		}
+	}
+	return ids
+}
+
+// Err returns the errors of every failed probe joined together, or nil if
+// every probe succeeded.
+func (inv *ProductInventory) Err() error {
+	var errs []error
+	for _, serviceID := range inv.ServiceIDs {
+		for _, productID := range inv.ProductIDs {
+			if err := inv.Status[serviceID][productID].Err; err != nil {
+				errs = append(errs, fmt.Errorf("service %s, product %s: %w", serviceID, productID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Inventory probes every registered product on every service and returns
+// which are enabled. A product the API reports as not found on a service is
+// disabled there.
+//
+// At most DefaultInventoryConcurrency probes are in flight at once. A failed
+// probe is recorded in the status of its product rather than stopping the
+// inventory, so the returned error only covers invalid input and context
+// cancellation.
+func Inventory(ctx context.Context, c *fastly.Client, serviceIDs []string) (*ProductInventory, error) {
+	return inventory(ctx, c, Registry(), serviceIDs)
+}
+
+// inventory implements Inventory for the given products.
+func inventory(ctx context.Context, c *fastly.Client, list []Product, serviceIDs []string) (*ProductInventory, error) {
+	inv := &ProductInventory{
+		ServiceIDs: serviceIDs,
+		Status:     make(map[string]map[string]ProductStatus, len(serviceIDs)),
+	}
+	for _, p := range list {
+		inv.ProductIDs = append(inv.ProductIDs, p.ID)
+	}
+	for _, serviceID := range serviceIDs {
+		if serviceID == "" {
+			return nil, fastly.ErrMissingServiceID
+		}
+		inv.Status[serviceID] = make(map[string]ProductStatus, len(list))
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, DefaultInventoryConcurrency)
+	)
+	for _, serviceID := range serviceIDs {
+		for _, p := range list {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				enabled, err := productEnabled(ctx, c, p, serviceID)
+				<-sem
+
+				mu.Lock()
+				defer mu.Unlock()
+				inv.Status[serviceID][p.ID] = ProductStatus{Enabled: enabled, Err: err}
+			}()
+		}
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// productEnabled reports whether a product is enabled on a service.
+func productEnabled(ctx context.Context, c *fastly.Client, p Product, serviceID string) (bool, error) {
+	_, err := p.Get(ctx, c, serviceID)
+	var herr *fastly.HTTPError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &herr) && herr.IsNotFound():
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// ProductState is the desired state of one product on one service.
+type ProductState struct {
+	// Configuration is the desired configuration of the product, passed to
+	// its UpdateConfiguration function. It is only applied when Enabled is
+	// set, and is left as is when nil.
+	Configuration any
+	// EnableInput is passed to the Enable function of the product when it
+	// needs to be enabled, for products that accept an input.
+	EnableInput any
+	// Enabled indicates the product should be enabled on the service.
+	Enabled bool
+	// ProductID is the ID of a registered product (required).
+	ProductID string
+	// ServiceID is the ID of the service (required).
+	ServiceID string
+}
+
+// ProductStateAction is a change made by ApplyProductState.
+type ProductStateAction string
+
+const (
+	// ProductStateConfigure means the configuration of the product was
+	// updated.
+	ProductStateConfigure ProductStateAction = "configure"
+	// ProductStateDisable means the product was disabled.
+	ProductStateDisable ProductStateAction = "disable"
+	// ProductStateEnable means the product was enabled.
+	ProductStateEnable ProductStateAction = "enable"
+)
+
+// ProductStateResult is the outcome of applying one ProductState.
+type ProductStateResult struct {
+	// Actions are the changes made, in order. It is empty when the product
+	// was already in the desired state.
+	Actions []ProductStateAction
+	// Err is the error that prevented the product from reaching the desired
+	// state, if any.
+	Err error
+	// ProductID is the ID of the product.
+	ProductID string
+	// ServiceID is the ID of the service.
+	ServiceID string
+}
+
+// ProductStateReport is the outcome of ApplyProductState.
+type ProductStateReport struct {
+	// Results holds one result per desired state, in the order given.
+	Results []ProductStateResult
+}
+
+// Changed returns the results of the products that were changed.
+func (r *ProductStateReport) Changed() []ProductStateResult {
+	var changed []ProductStateResult
+	for _, res := range r.Results {
+		if len(res.Actions) > 0 {
+			changed = append(changed, res)
+		}
+	}
+	return changed
+}
+
+// Failed returns the results of the products that could not reach their
+// desired state.
+func (r *ProductStateReport) Failed() []ProductStateResult {
+	var failed []ProductStateResult
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// ApplyProductState converges the enablement and configuration of products
+// on services to a desired state.
+//
+// Each product is probed first, then enabled or disabled if needed. The
+// configuration of an enabled product is compared with the desired
+// configuration and only updated when one of the attributes set in it
+// differs. Failures for one product are recorded in its result rather than
+// stopping the run, so the returned error only covers invalid input and
+// context cancellation.
+func ApplyProductState(ctx context.Context, c *fastly.Client, desired []ProductState) (*ProductStateReport, error) {
+	return applyProductState(ctx, c, Lookup, desired)
+}
+
+// applyProductState implements ApplyProductState, using lookup to find
+// products.
+func applyProductState(ctx context.Context, c *fastly.Client, lookup func(string) (Product, bool), desired []ProductState) (*ProductStateReport, error) {
+	list := make([]Product, len(desired))
+	for n, s := range desired {
+		if s.ServiceID == "" {
+			return nil, fastly.ErrMissingServiceID
+		}
+		if s.ProductID == "" {
+			return nil, fastly.ErrMissingProductID
+		}
+		p, ok := lookup(s.ProductID)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProduct, s.ProductID)
+		}
+		if s.Configuration != nil && !p.Configurable() {
+			return nil, fmt.Errorf("%w: %s", ErrProductNotConfigurable, s.ProductID)
+		}
+		list[n] = p
+	}
+
+	report := &ProductStateReport{}
+	for n, s := range desired {
+		res := ProductStateResult{ProductID: s.ProductID, ServiceID: s.ServiceID}
+		res.Actions, res.Err = applyOneProductState(ctx, c, list[n], &s)
+		report.Results = append(report.Results, res)
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// applyOneProductState converges one product on one service, returning the
+// changes made.
+func applyOneProductState(ctx context.Context, c *fastly.Client, p Product, s *ProductState) ([]ProductStateAction, error) {
+	var actions []ProductStateAction
+
+	enabled, err := productEnabled(ctx, c, p, s.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case enabled && !s.Enabled:
+		if err := p.Disable(ctx, c, s.ServiceID); err != nil {
+			return nil, err
+		}
+		return []ProductStateAction{ProductStateDisable}, nil
+	case !enabled && s.Enabled:
+		if _, err := p.Enable(ctx, c, s.ServiceID, s.EnableInput); err != nil {
+			return nil, err
+		}
+		actions = append(actions, ProductStateEnable)
+	case !s.Enabled:
+		return nil, nil
+	}
+
+	if s.Configuration == nil {
+		return actions, nil
+	}
+	current, err := p.GetConfiguration(ctx, c, s.ServiceID)
+	if err != nil {
+		return actions, err
+	}
+	matches, err := configurationMatches(current, s.Configuration)
+	if err != nil || matches {
+		return actions, err
+	}
+	if err := p.UpdateConfiguration(ctx, c, s.ServiceID, s.Configuration); err != nil {
+		return actions, err
+	}
+	return append(actions, ProductStateConfigure), nil
+}
+
+// configurationMatches reports whether every attribute set in the JSON form
+// of a desired configuration has the same value in the current
+// configuration. Attributes omitted from the desired configuration are
+// ignored.
+func configurationMatches(current map[string]any, desired any) (bool, error) {
+	want, err := jsonObject(desired)
+	if err != nil {
+		return false, fmt.Errorf("configuration must encode as a JSON object: %w", err)
+	}
+	for k, v := range want {
+		if !reflect.DeepEqual(v, current[k]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
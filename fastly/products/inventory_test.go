@@ -0,0 +1,265 @@
+package products
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+// fakeProducts records the state of products on services, keyed by service
+// ID and then product ID, and the calls made to change it.
+type fakeProducts struct {
+	mu      sync.Mutex
+	calls   []string
+	config  map[string]map[string]map[string]any
+	enabled map[string]map[string]bool
+	fail    map[string]error
+}
+
+func newFakeProducts() *fakeProducts {
+	return &fakeProducts{
+		config:  make(map[string]map[string]map[string]any),
+		enabled: make(map[string]map[string]bool),
+		fail:    make(map[string]error),
+	}
+}
+
+func (f *fakeProducts) set(serviceID, productID string, enabled bool) {
+	if f.enabled[serviceID] == nil {
+		f.enabled[serviceID] = make(map[string]bool)
+	}
+	f.enabled[serviceID][productID] = enabled
+}
+
+type fakeConfigureInput struct {
+	Mode string `json:"mode,omitempty"`
+}
+
+type fakeEnableInput struct {
+	WorkspaceID string `json:"workspace_id"`
+}
+
+// product returns a Product backed by the fake. Configurable products take a
+// fakeEnableInput.
+func (f *fakeProducts) product(id string, configurable bool) Product {
+	p := Product{
+		ID:   id,
+		Name: id,
+		Get: func(_ context.Context, _ *fastly.Client, serviceID string) (EnableOutput, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if err := f.fail[serviceID+"/"+id]; err != nil {
+				return EnableOutput{}, err
+			}
+			if !f.enabled[serviceID][id] {
+				return EnableOutput{}, &fastly.HTTPError{StatusCode: http.StatusNotFound}
+			}
+			return NewEnableOutput(id, serviceID), nil
+		},
+		Enable: EnableWithoutInput(func(_ context.Context, _ *fastly.Client, serviceID string) (EnableOutput, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.calls = append(f.calls, "enable "+serviceID+"/"+id)
+			f.set(serviceID, id, true)
+			return NewEnableOutput(id, serviceID), nil
+		}),
+		Disable: func(_ context.Context, _ *fastly.Client, serviceID string) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.calls = append(f.calls, "disable "+serviceID+"/"+id)
+			f.set(serviceID, id, false)
+			return nil
+		},
+	}
+	if !configurable {
+		return p
+	}
+
+	p.Enable = EnableWithInput(func(_ context.Context, _ *fastly.Client, serviceID string, i fakeEnableInput) (EnableOutput, error) {
+		if i.WorkspaceID == "" {
+			return EnableOutput{}, fastly.ErrMissingWorkspaceID
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.calls = append(f.calls, "enable "+serviceID+"/"+id+" "+i.WorkspaceID)
+		f.set(serviceID, id, true)
+		return NewEnableOutput(id, serviceID), nil
+	})
+	p.GetConfiguration = func(_ context.Context, _ *fastly.Client, serviceID string) (map[string]any, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.config[serviceID][id], nil
+	}
+	p.UpdateConfiguration = UpdateConfigurationWithInput(func(_ context.Context, _ *fastly.Client, serviceID string, i fakeConfigureInput) (ConfigureOutput, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.calls = append(f.calls, "configure "+serviceID+"/"+id+" "+i.Mode)
+		if f.config[serviceID] == nil {
+			f.config[serviceID] = make(map[string]map[string]any)
+		}
+		f.config[serviceID][id] = map[string]any{"mode": i.Mode}
+		return ConfigureOutput{}, nil
+	})
+	return p
+}
+
+func (f *fakeProducts) lookup(list ...Product) func(string) (Product, bool) {
+	return func(id string) (Product, bool) {
+		for _, p := range list {
+			if p.ID == id {
+				return p, true
+			}
+		}
+		return Product{}, false
+	}
+}
+
+func TestInventory(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeProducts()
+	f.set("svc1", "fanout", true)
+	f.set("svc2", "ngwaf", true)
+	f.fail["svc2/fanout"] = &fastly.HTTPError{StatusCode: http.StatusInternalServerError}
+	list := []Product{f.product("fanout", false), f.product("ngwaf", true)}
+
+	inv, err := inventory(context.Background(), nil, list, []string{"svc1", "svc2"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"fanout", "ngwaf"}, inv.ProductIDs)
+	require.Equal(t, []string{"svc1", "svc2"}, inv.ServiceIDs)
+	require.True(t, inv.Enabled("svc1", "fanout"))
+	require.False(t, inv.Enabled("svc1", "ngwaf"))
+	require.Equal(t, []string{"fanout"}, inv.EnabledProducts("svc1"))
+	require.Equal(t, []string{"ngwaf"}, inv.EnabledProducts("svc2"))
+	require.NoError(t, inv.Status["svc1"]["ngwaf"].Err)
+
+	var herr *fastly.HTTPError
+	require.ErrorAs(t, inv.Status["svc2"]["fanout"].Err, &herr)
+	require.ErrorContains(t, inv.Err(), "service svc2, product fanout")
+}
+
+func TestInventoryMissingServiceID(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeProducts()
+	_, err := inventory(context.Background(), nil, []Product{f.product("fanout", false)}, []string{""})
+	require.ErrorIs(t, err, fastly.ErrMissingServiceID)
+}
+
+func TestInventoryCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f := newFakeProducts()
+	_, err := inventory(ctx, nil, []Product{f.product("fanout", false)}, []string{"svc1"})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestApplyProductState(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeProducts()
+	f.set("svc1", "fanout", true)
+	f.set("svc1", "ngwaf", true)
+	f.config["svc1"] = map[string]map[string]any{"ngwaf": {"mode": "block", "traffic_ramp": "100"}}
+	f.set("svc2", "fanout", true)
+	lookup := f.lookup(f.product("fanout", false), f.product("ngwaf", true))
+
+	report, err := applyProductState(context.Background(), nil, lookup, []ProductState{
+		// Already in the desired state.
+		{ServiceID: "svc1", ProductID: "fanout", Enabled: true},
+		// Attributes not set in the desired configuration are ignored.
+		{ServiceID: "svc1", ProductID: "ngwaf", Enabled: true, Configuration: fakeConfigureInput{Mode: "block"}},
+		{ServiceID: "svc2", ProductID: "fanout", Enabled: false},
+		{ServiceID: "svc2", ProductID: "ngwaf", Enabled: true, EnableInput: map[string]any{"workspace_id": "ws1"}, Configuration: map[string]any{"mode": "log"}},
+		{ServiceID: "svc3", ProductID: "ngwaf", Enabled: true},
+		{ServiceID: "svc3", ProductID: "fanout", Enabled: false},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"disable svc2/fanout",
+		"enable svc2/ngwaf ws1",
+		"configure svc2/ngwaf log",
+	}, f.calls)
+
+	require.Len(t, report.Results, 6)
+	require.Empty(t, report.Results[0].Actions)
+	require.Empty(t, report.Results[1].Actions)
+	require.Equal(t, []ProductStateAction{ProductStateDisable}, report.Results[2].Actions)
+	require.Equal(t, []ProductStateAction{ProductStateEnable, ProductStateConfigure}, report.Results[3].Actions)
+	require.Len(t, report.Changed(), 2)
+
+	failed := report.Failed()
+	require.Len(t, failed, 1)
+	require.Equal(t, "svc3", failed[0].ServiceID)
+	require.ErrorIs(t, failed[0].Err, fastly.ErrMissingWorkspaceID)
+}
+
+func TestApplyProductStateInvalid(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeProducts()
+	lookup := f.lookup(f.product("fanout", false))
+
+	cases := []struct {
+		name  string
+		state ProductState
+		want  error
+	}{
+		{"missing service ID", ProductState{ProductID: "fanout"}, fastly.ErrMissingServiceID},
+		{"missing product ID", ProductState{ServiceID: "svc1"}, fastly.ErrMissingProductID},
+		{"unknown product", ProductState{ServiceID: "svc1", ProductID: "nope"}, ErrUnknownProduct},
+		{"not configurable", ProductState{ServiceID: "svc1", ProductID: "fanout", Enabled: true, Configuration: map[string]any{}}, ErrProductNotConfigurable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := applyProductState(context.Background(), nil, lookup, []ProductState{tc.state})
+			require.ErrorIs(t, err, tc.want)
+		})
+	}
+	require.Empty(t, f.calls)
+}
+
+func TestConvertInput(t *testing.T) {
+	t.Parallel()
+
+	want := fakeEnableInput{WorkspaceID: "ws1"}
+	for _, input := range []any{want, &want, map[string]any{"workspace_id": "ws1"}} {
+		got, err := convertInput[fakeEnableInput](input)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+
+	got, err := convertInput[fakeEnableInput](nil)
+	require.NoError(t, err)
+	require.Zero(t, got)
+
+	_, err = convertInput[fakeEnableInput]("ws1")
+	require.Error(t, err)
+}
+
+func TestConfigurationAttributes(t *testing.T) {
+	t.Parallel()
+
+	type nested struct {
+		Mode        *string `mapstructure:"mode"`
+		TrafficRamp *string `mapstructure:"traffic_ramp,omitempty"`
+	}
+	type output struct {
+		ConfigureOutput `mapstructure:",squash"`
+		Configuration   *nested `mapstructure:"configuration"`
+	}
+
+	attrs, err := configurationAttributes(output{Configuration: &nested{Mode: fastly.ToPointer("block")}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"mode": "block"}, attrs)
+}
@@ -0,0 +1,204 @@
+package products
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+// EnableFunc enables a product on a service. The input is passed to the
+// product's typed Enable function, see EnableWithInput.
+type EnableFunc func(ctx context.Context, c *fastly.Client, serviceID string, input any) (EnableOutput, error)
+
+// GetConfigurationFunc gets the configuration of a product on a service, as
+// the attributes of the 'configuration' object returned by the API.
+type GetConfigurationFunc func(ctx context.Context, c *fastly.Client, serviceID string) (map[string]any, error)
+
+// UpdateConfigurationFunc updates the configuration of a product on a
+// service. The input is passed to the product's typed UpdateConfiguration
+// function, see UpdateConfigurationWithInput.
+type UpdateConfigurationFunc func(ctx context.Context, c *fastly.Client, serviceID string, input any) error
+
+// Product describes the operations offered by a product package, so that
+// products can be handled uniformly.
+type Product struct {
+	// Disable disables the product on a service.
+	Disable func(ctx context.Context, c *fastly.Client, serviceID string) error
+	// Enable enables the product on a service.
+	Enable EnableFunc
+	// Get gets the status of the product on a service.
+	Get func(ctx context.Context, c *fastly.Client, serviceID string) (EnableOutput, error)
+	// GetConfiguration gets the configuration of the product on a service. It
+	// is nil for products without configuration.
+	GetConfiguration GetConfigurationFunc
+	// ID is the product ID used by the API.
+	ID string
+	// Name is the human-readable name of the product.
+	Name string
+	// UpdateConfiguration updates the configuration of the product on a
+	// service. It is nil for products without configuration.
+	UpdateConfiguration UpdateConfigurationFunc
+}
+
+// Configurable reports whether the product has a configuration.
+func (p Product) Configurable() bool {
+	return p.GetConfiguration != nil && p.UpdateConfiguration != nil
+}
+
+var (
+	// ErrUnknownProduct is the error returned when a product ID is not in
+	// the registry.
+	ErrUnknownProduct = errors.New("unknown product")
+	// ErrProductNotConfigurable is the error returned when a configuration
+	// is given for a product without configuration.
+	ErrProductNotConfigurable = errors.New("product has no configuration")
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Product)
+)
+
+// Register adds a product to the registry. Product packages register
+// themselves when they are imported; import the 'all' subpackage to register
+// every product.
+//
+// Register panics if the product has no ID or operations, or if a product
+// with the same ID is already registered.
+func Register(p Product) {
+	if p.ID == "" || p.Get == nil || p.Enable == nil || p.Disable == nil {
+		panic("products: Register called with an incomplete product")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[p.ID]; ok {
+		panic("products: Register called twice for product " + p.ID)
+	}
+	registry[p.ID] = p
+}
+
+// Registry returns the registered products, ordered by product ID.
+func Registry() []Product {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	list := make([]Product, 0, len(registry))
+	for _, p := range registry {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].ID < list[b].ID })
+	return list
+}
+
+// Lookup returns the registered product with the given ID.
+func Lookup(productID string) (Product, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := registry[productID]
+	return p, ok
+}
+
+// EnableWithoutInput adapts the typed Enable function of a product which
+// does not accept an input. Any input passed to the returned function is
+// ignored.
+func EnableWithoutInput(fn func(context.Context, *fastly.Client, string) (EnableOutput, error)) EnableFunc {
+	return func(ctx context.Context, c *fastly.Client, serviceID string, _ any) (EnableOutput, error) {
+		return fn(ctx, c, serviceID)
+	}
+}
+
+// EnableWithInput adapts the typed Enable function of a product which
+// accepts an input of type I. See convertInput for the inputs accepted by
+// the returned function.
+func EnableWithInput[I any](fn func(context.Context, *fastly.Client, string, I) (EnableOutput, error)) EnableFunc {
+	return func(ctx context.Context, c *fastly.Client, serviceID string, input any) (EnableOutput, error) {
+		i, err := convertInput[I](input)
+		if err != nil {
+			return EnableOutput{}, err
+		}
+		return fn(ctx, c, serviceID, i)
+	}
+}
+
+// GetConfigurationWithOutput adapts the typed GetConfiguration function of a
+// product.
+func GetConfigurationWithOutput[O ProductOutput](fn func(context.Context, *fastly.Client, string) (O, error)) GetConfigurationFunc {
+	return func(ctx context.Context, c *fastly.Client, serviceID string) (map[string]any, error) {
+		o, err := fn(ctx, c, serviceID)
+		if err != nil {
+			return nil, err
+		}
+		return configurationAttributes(o)
+	}
+}
+
+// UpdateConfigurationWithInput adapts the typed UpdateConfiguration function
+// of a product which accepts an input of type I. See convertInput for the
+// inputs accepted by the returned function.
+func UpdateConfigurationWithInput[I any, O ProductOutput](fn func(context.Context, *fastly.Client, string, I) (O, error)) UpdateConfigurationFunc {
+	return func(ctx context.Context, c *fastly.Client, serviceID string, input any) error {
+		i, err := convertInput[I](input)
+		if err != nil {
+			return err
+		}
+		_, err = fn(ctx, c, serviceID, i)
+		return err
+	}
+}
+
+// convertInput converts an untyped input to the input type of a product
+// operation. It accepts nil (the zero value), an I, a *I, or any value
+// whose JSON encoding decodes into an I, such as a map[string]any.
+func convertInput[I any](input any) (I, error) {
+	var i I
+	switch v := input.(type) {
+	case nil:
+		return i, nil
+	case I:
+		return v, nil
+	case *I:
+		if v != nil {
+			i = *v
+		}
+		return i, nil
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return i, fmt.Errorf("converting %T to %T: %w", input, i, err)
+	}
+	if err := json.Unmarshal(data, &i); err != nil {
+		return i, fmt.Errorf("converting %T to %T: %w", input, i, err)
+	}
+	return i, nil
+}
+
+// configurationAttributes returns the attributes of the 'configuration'
+// field of a product's ConfigureOutput, keyed by their API names and in
+// their JSON form, so that they can be compared with a ConfigureInput.
+func configurationAttributes(o any) (map[string]any, error) {
+	var fields map[string]any
+	if err := mapstructure.Decode(o, &fields); err != nil {
+		return nil, err
+	}
+	return jsonObject(fields["configuration"])
+}
+
+// jsonObject returns the JSON form of a value which encodes as a JSON
+// object.
+func jsonObject(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,218 @@
+package fastly
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/fastly/go-fastly/v17/internal/ctxutil"
+)
+
+const (
+	// DefaultPurgeVerifyPollInterval is the default delay between two edge
+	// checks of PurgeAndVerify.
+	DefaultPurgeVerifyPollInterval = 5 * time.Second
+	// DefaultPurgeVerifyTimeout is the default time PurgeAndVerify waits for
+	// every server to converge.
+	DefaultPurgeVerifyTimeout = 2 * time.Minute
+)
+
+// PurgeVerifyOptions controls PurgeAndVerify.
+type PurgeVerifyOptions struct {
+	// Key purges the items tagged with this surrogate key with PurgeKey,
+	// instead of purging the URL. ServiceID is required with it.
+	Key string
+	// PollInterval is the delay between two edge checks (default:
+	// DefaultPurgeVerifyPollInterval).
+	PollInterval time.Duration
+	// ServiceID is the ID of the service to purge the key from.
+	ServiceID string
+	// Soft performs a soft purge.
+	Soft bool
+	// Timeout is how long to wait for every server to converge (default:
+	// DefaultPurgeVerifyTimeout).
+	Timeout time.Duration
+}
+
+// PurgePOPConvergence is the convergence of the servers of one POP after a
+// purge.
+type PurgePOPConvergence struct {
+	// Code is the POP code, such as "AMS".
+	Code string
+	// Converged indicates every server of the POP converged.
+	Converged bool
+	// ConvergedAfter is the time from the purge until the last server of the
+	// POP converged. It is zero when Converged is false.
+	ConvergedAfter time.Duration
+	// Laggards are the servers of the POP which did not converge.
+	Laggards []string
+	// Servers is the number of servers of the POP.
+	Servers int
+}
+
+// PurgeVerification is the outcome of PurgeAndVerify.
+type PurgeVerification struct {
+	// Converged indicates every server converged before the timeout.
+	Converged bool
+	// Duration is the time from the purge until every server converged, or
+	// until the timeout.
+	Duration time.Duration
+	// Laggards are the servers which did not converge, ordered by name.
+	Laggards []string
+	// POPs describes the convergence of each POP, ordered by code.
+	POPs []PurgePOPConvergence
+	// Purge is the response to the purge request.
+	Purge *Purge
+}
+
+// PurgeAndVerify purges a URL, or a surrogate key with opts.Key, and then
+// polls EdgeCheck for the URL until every server has converged or the timeout
+// passes.
+//
+// The content hash of each server is recorded before the purge. A server has
+// converged once it reports a cache miss or a hash other than the one it
+// reported before the purge. Servers which did not respond before the purge
+// are compared with the most common hash, and servers which stop responding
+// after it are reported as laggards.
+//
+// Servers are grouped by POP as in NewEdgeCheckReport, using the datacenters
+// returned by AllDatacenters. A timeout is not an error: the returned
+// verification lists the laggards instead. Retryable errors from EdgeCheck
+// are retried at the next poll.
+func (c *Client) PurgeAndVerify(ctx context.Context, url string, opts *PurgeVerifyOptions) (*PurgeVerification, error) {
+	if url == "" {
+		return nil, ErrMissingURL
+	}
+	var o PurgeVerifyOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Key != "" && o.ServiceID == "" {
+		return nil, ErrMissingServiceID
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultPurgeVerifyPollInterval
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultPurgeVerifyTimeout
+	}
+
+	datacenters, err := c.AllDatacenters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	codes := datacenterCodes(datacenters)
+
+	before, err := c.EdgeCheck(ctx, &EdgeCheckInput{URL: url})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		baseline  = make(map[string]string)
+		hashes    = make(map[string]int)
+		pops      = make(map[string]string)
+		converged = make(map[string]time.Duration)
+	)
+	for _, ec := range before {
+		if ec == nil || ec.Server == nil {
+			continue
+		}
+		pops[*ec.Server] = edgeCheckPOPCode(ec, codes)
+		if ec.Hash != nil {
+			baseline[*ec.Server] = *ec.Hash
+			hashes[*ec.Hash]++
+		}
+	}
+	common := mostCommon(hashes)
+
+	var purge *Purge
+	if o.Key != "" {
+		purge, err = c.PurgeKey(ctx, &PurgeKeyInput{Key: o.Key, ServiceID: o.ServiceID, Soft: o.Soft})
+	} else {
+		purge, err = c.Purge(ctx, &PurgeInput{Soft: o.Soft, URL: url})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	deadline := start.Add(o.Timeout)
+	for {
+		checks, err := c.EdgeCheck(ctx, &EdgeCheckInput{URL: url})
+		var herr *HTTPError
+		switch {
+		case err == nil:
+			at := time.Since(start)
+			for _, ec := range checks {
+				if ec == nil || ec.Server == nil {
+					continue
+				}
+				server := *ec.Server
+				pops[server] = edgeCheckPOPCode(ec, codes)
+				if _, ok := converged[server]; ok {
+					continue
+				}
+				old, ok := baseline[server]
+				if !ok {
+					old = common
+				}
+				if edgeCheckCacheResult(ec) == "MISS" || (ec.Hash != nil && *ec.Hash != old) {
+					converged[server] = at
+				}
+			}
+		case !errors.As(err, &herr) || !herr.IsRetryable():
+			return nil, err
+		}
+
+		if len(pops) > 0 && len(converged) == len(pops) {
+			break
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		if !ctxutil.Sleep(ctx, min(o.PollInterval, remaining)) {
+			return nil, ctx.Err()
+		}
+	}
+
+	return newPurgeVerification(purge, pops, converged, time.Since(start)), nil
+}
+
+// newPurgeVerification summarizes the convergence of servers, given the POP
+// of each server and the time each converged server took.
+func newPurgeVerification(purge *Purge, pops map[string]string, converged map[string]time.Duration, elapsed time.Duration) *PurgeVerification {
+	v := &PurgeVerification{Converged: len(pops) > 0, Purge: purge}
+	byPOP := make(map[string]*PurgePOPConvergence)
+	for _, server := range slices.Sorted(maps.Keys(pops)) {
+		code := pops[server]
+		p, ok := byPOP[code]
+		if !ok {
+			p = &PurgePOPConvergence{Code: code, Converged: true}
+			byPOP[code] = p
+		}
+		p.Servers++
+		if after, ok := converged[server]; ok {
+			p.ConvergedAfter = max(p.ConvergedAfter, after)
+			v.Duration = max(v.Duration, after)
+			continue
+		}
+		p.Converged = false
+		p.Laggards = append(p.Laggards, server)
+		v.Converged = false
+		v.Laggards = append(v.Laggards, server)
+	}
+	for _, code := range slices.Sorted(maps.Keys(byPOP)) {
+		p := byPOP[code]
+		if !p.Converged {
+			p.ConvergedAfter = 0
+		}
+		v.POPs = append(v.POPs, *p)
+	}
+	if !v.Converged {
+		v.Duration = elapsed
+	}
+	return v
+}
@@ -0,0 +1,107 @@
+package fastly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPurgeAndVerify(t *testing.T) {
+	t.Parallel()
+
+	var (
+		v   *PurgeVerification
+		err error
+	)
+	// After the purge, cache-ams2100126 misses and cache-lhr7380045 serves new
+	// content while cache-ams2100131 lags. The next edge check fails with a
+	// 503 and is retried; then cache-ams2100131 serves new content, and a hit
+	// on stale content by cache-ams2100126 does not undo its convergence.
+	Record(t, "purge_verify/converged", func(c *Client) {
+		v, err = c.PurgeAndVerify(context.TODO(), "https://www.example.com/a", &PurgeVerifyOptions{PollInterval: time.Millisecond})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !v.Converged || len(v.Laggards) != 0 || ToValue(v.Purge.PurgeID) != "12441-1792324800-3287" {
+		t.Errorf("bad verification: %+v", v)
+	}
+	// The POPs reported by the API are shield names mapped to their codes.
+	type pop struct {
+		Code      string
+		Converged bool
+		Servers   int
+	}
+	var pops []pop
+	for _, p := range v.POPs {
+		pops = append(pops, pop{Code: p.Code, Converged: p.Converged, Servers: p.Servers})
+	}
+	want := []pop{
+		{Code: "AMS", Converged: true, Servers: 2},
+		{Code: "LHR", Converged: true, Servers: 1},
+	}
+	if diff := cmp.Diff(want, pops); diff != "" {
+		t.Errorf("bad POPs: %s", diff)
+	}
+	if ams, lhr := v.POPs[0].ConvergedAfter, v.POPs[1].ConvergedAfter; ams <= lhr || v.Duration != ams {
+		t.Errorf("bad convergence times: AMS %s, LHR %s, total %s", ams, lhr, v.Duration)
+	}
+}
+
+func TestPurgeAndVerifyTimeout(t *testing.T) {
+	t.Parallel()
+
+	var (
+		v   *PurgeVerification
+		err error
+	)
+	// The timeout passes after the first edge check following the purge, in
+	// which cache-ams2100126 still serves stale content and cache-syd10129
+	// no longer responds.
+	Record(t, "purge_verify/timeout", func(c *Client) {
+		v, err = c.PurgeAndVerify(context.TODO(), "https://www.example.com/a", &PurgeVerifyOptions{
+			Key:       "product-42",
+			ServiceID: "7i6HN3TK9wS159v2gPAZ8A",
+			Timeout:   time.Nanosecond,
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Converged || v.Duration <= 0 || ToValue(v.Purge.PurgeID) != "12441-1792324800-3288" {
+		t.Errorf("bad verification: %+v", v)
+	}
+	if diff := cmp.Diff([]string{"cache-ams2100126", "cache-syd10129"}, v.Laggards); diff != "" {
+		t.Errorf("bad laggards: %s", diff)
+	}
+	if len(v.POPs) != 2 || v.POPs[1].Code != "SYD" || v.POPs[1].Converged {
+		t.Errorf("bad POPs: %+v", v.POPs)
+	}
+}
+
+func TestPurgeAndVerifyErrors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := TestClient.PurgeAndVerify(context.TODO(), "", nil); !errors.Is(err, ErrMissingURL) {
+		t.Errorf("missing URL: %v", err)
+	}
+	if _, err := TestClient.PurgeAndVerify(context.TODO(), "www.example.com", &PurgeVerifyOptions{Key: "product-42"}); !errors.Is(err, ErrMissingServiceID) {
+		t.Errorf("missing service ID: %v", err)
+	}
+
+	var err error
+	// The edge check following the purge is forbidden, which is not retried.
+	Record(t, "purge_verify/error", func(c *Client) {
+		_, err = c.PurgeAndVerify(context.TODO(), "https://www.example.com/a", nil)
+	})
+	var herr *HTTPError
+	if !errors.As(err, &herr) || herr.StatusCode != http.StatusForbidden {
+		t.Errorf("non-retryable error: %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+package fastly
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clientKeyExpiryMargin is how long before its ExpiresAt time a cached client
+// key is replaced, so that it does not expire while a request is in flight.
+const clientKeyExpiryMargin = 30 * time.Second
+
+// SecretEncryptor encrypts secrets locally before uploading them to a Secret
+// Store, so the plaintext never leaves the caller.
+//
+// A client key is created on first use, its signature is verified against the
+// signing key, and it is then cached and reused until shortly before it
+// expires. A SecretEncryptor is safe for concurrent use.
+type SecretEncryptor struct {
+	signingKey ed25519.PublicKey
+
+	// createClientKey, createSecret, getSigningKey and now are replaced in
+	// tests.
+	createClientKey func(context.Context) (*ClientKey, error)
+	createSecret    func(context.Context, *CreateSecretInput) (*Secret, error)
+	getSigningKey   func(context.Context) (ed25519.PublicKey, error)
+	now             func() time.Time
+
+	mu  sync.Mutex
+	key *ClientKey
+}
+
+// NewSecretEncryptor returns a SecretEncryptor for the given client.
+//
+// signingKey should be the Secret Store signing key obtained out-of-band, as
+// recommended by GetSigningKey. If it is nil, it is retrieved with
+// GetSigningKey on first use.
+func (c *Client) NewSecretEncryptor(signingKey ed25519.PublicKey) *SecretEncryptor {
+	return &SecretEncryptor{
+		signingKey:      signingKey,
+		createClientKey: c.CreateClientKey,
+		createSecret:    c.CreateSecret,
+		getSigningKey:   c.GetSigningKey,
+		now:             time.Now,
+	}
+}
+
+// PutSecretEncryptedInput is used as input to the PutSecretEncrypted function.
+type PutSecretEncryptedInput struct {
+	// Method is the HTTP request method used to create the secret. See
+	// CreateSecretInput for the accepted values.
+	Method string
+	// Name of the Secret (required).
+	Name string
+	// Secret is the plaintext secret to be encrypted and stored (required).
+	Secret []byte
+	// StoreID of the Secret Store (required).
+	StoreID string
+}
+
+// PutSecretEncrypted encrypts a secret with a verified client key and stores
+// it. If the API rejects the client key with a 400 Bad Request, because the
+// error names the client key or the key has expired in the meantime, the
+// cached key is discarded and the secret is encrypted again with a new key
+// and retried once. Other errors are returned as is.
+func (e *SecretEncryptor) PutSecretEncrypted(ctx context.Context, i *PutSecretEncryptedInput) (*Secret, error) {
+	if i.StoreID == "" {
+		return nil, ErrMissingStoreID
+	}
+	if i.Name == "" {
+		return nil, ErrMissingName
+	}
+	if len(i.Secret) == 0 {
+		return nil, ErrMissingSecret
+	}
+
+	for attempt := 0; ; attempt++ {
+		ck, err := e.clientKey(ctx)
+		if err != nil {
+			return nil, err
+		}
+		enc, err := ck.Encrypt(i.Secret)
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := e.createSecret(ctx, &CreateSecretInput{
+			ClientKey: ck.PublicKey,
+			Method:    i.Method,
+			Name:      i.Name,
+			Secret:    enc,
+			StoreID:   i.StoreID,
+		})
+		if err != nil && attempt == 0 && e.isClientKeyError(err, ck) {
+			e.invalidate(ck)
+			continue
+		}
+		return s, err
+	}
+}
+
+// isClientKeyError reports whether err is the API rejecting ck: a 400 Bad
+// Request whose error names the client key, or any 400 Bad Request once ck
+// has expired.
+func (e *SecretEncryptor) isClientKeyError(err error, ck *ClientKey) bool {
+	var herr *HTTPError
+	if !errors.As(err, &herr) || !herr.IsBadRequest() {
+		return false
+	}
+	if !e.now().Before(ck.ExpiresAt) {
+		return true
+	}
+	for _, eo := range herr.Errors {
+		if eo == nil {
+			continue
+		}
+		msg := strings.ToLower(eo.Title + " " + eo.Detail)
+		if strings.Contains(msg, "client key") || strings.Contains(msg, "client_key") {
+			return true
+		}
+	}
+	return false
+}
+
+// PutSecretsEncryptedInput is used as input to the PutSecretsEncrypted
+// function.
+type PutSecretsEncryptedInput struct {
+	// Method is the HTTP request method used to create each secret. See
+	// CreateSecretInput for the accepted values.
+	Method string
+	// Secrets maps secret names to their plaintext values (required).
+	Secrets map[string][]byte
+	// StoreID of the Secret Store (required).
+	StoreID string
+}
+
+// PutSecretsEncrypted encrypts and stores each secret in name order. It stops
+// at the first failure, returning the secrets stored so far along with the
+// error.
+func (e *SecretEncryptor) PutSecretsEncrypted(ctx context.Context, i *PutSecretsEncryptedInput) ([]*Secret, error) {
+	if i.StoreID == "" {
+		return nil, ErrMissingStoreID
+	}
+	if len(i.Secrets) == 0 {
+		return nil, ErrMissingSecret
+	}
+
+	names := make([]string, 0, len(i.Secrets))
+	for name := range i.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	secrets := make([]*Secret, 0, len(names))
+	for _, name := range names {
+		s, err := e.PutSecretEncrypted(ctx, &PutSecretEncryptedInput{
+			Method:  i.Method,
+			Name:    name,
+			Secret:  i.Secrets[name],
+			StoreID: i.StoreID,
+		})
+		if err != nil {
+			return secrets, fmt.Errorf("failed to store secret %q: %w", name, err)
+		}
+		secrets = append(secrets, s)
+	}
+	return secrets, nil
+}
+
+// clientKey returns the cached client key, creating and verifying a new one
+// if none is cached or the cached key is about to expire.
+func (e *SecretEncryptor) clientKey(ctx context.Context) (*ClientKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.key != nil && e.now().Add(clientKeyExpiryMargin).Before(e.key.ExpiresAt) {
+		return e.key, nil
+	}
+
+	if e.signingKey == nil {
+		sk, err := e.getSigningKey(ctx)
+		if err != nil {
+			return nil, err
+		}
+		e.signingKey = sk
+	}
+	if len(e.signingKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid signing key length %d", len(e.signingKey))
+	}
+
+	ck, err := e.createClientKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ck.VerifySignature(e.signingKey) {
+		return nil, ErrInvalidClientKeySignature
+	}
+
+	e.key = ck
+	return ck, nil
+}
+
+// invalidate discards ck if it is still the cached client key.
+func (e *SecretEncryptor) invalidate(ck *ClientKey) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.key == ck {
+		e.key = nil
+	}
+}
+
+// ReadSecretsFile reads secrets from a file for use with PutSecretsEncrypted.
+// Files with a .json extension must contain a single JSON object of string
+// values; any other file is parsed as a .env file (see ParseSecretsEnv).
+func ReadSecretsFile(path string) (map[string][]byte, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ParseSecretsJSON(f)
+	}
+	return ParseSecretsEnv(f)
+}
+
+// ParseSecretsJSON parses a JSON object of string values into secrets.
+func ParseSecretsJSON(r io.Reader) (map[string][]byte, error) {
+	var values map[string]string
+	if err := json.NewDecoder(r).Decode(&values); err != nil {
+		return nil, fmt.Errorf("failed to decode secrets: %w", err)
+	}
+
+	secrets := make(map[string][]byte, len(values))
+	for name, value := range values {
+		secrets[name] = []byte(value)
+	}
+	return secrets, nil
+}
+
+// ParseSecretsEnv parses secrets in .env format: one NAME=value pair per line,
+// with blank lines and lines starting with # ignored and an optional leading
+// "export". Values may be enclosed in double quotes, in which case \n, \r,
+// \t, \" and \\ escapes are expanded, or in single quotes, in which case they
+// are taken literally. Unquoted values end at the first " #".
+func ParseSecretsEnv(r io.Reader) (map[string][]byte, error) {
+	secrets := make(map[string][]byte)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		name, value, ok := strings.Cut(text, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("line %d: expected NAME=value", line)
+		}
+
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		secrets[name] = []byte(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// parseEnvValue unquotes a single .env value.
+func parseEnvValue(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		var b strings.Builder
+		for i := 1; i < len(v); i++ {
+			switch c := v[i]; c {
+			case '"':
+				return b.String(), nil
+			case '\\':
+				i++
+				if i == len(v) {
+					return "", errors.New("unterminated escape sequence")
+				}
+				switch v[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(v[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated double-quoted value")
+	case strings.HasPrefix(v, "'"):
+		end := strings.IndexByte(v[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated single-quoted value")
+		}
+		return v[1 : end+1], nil
+	default:
+		if i := strings.Index(v, " #"); i >= 0 {
+			v = v[:i]
+		}
+		return strings.TrimSpace(v), nil
+	}
+}
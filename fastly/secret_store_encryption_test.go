@@ -0,0 +1,241 @@
+package fastly
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/nacl/box"
+)
+
+func TestSecretEncryptor_clientKey(t *testing.T) {
+	t.Parallel()
+
+	signingPub, signingPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var created int
+	newKey := func(priv ed25519.PrivateKey) func(context.Context) (*ClientKey, error) {
+		return func(context.Context) (*ClientKey, error) {
+			created++
+			pub, _, err := box.GenerateKey(rand.Reader)
+			if err != nil {
+				return nil, err
+			}
+			return &ClientKey{
+				PublicKey: pub[:],
+				Signature: ed25519.Sign(priv, pub[:]),
+				ExpiresAt: now.Add(5 * time.Minute),
+			}, nil
+		}
+	}
+
+	var fetchedSigningKey int
+	e := &SecretEncryptor{
+		createClientKey: newKey(signingPriv),
+		getSigningKey: func(context.Context) (ed25519.PublicKey, error) {
+			fetchedSigningKey++
+			return signingPub, nil
+		},
+		now: func() time.Time { return now },
+	}
+
+	first, err := e.clientKey(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := e.clientKey(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second || created != 1 || fetchedSigningKey != 1 {
+		t.Errorf("expected a cached key, got %d keys created and %d signing key fetches", created, fetchedSigningKey)
+	}
+
+	// A key close to expiry is replaced.
+	now = now.Add(5*time.Minute - clientKeyExpiryMargin)
+	third, err := e.clientKey(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third == first || created != 2 || fetchedSigningKey != 1 {
+		t.Errorf("expected a new key, got %d keys created and %d signing key fetches", created, fetchedSigningKey)
+	}
+
+	// An invalidated key is replaced.
+	e.invalidate(third)
+	if fourth, err := e.clientKey(context.TODO()); err != nil || fourth == third {
+		t.Errorf("expected a new key after invalidation, got %v", err)
+	}
+
+	// A key signed by another key is rejected.
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := &SecretEncryptor{
+		signingKey:      signingPub,
+		createClientKey: newKey(otherPriv),
+		now:             func() time.Time { return now },
+	}
+	if _, err := forged.clientKey(context.TODO()); !errors.Is(err, ErrInvalidClientKeySignature) {
+		t.Errorf("expected ErrInvalidClientKeySignature, got %v", err)
+	}
+}
+
+func TestSecretEncryptor_PutSecretEncrypted(t *testing.T) {
+	t.Parallel()
+
+	signingPub, signingPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// newEncryptor returns an encryptor whose CreateSecret calls fail with
+	// the given errors in turn, recording the client key of each call.
+	newEncryptor := func(errs ...error) (*SecretEncryptor, *[][]byte) {
+		var keys [][]byte
+		return &SecretEncryptor{
+			signingKey: signingPub,
+			createClientKey: func(context.Context) (*ClientKey, error) {
+				pub, _, err := box.GenerateKey(rand.Reader)
+				if err != nil {
+					return nil, err
+				}
+				return &ClientKey{
+					PublicKey: pub[:],
+					Signature: ed25519.Sign(signingPriv, pub[:]),
+					ExpiresAt: now.Add(5 * time.Minute),
+				}, nil
+			},
+			createSecret: func(_ context.Context, i *CreateSecretInput) (*Secret, error) {
+				keys = append(keys, i.ClientKey)
+				if n := len(keys) - 1; n < len(errs) && errs[n] != nil {
+					return nil, errs[n]
+				}
+				return &Secret{Name: i.Name}, nil
+			},
+			now: func() time.Time { return now },
+		}, &keys
+	}
+	input := &PutSecretEncryptedInput{Name: "token", Secret: []byte("s3cr3t"), StoreID: "store"}
+	badRequest := func(detail string) error {
+		return &HTTPError{
+			Errors:     []*ErrorObject{{Detail: detail, Title: "Bad Request"}},
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	// A rejected client key is replaced and the secret stored with the new
+	// one.
+	e, keys := newEncryptor(badRequest("Client key has expired"))
+	if s, err := e.PutSecretEncrypted(context.TODO(), input); err != nil || s.Name != "token" {
+		t.Fatalf("unexpected result: %v, %v", s, err)
+	}
+	if len(*keys) != 2 || string((*keys)[0]) == string((*keys)[1]) {
+		t.Errorf("expected a retry with a new client key, got %d calls", len(*keys))
+	}
+
+	// Other bad requests are not retried.
+	e, keys = newEncryptor(badRequest("Name is too long"))
+	if _, err := e.PutSecretEncrypted(context.TODO(), input); !strings.Contains(fmt.Sprint(err), "Name is too long") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(*keys) != 1 {
+		t.Errorf("expected a single call, got %d", len(*keys))
+	}
+
+	// Unless the client key expired while the request was in flight.
+	e, keys = newEncryptor()
+	e.createSecret = func(_ context.Context, i *CreateSecretInput) (*Secret, error) {
+		e.now = func() time.Time { return now.Add(5 * time.Minute) }
+		*keys = append(*keys, i.ClientKey)
+		return nil, badRequest("Invalid secret")
+	}
+	if _, err := e.PutSecretEncrypted(context.TODO(), input); err == nil {
+		t.Error("expected an error")
+	}
+	if len(*keys) != 2 {
+		t.Errorf("expected a retry once the client key expired, got %d calls", len(*keys))
+	}
+
+	// A retry is only attempted once.
+	e, keys = newEncryptor(badRequest("client_key is invalid"), badRequest("client_key is invalid"))
+	if _, err := e.PutSecretEncrypted(context.TODO(), input); err == nil {
+		t.Error("expected an error")
+	}
+	if len(*keys) != 2 {
+		t.Errorf("expected two calls, got %d", len(*keys))
+	}
+}
+
+func TestParseSecretsEnv(t *testing.T) {
+	t.Parallel()
+
+	input := `# comment
+API_TOKEN=abc123
+export DB_PASSWORD = "p@ss \"word\"\n"
+LITERAL='no \n escapes'
+TRAILING=value # comment
+
+EMPTY=
+`
+	got, err := ParseSecretsEnv(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]byte{
+		"API_TOKEN":   []byte("abc123"),
+		"DB_PASSWORD": []byte("p@ss \"word\"\n"),
+		"EMPTY":       []byte(""),
+		"LITERAL":     []byte(`no \n escapes`),
+		"TRAILING":    []byte("value"),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected secrets (-want +got):\n%s", diff)
+	}
+
+	for _, bad := range []string{"NOVALUE", `X="unterminated`, "X='unterminated", "=value"} {
+		if _, err := ParseSecretsEnv(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected an error parsing %q", bad)
+		}
+	}
+}
+
+func TestReadSecretsFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "secrets.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"A": "1", "B": "two"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	envPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte("A=1\nB=two\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]byte{"A": []byte("1"), "B": []byte("two")}
+	for _, path := range []string{jsonPath, envPath} {
+		got, err := ReadSecretsFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected secrets from %s (-want +got):\n%s", path, diff)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package fastly
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimelineEventKind is the kind of a TimelineEvent.
+type TimelineEventKind string
+
+const (
+	// TimelineAlertFired is the start of an alert.
+	TimelineAlertFired TimelineEventKind = "alert.fired"
+	// TimelineAlertResolved is the end of an alert.
+	TimelineAlertResolved TimelineEventKind = "alert.resolved"
+	// TimelineEvent is any other account event.
+	TimelineEvent TimelineEventKind = "event"
+	// TimelinePurge is a purge of the service cache.
+	TimelinePurge TimelineEventKind = "purge"
+	// TimelineVersionActivated is the activation of a service version.
+	TimelineVersionActivated TimelineEventKind = "version.activated"
+	// TimelineVersionDeactivated is the deactivation of a service version.
+	TimelineVersionDeactivated TimelineEventKind = "version.deactivated"
+)
+
+// ServiceTimelineEntry is one entry of a service timeline.
+type ServiceTimelineEntry struct {
+	// Alert is the alert history the entry comes from, for alert entries.
+	Alert *AlertHistory
+	// At is the time of the entry.
+	At time.Time
+	// Event is the account event the entry comes from, if any.
+	Event *Event
+	// Kind is the kind of entry.
+	Kind TimelineEventKind
+	// Summary is a short human-readable description of the entry.
+	Summary string
+	// UserID is the ID of the user who caused the entry, if known.
+	UserID string
+	// Version is the service version of version entries, if known.
+	Version *int
+}
+
+// String formats the entry as a single line, such as:
+//
+//	2026-08-12T15:04:05Z version.activated: version 412 activated by alice
+func (e ServiceTimelineEntry) String() string {
+	return fmt.Sprintf("%s %s: %s", e.At.UTC().Format(time.RFC3339), e.Kind, e.Summary)
+}
+
+// ServiceTimeline is a list of entries in chronological order.
+type ServiceTimeline []ServiceTimelineEntry
+
+// TimelineCorrelation links an alert to the change that most closely
+// preceded it.
+type TimelineCorrelation struct {
+	// Alert is the alert.fired entry.
+	Alert ServiceTimelineEntry
+	// Cause is the version activation or purge preceding the alert.
+	Cause ServiceTimelineEntry
+	// Delay is the time between the cause and the alert.
+	Delay time.Duration
+}
+
+// String formats the correlation as a sentence, such as "alert 5xx ratio
+// fired 3m0s after version 412 activated by alice".
+func (c TimelineCorrelation) String() string {
+	return fmt.Sprintf("%s %s after %s", c.Alert.Summary, c.Delay, c.Cause.Summary)
+}
+
+// Correlate returns, for each fired alert, the most recent version activation
+// or purge within window before it. Alerts without such a change are left
+// out.
+func (t ServiceTimeline) Correlate(window time.Duration) []TimelineCorrelation {
+	var (
+		correlations []TimelineCorrelation
+		last         *ServiceTimelineEntry
+	)
+	for n := range t {
+		e := &t[n]
+		switch e.Kind {
+		case TimelineVersionActivated, TimelinePurge:
+			last = e
+		case TimelineAlertFired:
+			if last != nil && e.At.Sub(last.At) <= window {
+				correlations = append(correlations, TimelineCorrelation{Alert: *e, Cause: *last, Delay: e.At.Sub(last.At)})
+			}
+		}
+	}
+	return correlations
+}
+
+// GetServiceTimelineInput is used as input to the GetServiceTimeline function.
+type GetServiceTimelineInput struct {
+	// From is the start of the time range (required).
+	From time.Time
+	// ServiceID is the ID of the service (required).
+	ServiceID string
+	// To is the end of the time range (default: now).
+	To time.Time
+}
+
+// GetServiceTimeline merges the alert history, version activations, purges
+// and other account events of a service within a time range into a single
+// chronological timeline.
+//
+// Version activations and purges come from account events, which record the
+// user responsible. The activation of the currently active version is also
+// inferred from ListVersions when no account event covers it.
+func (c *Client) GetServiceTimeline(ctx context.Context, i *GetServiceTimelineInput) (ServiceTimeline, error) {
+	if i.ServiceID == "" {
+		return nil, ErrMissingServiceID
+	}
+	if i.From.IsZero() {
+		return nil, ErrMissingFrom
+	}
+	from, to := i.From, i.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if !from.Before(to) {
+		return nil, ErrInvalidTimelineRange
+	}
+	inRange := func(t time.Time) bool {
+		return !t.Before(from) && !t.After(to)
+	}
+
+	var timeline ServiceTimeline
+
+	history, err := c.listServiceAlertHistory(ctx, i.ServiceID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for n := range history {
+		h := &history[n]
+		name := h.Definition.Name
+		if name == "" {
+			name = h.DefinitionID
+		}
+		if inRange(h.Start) {
+			timeline = append(timeline, ServiceTimelineEntry{Alert: h, At: h.Start, Kind: TimelineAlertFired, Summary: fmt.Sprintf("alert %s fired", name)})
+		}
+		if !h.End.IsZero() && inRange(h.End) {
+			timeline = append(timeline, ServiceTimelineEntry{Alert: h, At: h.End, Kind: TimelineAlertResolved, Summary: fmt.Sprintf("alert %s resolved", name)})
+		}
+	}
+
+	events, err := c.GetAPIEvents(ctx, &GetAPIEventsFilterInput{
+		CreatedAfter:  from.UTC().Format(time.RFC3339),
+		CreatedBefore: to.UTC().Format(time.RFC3339),
+		ServiceID:     i.ServiceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	activated := make(map[int]bool)
+	for _, ev := range events.Events {
+		if ev == nil || ev.CreatedAt == nil || !inRange(*ev.CreatedAt) {
+			continue
+		}
+		e := timelineEntryFromEvent(ev)
+		if e.Kind == TimelineVersionActivated && e.Version != nil {
+			activated[*e.Version] = true
+		}
+		timeline = append(timeline, e)
+	}
+
+	versions, err := c.ListVersions(ctx, &ListVersionsInput{ServiceID: i.ServiceID})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v == nil || !ToValue(v.Active) || v.Number == nil || v.UpdatedAt == nil {
+			continue
+		}
+		if activated[*v.Number] || !inRange(*v.UpdatedAt) {
+			continue
+		}
+		timeline = append(timeline, ServiceTimelineEntry{
+			At:      *v.UpdatedAt,
+			Kind:    TimelineVersionActivated,
+			Summary: fmt.Sprintf("version %d activated", *v.Number),
+			Version: v.Number,
+		})
+	}
+
+	sort.SliceStable(timeline, func(a, b int) bool { return timeline[a].At.Before(timeline[b].At) })
+	return timeline, nil
+}
+
+// listServiceAlertHistory lists the alert history of a service within a time
+// range, following pagination cursors.
+func (c *Client) listServiceAlertHistory(ctx context.Context, serviceID string, from, to time.Time) ([]AlertHistory, error) {
+	var history []AlertHistory
+	input := &ListAlertHistoryInput{
+		After:     ToPointer(from.UTC().Format(time.RFC3339)),
+		Before:    ToPointer(to.UTC().Format(time.RFC3339)),
+		ServiceID: ToPointer(serviceID),
+	}
+	for {
+		resp, err := c.ListAlertHistory(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, resp.Data...)
+		if resp.Meta.NextCursor == "" || (input.Cursor != nil && *input.Cursor == resp.Meta.NextCursor) {
+			return history, nil
+		}
+		next := *input
+		next.Cursor = ToPointer(resp.Meta.NextCursor)
+		input = &next
+	}
+}
+
+// timelineEntryFromEvent classifies an account event.
+func timelineEntryFromEvent(ev *Event) ServiceTimelineEntry {
+	e := ServiceTimelineEntry{At: *ev.CreatedAt, Event: ev, Kind: TimelineEvent, UserID: ev.UserID}
+	by := ""
+	if ev.UserID != "" {
+		by = " by " + ev.UserID
+	}
+
+	switch {
+	case ev.EventType == "version.activate":
+		e.Kind = TimelineVersionActivated
+		e.Version = eventVersion(ev)
+	case ev.EventType == "version.deactivate":
+		e.Kind = TimelineVersionDeactivated
+		e.Version = eventVersion(ev)
+	case strings.Contains(ev.EventType, "purge"):
+		e.Kind = TimelinePurge
+	}
+
+	switch {
+	case e.Version != nil && e.Kind == TimelineVersionActivated:
+		e.Summary = fmt.Sprintf("version %d activated%s", *e.Version, by)
+	case e.Version != nil && e.Kind == TimelineVersionDeactivated:
+		e.Summary = fmt.Sprintf("version %d deactivated%s", *e.Version, by)
+	case ev.Description != "":
+		e.Summary = ev.Description + by
+	default:
+		e.Summary = ev.EventType + by
+	}
+	return e
+}
+
+// eventVersion reads the service version of a version event from its
+// metadata, returning nil if it is absent.
+func eventVersion(ev *Event) *int {
+	switch v := ev.Metadata["version"].(type) {
+	case float64:
+		return ToPointer(int(v))
+	case int:
+		return ToPointer(v)
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return ToPointer(n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package fastly
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetServiceTimeline(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	var (
+		timeline ServiceTimeline
+		err      error
+	)
+	// The alert history spans two pages, and the definition of the second
+	// alert was deleted.
+	Record(t, "service_timeline/get", func(c *Client) {
+		timeline, err = c.GetServiceTimeline(context.TODO(), &GetServiceTimelineInput{From: base, ServiceID: "7i6HN3TK9wS159v2gPAZ8A", To: at(60)})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range timeline {
+		got = append(got, e.String())
+	}
+	want := []string{
+		"2026-08-12T15:10:00Z version.activated: version 412 activated by 4tKBSuFhNEiIpNDxmmVydt",
+		"2026-08-12T15:13:00Z alert.fired: alert 5xx ratio fired",
+		"2026-08-12T15:20:00Z purge: Purged all by 1tSCjJnpQYBBCAsIfI6pX0",
+		"2026-08-12T15:30:00Z event: Updated logging endpoint",
+		"2026-08-12T15:40:00Z alert.resolved: alert 5xx ratio resolved",
+		"2026-08-12T15:50:00Z alert.fired: alert 9WmPx4KtRb2NcLq7VzHd3F fired",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected timeline (-want +got):\n%s", diff)
+	}
+
+	var correlations []string
+	for _, c := range timeline.Correlate(time.Hour) {
+		correlations = append(correlations, c.String())
+	}
+	wantCorrelations := []string{
+		"alert 5xx ratio fired 3m0s after version 412 activated by 4tKBSuFhNEiIpNDxmmVydt",
+		"alert 9WmPx4KtRb2NcLq7VzHd3F fired 30m0s after Purged all by 1tSCjJnpQYBBCAsIfI6pX0",
+	}
+	if diff := cmp.Diff(wantCorrelations, correlations); diff != "" {
+		t.Errorf("unexpected correlations (-want +got):\n%s", diff)
+	}
+	if n := len(timeline.Correlate(5 * time.Minute)); n != 1 {
+		t.Errorf("got %d correlations within 5m, want 1", n)
+	}
+}
+
+func TestGetServiceTimeline_inferredActivation(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	var (
+		timeline ServiceTimeline
+		err      error
+	)
+	// No account event covers the activation of the active version 7.
+	Record(t, "service_timeline/inferred_activation", func(c *Client) {
+		timeline, err = c.GetServiceTimeline(context.TODO(), &GetServiceTimelineInput{From: base, ServiceID: "7i6HN3TK9wS159v2gPAZ8A", To: base.Add(time.Hour)})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timeline) != 1 || timeline[0].Kind != TimelineVersionActivated || *timeline[0].Version != 7 {
+		t.Errorf("unexpected timeline: %v", timeline)
+	}
+}
+
+func TestGetServiceTimeline_validation(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 8, 12, 15, 0, 0, 0, time.UTC)
+	cases := []struct {
+		input *GetServiceTimelineInput
+		want  error
+	}{
+		{input: &GetServiceTimelineInput{From: base}, want: ErrMissingServiceID},
+		{input: &GetServiceTimelineInput{ServiceID: "svc"}, want: ErrMissingFrom},
+		{input: &GetServiceTimelineInput{From: base, ServiceID: "svc", To: base}, want: ErrInvalidTimelineRange},
+	}
+	for _, tc := range cases {
+		if _, err := TestClient.GetServiceTimeline(context.TODO(), tc.input); !errors.Is(err, tc.want) {
+			t.Errorf("got %v, want %v", err, tc.want)
+		}
+	}
+}
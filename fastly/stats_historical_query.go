@@ -0,0 +1,280 @@
+package fastly
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Sample window durations accepted by the historical stats API.
+const (
+	StatsByDay    = "day"
+	StatsByHour   = "hour"
+	StatsByMinute = "minute"
+)
+
+// DefaultStatsQueryMaxRequests is the default maximum number of requests a
+// single QueryStats call may split its range into.
+const DefaultStatsQueryMaxRequests = 50
+
+// statsQueryGranularity describes the sample window of a By value.
+type statsQueryGranularity struct {
+	// sample is the duration of one sample.
+	sample time.Duration
+	// span is the duration covered by each request.
+	span time.Duration
+}
+
+// statsQueryGranularities maps the accepted By values to their sample
+// windows. Spans are chosen to keep each response to a few hundred samples.
+var statsQueryGranularities = map[string]statsQueryGranularity{
+	StatsByDay:    {sample: 24 * time.Hour, span: 365 * 24 * time.Hour},
+	StatsByHour:   {sample: time.Hour, span: 7 * 24 * time.Hour},
+	StatsByMinute: {sample: time.Minute, span: 6 * time.Hour},
+}
+
+// StatsPoint is the stats of a service for one sample window.
+type StatsPoint struct {
+	// Start is the start of the sample window.
+	Start time.Time
+	// Stats holds the measurements for the sample window.
+	Stats *Stats
+}
+
+// StatsSeries is a series of stats samples ordered by start time.
+type StatsSeries []StatsPoint
+
+// StatsValue is a single value of a series for one sample window.
+type StatsValue struct {
+	// Start is the start of the sample window.
+	Start time.Time
+	// Value is the value for the sample window.
+	Value float64
+}
+
+// QueryStatsInput is used as input to the QueryStats function.
+type QueryStatsInput struct {
+	// By is the duration of sample windows: StatsByMinute, StatsByHour or
+	// StatsByDay (required).
+	By string
+	// Field limits the response to a single stats field.
+	Field *string
+	// From is the start of the range. If zero, the range starts Last before
+	// To.
+	From time.Time
+	// Last is the length of the range ending at To, used when From is zero.
+	Last time.Duration
+	// MaxRequests is the maximum number of requests the range may be split
+	// into (default: DefaultStatsQueryMaxRequests).
+	MaxRequests *int
+	// Region limits the query to a specific geographic region.
+	Region *string
+	// Service limits the query to a single service. All services are queried
+	// if nil.
+	Service *string
+	// To is the end of the range (default: now).
+	To time.Time
+}
+
+// QueryStats retrieves historical stats over a time range, keyed by service
+// ID.
+//
+// The range is aligned to whole sample windows and split into as many
+// requests as needed, whose results are merged into a single series per
+// service ordered by start time. An error is returned if the range would need
+// more than MaxRequests requests, in which case a coarser By should be used.
+func (c *Client) QueryStats(ctx context.Context, i *QueryStatsInput) (map[string]StatsSeries, error) {
+	requests, err := splitStatsQuery(i)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]map[int64]*Stats)
+	for _, gi := range requests {
+		data, err := c.getStatsByService(ctx, gi)
+		if err != nil {
+			return nil, err
+		}
+		for serviceID, stats := range data {
+			points, ok := merged[serviceID]
+			if !ok {
+				points = make(map[int64]*Stats)
+				merged[serviceID] = points
+			}
+			for _, s := range stats {
+				if s == nil {
+					continue
+				}
+				// #nosec G115 -- start times are Unix seconds, far below math.MaxInt64
+				points[int64(ToValue(s.StartTime))] = s
+			}
+		}
+	}
+
+	result := make(map[string]StatsSeries, len(merged))
+	for serviceID, points := range merged {
+		series := make(StatsSeries, 0, len(points))
+		for start, s := range points {
+			series = append(series, StatsPoint{Start: time.Unix(start, 0).UTC(), Stats: s})
+		}
+		sort.Slice(series, func(a, b int) bool { return series[a].Start.Before(series[b].Start) })
+		result[serviceID] = series
+	}
+	return result, nil
+}
+
+// getStatsByService retrieves the stats of one request, keyed by service ID.
+func (c *Client) getStatsByService(ctx context.Context, i *GetStatsInput) (map[string][]*Stats, error) {
+	if i.Service != nil {
+		resp, err := c.GetStats(ctx, i)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]*Stats{*i.Service: resp.Data}, nil
+	}
+	resp, err := c.GetStatsField(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// splitStatsQuery validates a query and splits it into API requests.
+func splitStatsQuery(i *QueryStatsInput) ([]*GetStatsInput, error) {
+	g, ok := statsQueryGranularities[i.By]
+	if !ok {
+		return nil, ErrInvalidStatsBy
+	}
+
+	to := i.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := i.From
+	if from.IsZero() {
+		if i.Last <= 0 {
+			return nil, ErrMissingFrom
+		}
+		from = to.Add(-i.Last)
+	}
+
+	// Align the range to whole sample windows, in UTC.
+	from = from.UTC().Truncate(g.sample)
+	if aligned := to.UTC().Truncate(g.sample); aligned.Before(to) {
+		to = aligned.Add(g.sample)
+	} else {
+		to = aligned
+	}
+	if !from.Before(to) {
+		return nil, ErrInvalidStatsRange
+	}
+
+	maxRequests := DefaultStatsQueryMaxRequests
+	if i.MaxRequests != nil {
+		maxRequests = *i.MaxRequests
+	}
+	n := int((to.Sub(from) + g.span - 1) / g.span)
+	if n > maxRequests {
+		return nil, fmt.Errorf("%w: %d requests needed by %s, at most %d allowed", ErrStatsRangeTooLong, n, i.By, maxRequests)
+	}
+
+	requests := make([]*GetStatsInput, 0, n)
+	for start := from; start.Before(to); start = start.Add(g.span) {
+		end := start.Add(g.span)
+		if end.After(to) {
+			end = to
+		}
+		requests = append(requests, &GetStatsInput{
+			By:      ToPointer(i.By),
+			Field:   i.Field,
+			From:    ToPointer(strconv.FormatInt(start.Unix(), 10)),
+			Region:  i.Region,
+			Service: i.Service,
+			To:      ToPointer(strconv.FormatInt(end.Unix(), 10)),
+		})
+	}
+	return requests, nil
+}
+
+// Select returns the selected value of each sample.
+func (s StatsSeries) Select(f StatsSelector) []StatsValue {
+	values := make([]StatsValue, 0, len(s))
+	for _, p := range s {
+		if p.Stats != nil {
+			values = append(values, StatsValue{Start: p.Start, Value: f(p.Stats)})
+		}
+	}
+	return values
+}
+
+// Total returns the sum of the selected value over the whole series.
+func (s StatsSeries) Total(f StatsSelector) float64 {
+	var total float64
+	for _, p := range s {
+		if p.Stats != nil {
+			total += f(p.Stats)
+		}
+	}
+	return total
+}
+
+// SumStatsSeries adds the selected value of several series sample by sample,
+// for example to combine services or regions. Samples are aligned by start
+// time and a sample missing from a series counts as zero.
+func SumStatsSeries(f StatsSelector, series ...StatsSeries) []StatsValue {
+	sums := make(map[int64]float64)
+	for _, s := range series {
+		for _, v := range s.Select(f) {
+			sums[v.Start.Unix()] += v.Value
+		}
+	}
+	return sortedStatsValues(sums)
+}
+
+// DiffStatsValues subtracts b from a sample by sample. Samples are aligned by
+// start time and a sample missing from either side counts as zero.
+func DiffStatsValues(a, b []StatsValue) []StatsValue {
+	diffs := make(map[int64]float64, len(a))
+	for _, v := range a {
+		diffs[v.Start.Unix()] += v.Value
+	}
+	for _, v := range b {
+		diffs[v.Start.Unix()] -= v.Value
+	}
+	return sortedStatsValues(diffs)
+}
+
+// RatioStatsValues divides numerator by denominator sample by sample, such as
+// errors by requests. Samples are aligned by start time; samples whose
+// denominator is missing or zero are omitted.
+func RatioStatsValues(numerator, denominator []StatsValue) []StatsValue {
+	den := make(map[int64]float64, len(denominator))
+	for _, v := range denominator {
+		den[v.Start.Unix()] += v.Value
+	}
+	num := make(map[int64]float64, len(numerator))
+	for _, v := range numerator {
+		num[v.Start.Unix()] += v.Value
+	}
+
+	ratios := make(map[int64]float64, len(den))
+	for start, d := range den {
+		if d != 0 {
+			ratios[start] = num[start] / d
+		}
+	}
+	return sortedStatsValues(ratios)
+}
+
+// sortedStatsValues converts values keyed by Unix start time to a sorted
+// slice.
+func sortedStatsValues(m map[int64]float64) []StatsValue {
+	values := make([]StatsValue, 0, len(m))
+	for start, v := range m {
+		values = append(values, StatsValue{Start: time.Unix(start, 0).UTC(), Value: v})
+	}
+	sort.Slice(values, func(a, b int) bool { return values[a].Start.Before(values[b].Start) })
+	return values
+}
@@ -0,0 +1,162 @@
+package fastly
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitStatsQuery(t *testing.T) {
+	t.Parallel()
+
+	type window struct{ From, To string }
+	windows := func(requests []*GetStatsInput) []window {
+		var w []window
+		for _, r := range requests {
+			w = append(w, window{From: *r.From, To: *r.To})
+		}
+		return w
+	}
+	unix := func(s string) string {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strconv.FormatInt(ts.Unix(), 10)
+	}
+
+	cases := []struct {
+		name  string
+		input *QueryStatsInput
+		want  []window
+		err   error
+	}{
+		{
+			name:  "last day by hour is aligned to hours",
+			input: &QueryStatsInput{By: StatsByHour, Last: 24 * time.Hour, To: time.Date(2026, 3, 10, 12, 30, 15, 0, time.UTC)},
+			want: []window{
+				{From: unix("2026-03-09T12:00:00Z"), To: unix("2026-03-10T13:00:00Z")},
+			},
+		},
+		{
+			name: "long minute range is split",
+			input: &QueryStatsInput{
+				By:   StatsByMinute,
+				From: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2026, 3, 9, 13, 0, 0, 0, time.UTC),
+			},
+			want: []window{
+				{From: unix("2026-03-09T00:00:00Z"), To: unix("2026-03-09T06:00:00Z")},
+				{From: unix("2026-03-09T06:00:00Z"), To: unix("2026-03-09T12:00:00Z")},
+				{From: unix("2026-03-09T12:00:00Z"), To: unix("2026-03-09T13:00:00Z")},
+			},
+		},
+		{
+			name:  "unsupported by",
+			input: &QueryStatsInput{By: "second", Last: time.Hour},
+			err:   ErrInvalidStatsBy,
+		},
+		{
+			name:  "missing from",
+			input: &QueryStatsInput{By: StatsByDay},
+			err:   ErrMissingFrom,
+		},
+		{
+			name: "empty range",
+			input: &QueryStatsInput{
+				By:   StatsByDay,
+				From: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC),
+			},
+			err: ErrInvalidStatsRange,
+		},
+		{
+			name:  "range too long for minute samples",
+			input: &QueryStatsInput{By: StatsByMinute, Last: 90 * 24 * time.Hour},
+			err:   ErrStatsRangeTooLong,
+		},
+		{
+			name:  "max requests",
+			input: &QueryStatsInput{By: StatsByMinute, Last: 13 * time.Hour, MaxRequests: ToPointer(2)},
+			err:   ErrStatsRangeTooLong,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := splitStatsQuery(c.input)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("got error %v, want %v", err, c.err)
+			}
+			if diff := cmp.Diff(c.want, windows(got)); diff != "" {
+				t.Errorf("unexpected windows (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestQueryStats(t *testing.T) {
+	t.Parallel()
+
+	var (
+		result map[string]StatsSeries
+		err    error
+	)
+	// The range needs two requests, and the boundary sample is returned by
+	// both.
+	Record(t, "stats/query", func(c *Client) {
+		result, err = c.QueryStats(context.TODO(), &QueryStatsInput{
+			By:   StatsByHour,
+			From: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2026, 9, 9, 0, 0, 0, 0, time.UTC),
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		a = "SU1Z0isxPaozGVKXdv0eY"
+		b = "7i6HN3TK9wS159v2gPAZ8A"
+		// o is the start of the last sample of the first request.
+		o = 1788822000
+	)
+	starts := func(s StatsSeries) []int64 {
+		var u []int64
+		for _, p := range s {
+			u = append(u, p.Start.Unix())
+		}
+		return u
+	}
+	if diff := cmp.Diff([]int64{o, o + 3600, o + 7200}, starts(result[a])); diff != "" {
+		t.Errorf("unexpected series a (-want +got):\n%s", diff)
+	}
+	if got := result[a].Total(selectRequests); got != 300 {
+		t.Errorf("Total: got %v, want 300", got)
+	}
+
+	value := func(start int64, v float64) StatsValue { return StatsValue{Start: time.Unix(start, 0).UTC(), Value: v} }
+
+	requests := SumStatsSeries(selectRequests, result[a], result[b])
+	if diff := cmp.Diff([]StatsValue{value(o, 150), value(o+3600, 100), value(o+7200, 150)}, requests); diff != "" {
+		t.Errorf("unexpected sum (-want +got):\n%s", diff)
+	}
+
+	errs := SumStatsSeries(select5xx, result[a], result[b])
+	ratios := RatioStatsValues(errs, requests)
+	if diff := cmp.Diff([]StatsValue{value(o, 0.0133), value(o+3600, 0.01), value(o+7200, 0.0667)}, ratios, cmp.Comparer(func(x, y float64) bool {
+		return x-y < 0.0001 && y-x < 0.0001
+	})); diff != "" {
+		t.Errorf("unexpected ratio (-want +got):\n%s", diff)
+	}
+
+	diff := DiffStatsValues(result[a].Select(selectRequests), result[b].Select(selectRequests))
+	if d := cmp.Diff([]StatsValue{value(o, 50), value(o+3600, 100), value(o+7200, 50)}, diff); d != "" {
+		t.Errorf("unexpected diff (-want +got):\n%s", d)
+	}
+}
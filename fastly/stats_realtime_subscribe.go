@@ -0,0 +1,334 @@
+package fastly
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fastly/go-fastly/v17/internal/ctxutil"
+)
+
+const (
+	// DefaultSubscribeConcurrency is the default maximum number of realtime
+	// stats requests SubscribeMany has in flight at once.
+	DefaultSubscribeConcurrency = 10
+	// DefaultSubscribeMaxBackoff is the default maximum delay between retries
+	// of a failed realtime stats request.
+	DefaultSubscribeMaxBackoff = 30 * time.Second
+	// DefaultSubscribeMinBackoff is the default delay before the first retry of
+	// a failed realtime stats request.
+	DefaultSubscribeMinBackoff = time.Second
+	// DefaultSubscribePollInterval is the default delay before polling again
+	// when a realtime stats request returned no new data.
+	DefaultSubscribePollInterval = time.Second
+)
+
+// SubscribeOptions configures a realtime stats subscription. The zero value
+// uses the defaults.
+type SubscribeOptions struct {
+	// Concurrency is the maximum number of requests SubscribeMany has in
+	// flight at once, across all services (default:
+	// DefaultSubscribeConcurrency).
+	Concurrency int
+	// Limit is passed through to each GetRealtimeStats request.
+	Limit *uint32
+	// MaxBackoff is the maximum delay between retries of a failed request
+	// (default: DefaultSubscribeMaxBackoff).
+	MaxBackoff time.Duration
+	// MinBackoff is the delay before the first retry of a failed request. It
+	// doubles after each consecutive failure (default:
+	// DefaultSubscribeMinBackoff).
+	MinBackoff time.Duration
+	// PollInterval is the delay before polling again when a request returned
+	// no new data (default: DefaultSubscribePollInterval).
+	PollInterval time.Duration
+	// Timestamp is the cursor the subscription starts from. The zero value
+	// starts from the most recent data.
+	Timestamp uint64
+}
+
+// ServiceRealtimeData is a record delivered by SubscribeMany.
+type ServiceRealtimeData struct {
+	// Data is one second of realtime stats.
+	Data *RealtimeData
+	// ServiceID is the ID of the service the record belongs to.
+	ServiceID string
+}
+
+// RealtimeStatsError is the error delivered by a realtime stats subscription
+// when a request fails.
+type RealtimeStatsError struct {
+	// Err is the underlying error.
+	Err error
+	// ServiceID is the ID of the service whose request failed.
+	ServiceID string
+}
+
+// Error implements the error interface.
+func (e *RealtimeStatsError) Error() string {
+	return fmt.Sprintf("realtime stats for service %s: %s", e.ServiceID, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RealtimeStatsError) Unwrap() error {
+	return e.Err
+}
+
+// Subscribe streams the realtime stats of a service, one record per second.
+//
+// The subscription threads the Timestamp cursor through successive requests,
+// drops records for seconds that were already delivered and retries failed
+// requests with exponential backoff. Seconds more recent than the
+// AggregateDelay of the response may still be incomplete: they are held
+// back, replaced if a later response includes them again, and delivered once
+// the delay has passed. Failures are reported on the error
+// channel as *RealtimeStatsError values; a failure that is not retryable, such
+// as a 401 or 404 response, ends the subscription.
+//
+// Both channels are closed when the subscription ends, which happens at the
+// latest when ctx is cancelled. Callers must receive from both channels until
+// they are closed.
+func (c *RTSClient) Subscribe(ctx context.Context, serviceID string, opts *SubscribeOptions) (<-chan *RealtimeData, <-chan error) {
+	data := make(chan *RealtimeData)
+	errs := make(chan error)
+
+	s := newRealtimeSubscription(c, opts, nil)
+	go func() {
+		defer close(data)
+		defer close(errs)
+		s.run(ctx, serviceID,
+			func(d *RealtimeData) bool { return ctxutil.Send(ctx, data, d) },
+			func(err error) bool { return ctxutil.Send(ctx, errs, err) },
+		)
+	}()
+
+	return data, errs
+}
+
+// SubscribeMany streams the realtime stats of several services over a single
+// pair of channels. Each service is subscribed to as with Subscribe, but at
+// most opts.Concurrency requests are in flight at once across all of them.
+//
+// Both channels are closed once every subscription has ended. Callers must
+// receive from both channels until they are closed.
+func (c *RTSClient) SubscribeMany(ctx context.Context, serviceIDs []string, opts *SubscribeOptions) (<-chan *ServiceRealtimeData, <-chan error) {
+	data := make(chan *ServiceRealtimeData)
+	errs := make(chan error)
+
+	concurrency := DefaultSubscribeConcurrency
+	if opts != nil && opts.Concurrency > 0 {
+		concurrency = opts.Concurrency
+	}
+	s := newRealtimeSubscription(c, opts, make(chan struct{}, concurrency))
+
+	var wg sync.WaitGroup
+	for _, id := range serviceIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.run(ctx, id,
+				func(d *RealtimeData) bool {
+					return ctxutil.Send(ctx, data, &ServiceRealtimeData{Data: d, ServiceID: id})
+				},
+				func(err error) bool { return ctxutil.Send(ctx, errs, err) },
+			)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(data)
+		close(errs)
+	}()
+
+	return data, errs
+}
+
+// realtimeSubscription holds the configuration shared by the services of a
+// subscription.
+type realtimeSubscription struct {
+	c    *RTSClient
+	opts SubscribeOptions
+	// sem bounds the number of requests in flight, if not nil.
+	sem chan struct{}
+}
+
+// newRealtimeSubscription returns a realtimeSubscription with the defaults
+// applied to opts.
+func newRealtimeSubscription(c *RTSClient, opts *SubscribeOptions, sem chan struct{}) *realtimeSubscription {
+	s := &realtimeSubscription{c: c, sem: sem}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.MinBackoff <= 0 {
+		s.opts.MinBackoff = DefaultSubscribeMinBackoff
+	}
+	if s.opts.MaxBackoff <= 0 {
+		s.opts.MaxBackoff = DefaultSubscribeMaxBackoff
+	}
+	if s.opts.MaxBackoff < s.opts.MinBackoff {
+		s.opts.MaxBackoff = s.opts.MinBackoff
+	}
+	if s.opts.PollInterval <= 0 {
+		s.opts.PollInterval = DefaultSubscribePollInterval
+	}
+	return s
+}
+
+// run polls the realtime stats of a service until ctx is cancelled, a
+// non-retryable error occurs, or emit or report returns false.
+func (s *realtimeSubscription) run(ctx context.Context, serviceID string, emit func(*RealtimeData) bool, report func(error) bool) {
+	var (
+		cursor   = s.opts.Timestamp
+		held     realtimeHeld
+		latest   uint64
+		backoff  time.Duration
+		idleWait time.Duration
+	)
+	// release delivers the held records whose aggregation delay has passed,
+	// reporting whether any was delivered, and false as its second result if
+	// the subscription should end.
+	release := func() (bool, bool) {
+		delivered := false
+		for _, d := range held.due(time.Now()) {
+			latest = ToValue(d.Recorded)
+			if !emit(d) {
+				return delivered, false
+			}
+			delivered = true
+		}
+		return delivered, true
+	}
+
+	for {
+		wait := idleWait
+		if backoff > 0 {
+			wait = backoff
+		}
+		if wait > 0 && !ctxutil.Sleep(ctx, wait) {
+			return
+		}
+		if _, ok := release(); !ok {
+			return
+		}
+
+		resp, err := s.get(ctx, serviceID, cursor)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil && resp == nil {
+			resp = &RealtimeStatsResponse{}
+		}
+		if err == nil && ToValue(resp.Error) != "" {
+			err = errors.New(*resp.Error)
+		}
+		if err != nil {
+			if !report(&RealtimeStatsError{Err: err, ServiceID: serviceID}) || !isRetryableRealtimeStatsError(err) {
+				return
+			}
+			backoff = min(max(2*backoff, s.opts.MinBackoff), s.opts.MaxBackoff)
+			continue
+		}
+		backoff = 0
+
+		held.delay = time.Duration(ToValue(resp.AggregateDelay)) * time.Second
+		delivered := false
+		for _, d := range resp.Data {
+			if d == nil {
+				continue
+			}
+			recorded := ToValue(d.Recorded)
+			if recorded == 0 {
+				if !emit(d) {
+					return
+				}
+				delivered = true
+				continue
+			}
+			if recorded > latest {
+				held.add(d)
+			}
+		}
+		released, ok := release()
+		if !ok {
+			return
+		}
+		delivered = delivered || released
+
+		next := ToValue(resp.Timestamp)
+		idleWait = 0
+		if !delivered && (next == 0 || next == cursor) {
+			idleWait = s.opts.PollInterval
+		}
+		if next != 0 {
+			cursor = next
+		}
+	}
+}
+
+// realtimeHeld holds the records of the seconds still within the aggregation
+// delay, in order.
+type realtimeHeld struct {
+	delay   time.Duration
+	records []*RealtimeData
+}
+
+// add holds d, replacing the record of the same second if there is one.
+func (h *realtimeHeld) add(d *RealtimeData) {
+	recorded := ToValue(d.Recorded)
+	n, found := slices.BinarySearchFunc(h.records, recorded, func(r *RealtimeData, t uint64) int {
+		return cmp.Compare(ToValue(r.Recorded), t)
+	})
+	if found {
+		h.records[n] = d
+		return
+	}
+	h.records = slices.Insert(h.records, n, d)
+}
+
+// due removes and returns the records whose second ended at least the
+// aggregation delay before now.
+func (h *realtimeHeld) due(now time.Time) []*RealtimeData {
+	n := 0
+	for n < len(h.records) && !time.Unix(int64(ToValue(h.records[n].Recorded))+1, 0).Add(h.delay).After(now) {
+		n++
+	}
+	due := h.records[:n:n]
+	h.records = h.records[n:]
+	return due
+}
+
+// get performs a single request, waiting for a slot if the subscription is
+// bounded.
+func (s *realtimeSubscription) get(ctx context.Context, serviceID string, cursor uint64) (*RealtimeStatsResponse, error) {
+	if s.sem != nil {
+		select {
+		case s.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-s.sem }()
+	}
+	return s.c.GetRealtimeStats(ctx, &GetRealtimeStatsInput{
+		Limit:     s.opts.Limit,
+		ServiceID: serviceID,
+		Timestamp: cursor,
+	})
+}
+
+// isRetryableRealtimeStatsError reports whether a subscription should keep
+// polling after err. Only HTTP errors that indicate a problem with the
+// request itself, such as a missing service or invalid token, are final.
+func isRetryableRealtimeStatsError(err error) bool {
+	var herr *HTTPError
+	if errors.Is(err, ErrMissingServiceID) {
+		return false
+	}
+	if errors.As(err, &herr) {
+		return herr.IsRetryable()
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package fastly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fastSubscribeOptions keeps subscription delays short in tests.
+var fastSubscribeOptions = &SubscribeOptions{
+	MaxBackoff:   4 * time.Millisecond,
+	MinBackoff:   time.Millisecond,
+	PollInterval: time.Millisecond,
+}
+
+func realtimeRecords(recorded ...uint64) []*RealtimeData {
+	data := make([]*RealtimeData, len(recorded))
+	for n, r := range recorded {
+		data[n] = &RealtimeData{Recorded: ToPointer(r)}
+	}
+	return data
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+
+	var (
+		recorded []uint64
+		statuses []string
+	)
+	RecordRealtimeStats(t, "realtime_stats/subscribe", func(c *RTSClient) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		data, errs := c.Subscribe(ctx, "kKJb5bOFI47uHeBVluGfX1", fastSubscribeOptions)
+		for data != nil || errs != nil {
+			select {
+			case d, ok := <-data:
+				if !ok {
+					data = nil
+					continue
+				}
+				recorded = append(recorded, ToValue(d.Recorded))
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				var rerr *RealtimeStatsError
+				if !errors.As(err, &rerr) || rerr.ServiceID != "kKJb5bOFI47uHeBVluGfX1" {
+					t.Fatalf("unexpected error %v", err)
+				}
+				statuses = append(statuses, rerr.Err.Error())
+			}
+		}
+	})
+
+	// Overlapping seconds are dropped.
+	want := []uint64{1792324791, 1792324792, 1792324793, 1792324794}
+	if diff := cmp.Diff(want, recorded); diff != "" {
+		t.Errorf("unexpected records (-want +got):\n%s", diff)
+	}
+	if len(statuses) != 3 {
+		t.Errorf("got %d errors, want 3: %v", len(statuses), statuses)
+	}
+}
+
+func TestSubscribe_cancel(t *testing.T) {
+	t.Parallel()
+
+	RecordRealtimeStats(t, "realtime_stats/subscribe_cancel", func(c *RTSClient) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		data, errs := c.Subscribe(ctx, "kKJb5bOFI47uHeBVluGfX1", fastSubscribeOptions)
+
+		if _, ok := <-data; ok {
+			t.Error("data channel not closed")
+		}
+		if _, ok := <-errs; ok {
+			t.Error("error channel not closed")
+		}
+	})
+}
+
+// inFlight counts the requests in flight through it and records the peak.
+type inFlight struct {
+	next    http.RoundTripper
+	n, peak atomic.Int32
+}
+
+func (f *inFlight) RoundTrip(r *http.Request) (*http.Response, error) {
+	n := f.n.Add(1)
+	defer f.n.Add(-1)
+	for {
+		p := f.peak.Load()
+		if n <= p || f.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return f.next.RoundTrip(r)
+}
+
+func TestSubscribeMany(t *testing.T) {
+	t.Parallel()
+
+	const concurrency = 3
+	ids := []string{
+		"0Vwv2ZGNHSkhrqkOEYtU32", "1FCTPxBvMnTL49Ijd3Ub14", "2ZUdP5rTwdoV0VqkEXD7B6", "3hUDdlyzNTVmkDuJBi8R18",
+		"4WsJT9xOOWSaRXJ5VcRdo0", "5kL0sbC7qjQlzTjiVwmYV2", "6tnzUQ79vdBfnjWpmY0XX4", "7Gx1pINjD7xp7JsBZG6Iq6",
+	}
+	got := make(map[string]int)
+	failed := make(map[string]int)
+	transport := &inFlight{}
+	RecordRealtimeStats(t, "realtime_stats/subscribe_many", func(c *RTSClient) {
+		transport.next = c.client.HTTPClient.Transport
+		c.client.HTTPClient.Transport = transport
+
+		opts := *fastSubscribeOptions
+		opts.Concurrency = concurrency
+		data, errs := c.SubscribeMany(context.Background(), ids, &opts)
+		for data != nil || errs != nil {
+			select {
+			case d, ok := <-data:
+				if !ok {
+					data = nil
+					continue
+				}
+				got[d.ServiceID]++
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				var rerr *RealtimeStatsError
+				if !errors.As(err, &rerr) {
+					t.Fatalf("unexpected error %v", err)
+				}
+				failed[rerr.ServiceID]++
+			}
+		}
+	})
+
+	for _, id := range ids {
+		if got[id] != 1 || failed[id] != 1 {
+			t.Errorf("service %s: got %d records and %d errors, want 1 and 1", id, got[id], failed[id])
+		}
+	}
+	if p := transport.peak.Load(); p > concurrency {
+		t.Errorf("peak concurrency %d exceeds %d", p, concurrency)
+	}
+}
+
+func TestRealtimeHeld(t *testing.T) {
+	t.Parallel()
+
+	h := realtimeHeld{delay: 2 * time.Second}
+	for _, d := range realtimeRecords(101, 99, 100) {
+		h.add(d)
+	}
+	due := func(now int64) []uint64 {
+		var recorded []uint64
+		for _, d := range h.due(time.Unix(now, 0)) {
+			recorded = append(recorded, ToValue(d.Recorded))
+		}
+		return recorded
+	}
+
+	if diff := cmp.Diff([]uint64{99}, due(102)); diff != "" {
+		t.Errorf("unexpected records at 102 (-want +got):\n%s", diff)
+	}
+	// Second 101 is still within the delay and is replaced.
+	revised := realtimeRecords(101, 102)
+	for _, d := range revised {
+		h.add(d)
+	}
+	if diff := cmp.Diff([]uint64{100}, due(103)); diff != "" {
+		t.Errorf("unexpected records at 103 (-want +got):\n%s", diff)
+	}
+	if len(h.records) != 2 || h.records[0] != revised[0] {
+		t.Error("second 101 was not replaced")
+	}
+	if diff := cmp.Diff([]uint64{101, 102}, due(106)); diff != "" {
+		t.Errorf("unexpected records at 106 (-want +got):\n%s", diff)
+	}
+	if got := due(107); len(got) != 0 {
+		t.Errorf("unexpected records at 107: %v", got)
+	}
+}
@@ -0,0 +1,378 @@
+package fastly
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StatsSelector extracts a single value from a Stats record.
+type StatsSelector func(*Stats) float64
+
+// HistogramSelector extracts a latency histogram, such as MissHistogram, from
+// a Stats record. Histogram keys are bucket lower bounds in milliseconds.
+type HistogramSelector func(*Stats) map[int]int
+
+// StatsField returns a StatsSelector for the numeric Stats field with the
+// given mapstructure tag, such as "status_5xx" or "requests". Missing values
+// are read as zero.
+func StatsField(name string) (StatsSelector, error) {
+	t := reflect.TypeFor[Stats]()
+	for n := range t.NumField() {
+		f := t.Field(n)
+		if f.Tag.Get("mapstructure") != name {
+			continue
+		}
+		switch f.Type {
+		case reflect.TypeFor[*uint64]():
+			return func(s *Stats) float64 {
+				return float64(ToValue(reflect.ValueOf(s).Elem().Field(n).Interface().(*uint64)))
+			}, nil
+		case reflect.TypeFor[*float64]():
+			return func(s *Stats) float64 {
+				return ToValue(reflect.ValueOf(s).Elem().Field(n).Interface().(*float64))
+			}, nil
+		}
+		return nil, fmt.Errorf("stats field %q is not numeric", name)
+	}
+	return nil, fmt.Errorf("unknown stats field %q", name)
+}
+
+// RealtimeWindow is an in-memory ring buffer holding the most recent seconds
+// of aggregated realtime stats for a service, with rolling-window queries
+// over them.
+//
+// Windows are measured back from the most recent second added rather than
+// from the wall clock, so results depend only on the data. A RealtimeWindow
+// is safe for concurrent use.
+type RealtimeWindow struct {
+	mu     sync.RWMutex
+	latest uint64
+	slots  []windowSlot
+}
+
+// windowSlot holds the stats recorded for one second.
+type windowSlot struct {
+	recorded uint64
+	stats    *Stats
+}
+
+// NewRealtimeWindow returns a RealtimeWindow that retains capacity worth of
+// per-second records. Capacity is rounded up to a whole number of seconds.
+func NewRealtimeWindow(capacity time.Duration) *RealtimeWindow {
+	return &RealtimeWindow{slots: make([]windowSlot, max(windowSeconds(capacity), 1))}
+}
+
+// Capacity returns the length of time the window retains.
+func (w *RealtimeWindow) Capacity() time.Duration {
+	return time.Duration(len(w.slots)) * time.Second
+}
+
+// Add records the aggregated stats of a realtime record. Records without a
+// timestamp, or older than the retained window, are ignored. A record for a
+// second that is already held replaces it.
+func (w *RealtimeWindow) Add(d *RealtimeData) {
+	if d == nil || d.Aggregated == nil {
+		return
+	}
+	recorded := ToValue(d.Recorded)
+	if recorded == 0 {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	size := uint64(len(w.slots))
+	if w.latest >= size && recorded <= w.latest-size {
+		return
+	}
+	w.slots[recorded%size] = windowSlot{recorded: recorded, stats: d.Aggregated}
+	w.latest = max(w.latest, recorded)
+}
+
+// AddResponse records every record of a realtime stats response.
+func (w *RealtimeWindow) AddResponse(r *RealtimeStatsResponse) {
+	if r == nil {
+		return
+	}
+	for _, d := range r.Data {
+		w.Add(d)
+	}
+}
+
+// Latest returns the time of the most recent second added, or the zero time
+// if the window is empty.
+func (w *RealtimeWindow) Latest() time.Time {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.latest == 0 {
+		return time.Time{}
+	}
+	// #nosec G115 -- realtime timestamps are Unix seconds, far below math.MaxInt64
+	return time.Unix(int64(w.latest), 0)
+}
+
+// Len returns the number of seconds held within window of the most recent
+// second.
+func (w *RealtimeWindow) Len(window time.Duration) int {
+	n := 0
+	w.each(window, func(*Stats) { n++ })
+	return n
+}
+
+// Sum returns the sum of the selected value over the seconds within window of
+// the most recent second.
+func (w *RealtimeWindow) Sum(window time.Duration, f StatsSelector) float64 {
+	var sum float64
+	w.each(window, func(s *Stats) { sum += f(s) })
+	return sum
+}
+
+// Rate returns the per-second rate of the selected value over window.
+func (w *RealtimeWindow) Rate(window time.Duration, f StatsSelector) float64 {
+	seconds := windowSeconds(window)
+	if seconds == 0 {
+		return 0
+	}
+	return w.Sum(window, f) / float64(seconds)
+}
+
+// Ratio returns the ratio of the sums of two selected values over window. The
+// boolean is false if the denominator is zero.
+func (w *RealtimeWindow) Ratio(window time.Duration, numerator, denominator StatsSelector) (float64, bool) {
+	var num, den float64
+	w.each(window, func(s *Stats) {
+		num += numerator(s)
+		den += denominator(s)
+	})
+	if den == 0 {
+		return 0, false
+	}
+	return num / den, true
+}
+
+// Quantile estimates the q-quantile, for q between 0 and 1, of a latency
+// histogram merged over window. Values are interpolated linearly within
+// buckets. The boolean is false if the histogram holds no observations.
+func (w *RealtimeWindow) Quantile(window time.Duration, q float64, f HistogramSelector) (time.Duration, bool) {
+	merged := make(map[int]int)
+	w.each(window, func(s *Stats) {
+		for k, c := range f(s) {
+			if c > 0 {
+				merged[k] += c
+			}
+		}
+	})
+	return histogramQuantile(merged, q)
+}
+
+// each calls fn with the stats of every second within window of the most
+// recent second.
+func (w *RealtimeWindow) each(window time.Duration, fn func(*Stats)) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	// #nosec G115 -- both operands are non-negative
+	seconds := uint64(min(windowSeconds(window), len(w.slots)))
+	for _, slot := range w.slots {
+		if slot.stats != nil && slot.recorded+seconds > w.latest {
+			fn(slot.stats)
+		}
+	}
+}
+
+// windowSeconds converts a window to a whole number of seconds, rounding up.
+func windowSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// histogramQuantile estimates the q-quantile of a histogram keyed by bucket
+// lower bounds in milliseconds. Each bucket is taken to extend to the next
+// key; the last bucket has no known upper bound, so quantiles falling in it
+// are reported as its lower bound.
+func histogramQuantile(h map[int]int, q float64) (time.Duration, bool) {
+	bounds := make([]int, 0, len(h))
+	total := 0
+	for b, c := range h {
+		bounds = append(bounds, b)
+		total += c
+	}
+	if total == 0 {
+		return 0, false
+	}
+	sort.Ints(bounds)
+	q = min(max(q, 0), 1)
+
+	rank := q * float64(total)
+	seen := 0
+	for n, b := range bounds[:len(bounds)-1] {
+		c := h[b]
+		if c > 0 && float64(seen+c) >= rank {
+			upper := bounds[n+1]
+			ms := float64(b) + float64(upper-b)*(rank-float64(seen))/float64(c)
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+		seen += c
+	}
+	return time.Duration(bounds[len(bounds)-1]) * time.Millisecond, true
+}
+
+// SLO is a service level objective evaluated over realtime stats, expressed
+// as the maximum acceptable ratio of bad to total events over a window, such
+// as 5xx responses to requests below 0.1% over 5 minutes.
+type SLO struct {
+	// Bad selects the number of bad events (required).
+	Bad StatsSelector
+	// BurnRate is the burn rate at or above which the SLO alerts (default: 1).
+	// A burn rate of 1 consumes the error budget exactly at the rate the
+	// objective allows.
+	BurnRate float64
+	// Name identifies the SLO in alerts (required).
+	Name string
+	// Objective is the maximum acceptable ratio of bad to total events, such
+	// as 0.001 for 0.1% (required).
+	Objective float64
+	// ShortWindow, if set, is a second, shorter window whose burn rate must
+	// also reach BurnRate for the SLO to alert. This avoids alerting on a burst
+	// that has already ended.
+	ShortWindow time.Duration
+	// Total selects the number of events (required).
+	Total StatsSelector
+	// Window is the window the objective applies to (required).
+	Window time.Duration
+}
+
+// SLOAlert is passed to the SLOEvaluator callback when an SLO starts or stops
+// alerting.
+type SLOAlert struct {
+	// At is the time of the most recent second of data evaluated.
+	At time.Time
+	// BurnRate is the burn rate over the SLO window.
+	BurnRate float64
+	// Firing is true when the SLO starts alerting and false when it recovers.
+	Firing bool
+	// Ratio is the ratio of bad to total events over the SLO window.
+	Ratio float64
+	// SLO is the SLO that changed state.
+	SLO *SLO
+}
+
+// SLOStatus is the result of evaluating one SLO.
+type SLOStatus struct {
+	// BurnRate is the burn rate over the SLO window.
+	BurnRate float64
+	// Firing indicates whether the SLO is alerting.
+	Firing bool
+	// HasData is false if the window held no events, in which case the other
+	// values are zero and the alert state is unchanged.
+	HasData bool
+	// Ratio is the ratio of bad to total events over the SLO window.
+	Ratio float64
+	// SLO is the SLO that was evaluated.
+	SLO *SLO
+}
+
+// SLOEvaluator evaluates SLOs over a RealtimeWindow and reports burn-rate
+// alerts through a callback.
+type SLOEvaluator struct {
+	onAlert func(SLOAlert)
+	slos    []*SLO
+	window  *RealtimeWindow
+
+	mu     sync.Mutex
+	firing map[*SLO]bool
+}
+
+// NewSLOEvaluator returns an SLOEvaluator for the given SLOs. onAlert, which
+// may be nil, is called from Evaluate whenever an SLO starts or stops
+// alerting. Every SLO window must fit in the capacity of w.
+func NewSLOEvaluator(w *RealtimeWindow, slos []SLO, onAlert func(SLOAlert)) (*SLOEvaluator, error) {
+	e := &SLOEvaluator{
+		firing:  make(map[*SLO]bool, len(slos)),
+		onAlert: onAlert,
+		window:  w,
+	}
+	for n := range slos {
+		slo := slos[n]
+		switch {
+		case slo.Name == "":
+			return nil, fmt.Errorf("SLO %d: missing name", n)
+		case slo.Bad == nil || slo.Total == nil:
+			return nil, fmt.Errorf("SLO %q: missing Bad or Total selector", slo.Name)
+		case slo.Objective <= 0 || slo.Objective >= 1:
+			return nil, fmt.Errorf("SLO %q: objective must be between 0 and 1", slo.Name)
+		case slo.Window <= 0 || slo.Window > w.Capacity():
+			return nil, fmt.Errorf("SLO %q: window must be positive and at most %s", slo.Name, w.Capacity())
+		case slo.ShortWindow < 0 || slo.ShortWindow > slo.Window:
+			return nil, fmt.Errorf("SLO %q: short window must be at most the window", slo.Name)
+		}
+		if slo.BurnRate <= 0 {
+			slo.BurnRate = 1
+		}
+		e.slos = append(e.slos, &slo)
+	}
+	return e, nil
+}
+
+// Evaluate computes the status of every SLO from the current contents of the
+// window, calling the alert callback for each SLO whose state changed.
+func (e *SLOEvaluator) Evaluate() []SLOStatus {
+	statuses, alerts := e.evaluate()
+	if e.onAlert != nil {
+		for _, a := range alerts {
+			e.onAlert(a)
+		}
+	}
+	return statuses
+}
+
+// evaluate computes the status of every SLO and records which are firing,
+// returning the alerts to report. onAlert is called by Evaluate once e.mu
+// is released, so that it may call back into the evaluator.
+func (e *SLOEvaluator) evaluate() ([]SLOStatus, []SLOAlert) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var alerts []SLOAlert
+	at := e.window.Latest()
+	statuses := make([]SLOStatus, 0, len(e.slos))
+	for _, slo := range e.slos {
+		status := SLOStatus{Firing: e.firing[slo], SLO: slo}
+
+		ratio, ok := e.window.Ratio(slo.Window, slo.Bad, slo.Total)
+		if !ok {
+			statuses = append(statuses, status)
+			continue
+		}
+		status.HasData = true
+		status.Ratio = ratio
+		status.BurnRate = ratio / slo.Objective
+
+		firing := status.BurnRate >= slo.BurnRate
+		if firing && slo.ShortWindow > 0 {
+			short, ok := e.window.Ratio(slo.ShortWindow, slo.Bad, slo.Total)
+			firing = ok && short/slo.Objective >= slo.BurnRate
+		}
+
+		if firing != status.Firing {
+			e.firing[slo] = firing
+			status.Firing = firing
+			alerts = append(alerts, SLOAlert{
+				At:       at,
+				BurnRate: status.BurnRate,
+				Firing:   firing,
+				Ratio:    ratio,
+				SLO:      slo,
+			})
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, alerts
+}
@@ -0,0 +1,284 @@
+package fastly
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// syntheticRealtimeResponse returns a response holding one record per
+// element of stats, recorded at consecutive seconds starting at start.
+func syntheticRealtimeResponse(start uint64, stats ...*Stats) *RealtimeStatsResponse {
+	r := &RealtimeStatsResponse{Timestamp: ToPointer(start + uint64(len(stats)))}
+	for n, s := range stats {
+		r.Data = append(r.Data, &RealtimeData{
+			Aggregated: s,
+			Recorded:   ToPointer(start + uint64(n)),
+		})
+	}
+	return r
+}
+
+// requestStats returns a Stats record with the given request and 5xx counts.
+func requestStats(requests, errors uint64) *Stats {
+	return &Stats{Requests: ToPointer(requests), Status5xx: ToPointer(errors)}
+}
+
+func select5xx(s *Stats) float64      { return float64(ToValue(s.Status5xx)) }
+func selectRequests(s *Stats) float64 { return float64(ToValue(s.Requests)) }
+
+func TestStatsField(t *testing.T) {
+	t.Parallel()
+
+	s := &Stats{HitRatio: ToPointer(0.25), Status5xx: ToPointer(uint64(3))}
+
+	f, err := StatsField("status_5xx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f(s); got != 3 {
+		t.Errorf("status_5xx: got %v, want 3", got)
+	}
+	f, err = StatsField("hit_ratio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f(s); got != 0.25 {
+		t.Errorf("hit_ratio: got %v, want 0.25", got)
+	}
+	f, err = StatsField("requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f(s); got != 0 {
+		t.Errorf("requests: got %v, want 0", got)
+	}
+
+	for _, name := range []string{"miss_histogram", "nope"} {
+		if _, err := StatsField(name); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestRealtimeWindow(t *testing.T) {
+	t.Parallel()
+
+	w := NewRealtimeWindow(5 * time.Second)
+	w.AddResponse(syntheticRealtimeResponse(1000,
+		requestStats(100, 1),
+		requestStats(100, 2),
+		requestStats(100, 3),
+	))
+	// A record without aggregated stats is ignored.
+	w.Add(&RealtimeData{Recorded: ToPointer(uint64(1003))})
+
+	if got := w.Latest(); !got.Equal(time.Unix(1002, 0)) {
+		t.Errorf("Latest: got %v", got)
+	}
+	if got := w.Sum(2*time.Second, select5xx); got != 5 {
+		t.Errorf("Sum: got %v, want 5", got)
+	}
+	if got := w.Rate(time.Minute, selectRequests); got != 300.0/60 {
+		t.Errorf("Rate: got %v, want 5", got)
+	}
+	if got, ok := w.Ratio(time.Minute, select5xx, selectRequests); !ok || got != 0.02 {
+		t.Errorf("Ratio: got %v, %v, want 0.02", got, ok)
+	}
+
+	// Advancing past the capacity evicts the oldest seconds, and records that
+	// are older than the window are dropped.
+	w.AddResponse(syntheticRealtimeResponse(1005, requestStats(10, 0), requestStats(10, 0)))
+	w.Add(&RealtimeData{Aggregated: requestStats(1000, 1000), Recorded: ToPointer(uint64(1001))})
+	if got := w.Len(time.Hour); got != 3 {
+		t.Errorf("Len: got %d, want 3", got)
+	}
+	if got := w.Sum(time.Hour, selectRequests); got != 120 {
+		t.Errorf("Sum after eviction: got %v, want 120", got)
+	}
+
+	if _, ok := NewRealtimeWindow(time.Minute).Ratio(time.Minute, select5xx, selectRequests); ok {
+		t.Error("Ratio on empty window: expected no data")
+	}
+}
+
+func TestRealtimeWindow_Quantile(t *testing.T) {
+	t.Parallel()
+
+	// The fixture mirrors the shape of an API response.
+	fixture := `{
+		"Timestamp": 1002,
+		"AggregateDelay": 5,
+		"Data": [
+			{"recorded": 1000, "aggregated": {"miss_histogram": {"10": 50, "20": 30}}},
+			{"recorded": 1001, "aggregated": {"miss_histogram": {"20": 10, "100": 10}}}
+		]
+	}`
+	var raw any
+	if err := json.Unmarshal([]byte(fixture), &raw); err != nil {
+		t.Fatal(err)
+	}
+	var resp *RealtimeStatsResponse
+	if err := decodeMap(raw, &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewRealtimeWindow(time.Minute)
+	w.AddResponse(resp)
+	missHistogram := func(s *Stats) map[int]int { return s.MissHistogram }
+
+	cases := []struct {
+		q    float64
+		want time.Duration
+	}{
+		// Keys are bucket lower bounds: the 10 bucket spans 10-20ms and
+		// the 20 bucket 20-100ms. The 100 bucket has no upper bound.
+		{q: 0, want: 10 * time.Millisecond},
+		{q: 0.25, want: 15 * time.Millisecond},
+		{q: 0.5, want: 20 * time.Millisecond},
+		{q: 0.7, want: 60 * time.Millisecond},
+		{q: 0.95, want: 100 * time.Millisecond},
+		{q: 1, want: 100 * time.Millisecond},
+	}
+	for _, c := range cases {
+		got, ok := w.Quantile(time.Minute, c.q, missHistogram)
+		if !ok || math.Abs(float64(got-c.want)) > float64(time.Microsecond) {
+			t.Errorf("q=%v: got %v, %v, want %v", c.q, got, ok, c.want)
+		}
+	}
+
+	if _, ok := w.Quantile(time.Minute, 0.5, func(*Stats) map[int]int { return nil }); ok {
+		t.Error("expected no data for empty histogram")
+	}
+}
+
+func TestNewSLOEvaluator_validation(t *testing.T) {
+	t.Parallel()
+
+	w := NewRealtimeWindow(time.Minute)
+	valid := SLO{Bad: select5xx, Name: "5xx", Objective: 0.001, Total: selectRequests, Window: time.Minute}
+
+	invalid := map[string]func(*SLO){
+		"name":         func(s *SLO) { s.Name = "" },
+		"selector":     func(s *SLO) { s.Bad = nil },
+		"objective":    func(s *SLO) { s.Objective = 1 },
+		"window":       func(s *SLO) { s.Window = 2 * time.Minute },
+		"short window": func(s *SLO) { s.ShortWindow = 2 * time.Minute },
+	}
+	for name, mutate := range invalid {
+		slo := valid
+		mutate(&slo)
+		if _, err := NewSLOEvaluator(w, []SLO{slo}, nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := NewSLOEvaluator(w, []SLO{valid}, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSLOEvaluator(t *testing.T) {
+	t.Parallel()
+
+	w := NewRealtimeWindow(5 * time.Minute)
+	var alerts []SLOAlert
+	e, err := NewSLOEvaluator(w, []SLO{{
+		Bad:         select5xx,
+		BurnRate:    2,
+		Name:        "5xx ratio < 0.1% over 5m",
+		Objective:   0.001,
+		ShortWindow: 10 * time.Second,
+		Total:       selectRequests,
+		Window:      5 * time.Minute,
+	}}, func(a SLOAlert) { alerts = append(alerts, a) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := e.Evaluate(); s[0].HasData || s[0].Firing {
+		t.Errorf("unexpected status without data: %+v", s[0])
+	}
+
+	// 60 healthy seconds.
+	healthy := make([]*Stats, 60)
+	for n := range healthy {
+		healthy[n] = requestStats(1000, 0)
+	}
+	w.AddResponse(syntheticRealtimeResponse(1000, healthy...))
+	if s := e.Evaluate(); !s[0].HasData || s[0].Firing || s[0].Ratio != 0 {
+		t.Errorf("unexpected status when healthy: %+v", s[0])
+	}
+
+	// 10 seconds at 2% errors: 200 bad out of 70000 is a burn rate of ~2.9.
+	burst := make([]*Stats, 10)
+	for n := range burst {
+		burst[n] = requestStats(1000, 20)
+	}
+	w.AddResponse(syntheticRealtimeResponse(1060, burst...))
+	s := e.Evaluate()
+	if !s[0].Firing {
+		t.Errorf("expected SLO to fire: %+v", s[0])
+	}
+	// Evaluating again does not repeat the alert.
+	e.Evaluate()
+
+	// The burst ends: the long window still burns, but the short one does not.
+	recovery := make([]*Stats, 10)
+	for n := range recovery {
+		recovery[n] = requestStats(1000, 0)
+	}
+	w.AddResponse(syntheticRealtimeResponse(1070, recovery...))
+	if s := e.Evaluate(); s[0].Firing {
+		t.Errorf("expected SLO to recover: %+v", s[0])
+	}
+
+	type alert struct {
+		At     int64
+		Firing bool
+		Ratio  float64
+	}
+	var got []alert
+	for _, a := range alerts {
+		got = append(got, alert{At: a.At.Unix(), Firing: a.Firing, Ratio: math.Round(a.Ratio*1e6) / 1e6})
+	}
+	want := []alert{
+		{At: 1069, Firing: true, Ratio: 0.002857},
+		{At: 1079, Firing: false, Ratio: 0.0025},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected alerts (-want +got):\n%s", diff)
+	}
+}
+
+func TestSLOEvaluator_callbackOutsideLock(t *testing.T) {
+	t.Parallel()
+
+	w := NewRealtimeWindow(time.Minute)
+	var (
+		e      *SLOEvaluator
+		nested []SLOStatus
+	)
+	e, err := NewSLOEvaluator(w, []SLO{{
+		Bad:       select5xx,
+		Name:      "5xx ratio < 1% over 1m",
+		Objective: 0.01,
+		Total:     selectRequests,
+		Window:    time.Minute,
+	}}, func(SLOAlert) { nested = e.Evaluate() })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.AddResponse(syntheticRealtimeResponse(1000, requestStats(1000, 100)))
+	// The callback evaluates again, which would deadlock if it were called
+	// with the lock held.
+	if s := e.Evaluate(); !s[0].Firing {
+		t.Errorf("expected SLO to fire: %+v", s[0])
+	}
+	if len(nested) != 1 || !nested[0].Firing {
+		t.Errorf("unexpected nested status: %+v", nested)
+	}
+}
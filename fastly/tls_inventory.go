@@ -0,0 +1,492 @@
+package fastly
+
+import (
+	"cmp"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TLSCertificateSource is the API a TLSInventoryCertificate comes from.
+type TLSCertificateSource string
+
+const (
+	// TLSCertificateSourceBulk is a platform TLS certificate, from
+	// ListBulkCertificates.
+	TLSCertificateSourceBulk TLSCertificateSource = "bulk"
+	// TLSCertificateSourceCustom is a custom TLS certificate, from
+	// ListCustomTLSCertificates.
+	TLSCertificateSourceCustom TLSCertificateSource = "custom"
+	// TLSCertificateSourceMutualAuthentication is a mutual authentication
+	// certificate bundle, from ListTLSMutualAuthentication.
+	TLSCertificateSourceMutualAuthentication TLSCertificateSource = "mutual_authentication"
+	// TLSCertificateSourceSubscription is a certificate managed by Fastly,
+	// from ListTLSSubscriptions.
+	TLSCertificateSourceSubscription TLSCertificateSource = "subscription"
+)
+
+// tlsInventoryPageSize is the page size used to list TLS resources.
+const tlsInventoryPageSize = 100
+
+// TLSDomainService is a service a TLS domain is configured on.
+type TLSDomainService struct {
+	// Domain is the domain name configured on the service, which may be
+	// covered by a wildcard certificate domain.
+	Domain string
+	// ServiceID is the ID of the service.
+	ServiceID string
+	// ServiceName is the name of the service.
+	ServiceName string
+	// ServiceVersion is the version of the service the domain was read from.
+	ServiceVersion int
+}
+
+// TLSInventoryCertificate is a certificate of any source, in a common shape.
+type TLSInventoryCertificate struct {
+	// Domains are the domains on the certificate's SAN list, or the domains
+	// the mutual authentication is activated on.
+	Domains []string
+	// ID is the ID of the certificate, or of the subscription or mutual
+	// authentication for those sources.
+	ID string
+	// Issuer is the certificate issuer, if known.
+	Issuer string
+	// Name is the name of the certificate, if any.
+	Name string
+	// NotAfter is the expiry of the certificate. It is nil when unknown, as
+	// for mutual authentication bundles or subscriptions without an issued
+	// certificate.
+	NotAfter *time.Time
+	// NotBefore is the start of the certificate's validity, if known.
+	NotBefore *time.Time
+	// SerialNumber is the certificate serial number, if known.
+	SerialNumber string
+	// Services are the services configured with one of the Domains.
+	Services []TLSDomainService
+	// Source is the API the certificate comes from.
+	Source TLSCertificateSource
+	// State is the state of a subscription.
+	State string
+}
+
+// ExpiresWithin reports whether the certificate expires within d of now,
+// including certificates which have already expired.
+func (c *TLSInventoryCertificate) ExpiresWithin(d time.Duration, now time.Time) bool {
+	return c.NotAfter != nil && c.NotAfter.Before(now.Add(d))
+}
+
+// Covers reports whether the certificate covers domain, either exactly or
+// through a wildcard such as "*.example.com".
+func (c *TLSInventoryCertificate) Covers(domain string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for _, d := range c.Domains {
+		d = strings.ToLower(d)
+		if d == domain {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(d, "*."); ok {
+			if label, rest, found := strings.Cut(domain, "."); found && label != "" && rest == suffix {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TLSInventory is the TLS certificates of an account, of all sources.
+type TLSInventory struct {
+	// Certificates are sorted by expiry, soonest first, with certificates of
+	// unknown expiry last.
+	Certificates []*TLSInventoryCertificate
+	// GeneratedAt is the time the inventory was read.
+	GeneratedAt time.Time
+}
+
+// Expiring returns the certificates which expire within the given number of
+// days of GeneratedAt, including certificates which have already expired,
+// soonest first.
+func (inv *TLSInventory) Expiring(days int) []*TLSInventoryCertificate {
+	var expiring []*TLSInventoryCertificate
+	for _, c := range inv.Certificates {
+		if c.ExpiresWithin(time.Duration(days)*24*time.Hour, inv.GeneratedAt) {
+			expiring = append(expiring, c)
+		}
+	}
+	return expiring
+}
+
+// ForDomain returns the certificates covering domain.
+func (inv *TLSInventory) ForDomain(domain string) []*TLSInventoryCertificate {
+	var certs []*TLSInventoryCertificate
+	for _, c := range inv.Certificates {
+		if c.Covers(domain) {
+			certs = append(certs, c)
+		}
+	}
+	return certs
+}
+
+// GetTLSInventoryInput is used as input to the GetTLSInventory function.
+type GetTLSInventoryInput struct {
+	// ServiceIDs are the services whose domains are mapped to certificates
+	// (default: every service of the account).
+	ServiceIDs []string
+	// SkipServices skips mapping domains to services.
+	SkipServices bool
+}
+
+// GetTLSInventory lists the custom, bulk, subscription and mutual
+// authentication certificates of the account as a single inventory, and maps
+// each certificate to the services configured with the domains it covers.
+//
+// Services are mapped using the domains of their active version, or of their
+// latest version when no version is active.
+func (c *Client) GetTLSInventory(ctx context.Context, i *GetTLSInventoryInput) (*TLSInventory, error) {
+	inv := &TLSInventory{GeneratedAt: time.Now()}
+
+	custom, err := listAllPages(func(page int) ([]*CustomTLSCertificate, error) {
+		return c.ListCustomTLSCertificates(ctx, &ListCustomTLSCertificatesInput{PageNumber: page, PageSize: tlsInventoryPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing custom TLS certificates: %w", err)
+	}
+	for _, cc := range custom {
+		inv.Certificates = append(inv.Certificates, &TLSInventoryCertificate{
+			Domains:      tlsDomainNames(cc.Domains),
+			ID:           cc.ID,
+			Issuer:       cc.Issuer,
+			Name:         cc.Name,
+			NotAfter:     cc.NotAfter,
+			NotBefore:    cc.NotBefore,
+			SerialNumber: cc.SerialNumber,
+			Source:       TLSCertificateSourceCustom,
+		})
+	}
+
+	bulk, err := listAllPages(func(page int) ([]*BulkCertificate, error) {
+		return c.ListBulkCertificates(ctx, &ListBulkCertificatesInput{PageNumber: page, PageSize: tlsInventoryPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing bulk certificates: %w", err)
+	}
+	for _, bc := range bulk {
+		inv.Certificates = append(inv.Certificates, &TLSInventoryCertificate{
+			Domains:   tlsDomainNames(bc.Domains),
+			ID:        bc.ID,
+			NotAfter:  bc.NotAfter,
+			NotBefore: bc.NotBefore,
+			Source:    TLSCertificateSourceBulk,
+		})
+	}
+
+	subscriptions, err := listAllPages(func(page int) ([]*TLSSubscription, error) {
+		return c.ListTLSSubscriptions(ctx, &ListTLSSubscriptionsInput{Include: "tls_certificates", PageNumber: page, PageSize: tlsInventoryPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing TLS subscriptions: %w", err)
+	}
+	for _, s := range subscriptions {
+		cert := &TLSInventoryCertificate{
+			Domains: tlsDomainNames(s.Domains),
+			ID:      s.ID,
+			Source:  TLSCertificateSourceSubscription,
+			State:   s.State,
+		}
+		// A subscription keeps its previous certificates; the current one
+		// expires last.
+		var latest *TLSSubscriptionCertificate
+		for _, sc := range s.Certificates {
+			if sc != nil && sc.NotAfter != nil && (latest == nil || sc.NotAfter.After(*latest.NotAfter)) {
+				latest = sc
+			}
+		}
+		if latest != nil {
+			cert.Issuer = latest.Issuer
+			cert.Name = latest.Name
+			cert.NotAfter = latest.NotAfter
+			cert.NotBefore = latest.NotBefore
+			cert.SerialNumber = latest.SerialNumber
+		}
+		inv.Certificates = append(inv.Certificates, cert)
+	}
+
+	mutual, err := listAllPages(func(page int) ([]*TLSMutualAuthentication, error) {
+		return c.ListTLSMutualAuthentication(ctx, &ListTLSMutualAuthenticationsInput{Include: []string{"tls_activations"}, PageNumber: page, PageSize: tlsInventoryPageSize})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing TLS mutual authentications: %w", err)
+	}
+	for _, m := range mutual {
+		var domains []*TLSDomain
+		for _, a := range m.Activations {
+			if a != nil {
+				domains = append(domains, a.Domain)
+			}
+		}
+		inv.Certificates = append(inv.Certificates, &TLSInventoryCertificate{
+			Domains: tlsDomainNames(domains),
+			ID:      m.ID,
+			Name:    m.Name,
+			Source:  TLSCertificateSourceMutualAuthentication,
+		})
+	}
+
+	slices.SortStableFunc(inv.Certificates, func(a, b *TLSInventoryCertificate) int {
+		switch {
+		case a.NotAfter == nil || b.NotAfter == nil:
+			return cmp.Compare(unknownExpiry(a), unknownExpiry(b))
+		default:
+			return a.NotAfter.Compare(*b.NotAfter)
+		}
+	})
+
+	if i.SkipServices {
+		return inv, nil
+	}
+	services, err := c.tlsInventoryServices(ctx, i.ServiceIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range inv.Certificates {
+		for _, s := range services {
+			if cert.Covers(s.Domain) {
+				cert.Services = append(cert.Services, s)
+			}
+		}
+	}
+	return inv, nil
+}
+
+// unknownExpiry returns 1 for certificates of unknown expiry, to sort them
+// last.
+func unknownExpiry(c *TLSInventoryCertificate) int {
+	if c.NotAfter == nil {
+		return 1
+	}
+	return 0
+}
+
+// tlsInventoryServices returns the domains of the given services, or of every
+// service of the account when serviceIDs is empty.
+func (c *Client) tlsInventoryServices(ctx context.Context, serviceIDs []string) ([]TLSDomainService, error) {
+	names := make(map[string]string)
+	active := make(map[string]int)
+	if len(serviceIDs) == 0 {
+		all, err := c.ListServices(ctx, &ListServicesInput{})
+		if err != nil {
+			return nil, fmt.Errorf("listing services: %w", err)
+		}
+		for _, s := range all {
+			id := ToValue(s.ServiceID)
+			serviceIDs = append(serviceIDs, id)
+			names[id] = ToValue(s.Name)
+			active[id] = ToValue(s.ActiveVersion)
+		}
+	} else {
+		for _, id := range serviceIDs {
+			s, err := c.GetService(ctx, &GetServiceInput{ServiceID: id})
+			if err != nil {
+				return nil, fmt.Errorf("getting service %s: %w", id, err)
+			}
+			names[id] = ToValue(s.Name)
+			active[id] = ToValue(s.ActiveVersion)
+		}
+	}
+
+	var services []TLSDomainService
+	for _, id := range serviceIDs {
+		domains, err := c.ListServiceDomains(ctx, &ListServiceDomainInput{ServiceID: id})
+		if err != nil {
+			return nil, fmt.Errorf("listing domains of service %s: %w", id, err)
+		}
+		// Domains are listed for every version: keep those of the active
+		// version, or of the latest one.
+		version := active[id]
+		if version == 0 {
+			for _, d := range domains {
+				version = max(version, int(ToValue(d.ServiceVersion)))
+			}
+		}
+		for _, d := range domains {
+			if int(ToValue(d.ServiceVersion)) != version {
+				continue
+			}
+			services = append(services, TLSDomainService{
+				Domain:         ToValue(d.Name),
+				ServiceID:      id,
+				ServiceName:    names[id],
+				ServiceVersion: version,
+			})
+		}
+	}
+	return services, nil
+}
+
+// tlsDomainNames returns the names of domains.
+func tlsDomainNames(domains []*TLSDomain) []string {
+	var names []string
+	for _, d := range domains {
+		if d != nil && d.ID != "" {
+			names = append(names, d.ID)
+		}
+	}
+	return names
+}
+
+// listAllPages calls list with increasing page numbers, starting at 1, until
+// it returns fewer than tlsInventoryPageSize items.
+func listAllPages[T any](list func(page int) ([]*T, error)) ([]*T, error) {
+	var all []*T
+	for page := 1; ; page++ {
+		items, err := list(page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < tlsInventoryPageSize {
+			return all, nil
+		}
+	}
+}
+
+// TLSRenewal is a renewed certificate, in PEM format.
+type TLSRenewal struct {
+	// CertBlob is the PEM-formatted certificate (required).
+	CertBlob string
+	// IntermediatesBlob is the PEM-formatted chain of intermediate
+	// certificates (required for bulk certificates).
+	IntermediatesBlob string
+}
+
+// TLSRenewFunc returns the renewed certificate for cert, typically by
+// requesting one from a certificate authority.
+type TLSRenewFunc func(ctx context.Context, cert *TLSInventoryCertificate) (*TLSRenewal, error)
+
+// RenewTLSCertificateInput is used as input to the RenewTLSCertificate
+// function.
+type RenewTLSCertificateInput struct {
+	// AllowUntrustedRoot allows certificates which do not chain to a trusted
+	// root.
+	AllowUntrustedRoot bool
+	// Certificate is the custom or bulk certificate to renew, from
+	// GetTLSInventory (required).
+	Certificate *TLSInventoryCertificate
+	// Renew supplies the renewed certificate (required).
+	Renew TLSRenewFunc
+}
+
+// RenewTLSCertificate replaces a custom or bulk certificate with the one
+// supplied by Renew, then reads the certificate back to verify it has the
+// serial number of the renewed one.
+//
+// It returns the certificate as read back.
+func (c *Client) RenewTLSCertificate(ctx context.Context, i *RenewTLSCertificateInput) (*TLSInventoryCertificate, error) {
+	if i.Certificate == nil {
+		return nil, ErrMissingCertificate
+	}
+	if i.Renew == nil {
+		return nil, ErrMissingRenew
+	}
+	cert := i.Certificate
+	if cert.ID == "" {
+		return nil, ErrMissingID
+	}
+	if cert.Source != TLSCertificateSourceCustom && cert.Source != TLSCertificateSourceBulk {
+		return nil, fmt.Errorf("%w: %s certificate %s", ErrTLSRenewalUnsupported, cert.Source, cert.ID)
+	}
+
+	renewal, err := i.Renew(ctx, cert)
+	if err != nil {
+		return nil, fmt.Errorf("renewing certificate %s: %w", cert.ID, err)
+	}
+	if renewal == nil || renewal.CertBlob == "" {
+		return nil, ErrMissingCertBlob
+	}
+	leaf, err := parseLeafCertificate(renewal.CertBlob)
+	if err != nil {
+		return nil, fmt.Errorf("renewing certificate %s: %w", cert.ID, err)
+	}
+	if cert.SerialNumber != "" && sameSerialNumber(cert.SerialNumber, leaf.SerialNumber) {
+		return nil, fmt.Errorf("renewing certificate %s: the renewed certificate has the serial number of the current one", cert.ID)
+	}
+
+	renewed := *cert
+	switch cert.Source {
+	case TLSCertificateSourceCustom:
+		if _, err := c.UpdateCustomTLSCertificate(ctx, &UpdateCustomTLSCertificateInput{
+			AllowUntrustedRoot: i.AllowUntrustedRoot,
+			CertBlob:           renewal.CertBlob,
+			ID:                 cert.ID,
+			Name:               cert.Name,
+		}); err != nil {
+			return nil, fmt.Errorf("updating custom TLS certificate %s: %w", cert.ID, err)
+		}
+		cc, err := c.GetCustomTLSCertificate(ctx, &GetCustomTLSCertificateInput{ID: cert.ID})
+		if err != nil {
+			return nil, fmt.Errorf("verifying custom TLS certificate %s: %w", cert.ID, err)
+		}
+		if !sameSerialNumber(cc.SerialNumber, leaf.SerialNumber) {
+			return nil, fmt.Errorf("%w: certificate %s has serial number %s, expected %s", ErrTLSSerialMismatch, cert.ID, cc.SerialNumber, leaf.SerialNumber)
+		}
+		renewed.Domains = tlsDomainNames(cc.Domains)
+		renewed.Issuer = cc.Issuer
+		renewed.NotAfter = cc.NotAfter
+		renewed.NotBefore = cc.NotBefore
+		renewed.SerialNumber = cc.SerialNumber
+	case TLSCertificateSourceBulk:
+		if _, err := c.UpdateBulkCertificate(ctx, &UpdateBulkCertificateInput{
+			AllowUntrusted:    i.AllowUntrustedRoot,
+			CertBlob:          renewal.CertBlob,
+			ID:                cert.ID,
+			IntermediatesBlob: renewal.IntermediatesBlob,
+		}); err != nil {
+			return nil, fmt.Errorf("updating bulk certificate %s: %w", cert.ID, err)
+		}
+		bc, err := c.GetBulkCertificate(ctx, &GetBulkCertificateInput{ID: cert.ID})
+		if err != nil {
+			return nil, fmt.Errorf("verifying bulk certificate %s: %w", cert.ID, err)
+		}
+		// Bulk certificates do not expose their serial number: compare the
+		// validity period instead.
+		if bc.NotAfter == nil || !bc.NotAfter.Equal(leaf.NotAfter) || bc.NotBefore == nil || !bc.NotBefore.Equal(leaf.NotBefore) {
+			return nil, fmt.Errorf("%w: bulk certificate %s has a different validity period than the renewed certificate with serial number %s", ErrTLSSerialMismatch, cert.ID, leaf.SerialNumber)
+		}
+		renewed.Domains = tlsDomainNames(bc.Domains)
+		renewed.NotAfter = bc.NotAfter
+		renewed.NotBefore = bc.NotBefore
+		renewed.SerialNumber = leaf.SerialNumber.String()
+	}
+	return &renewed, nil
+}
+
+// parseLeafCertificate parses the first certificate of a PEM blob.
+func parseLeafCertificate(blob string) (*x509.Certificate, error) {
+	rest := []byte(blob)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("no PEM-encoded certificate found")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// sameSerialNumber reports whether a serial number, as returned by the API in
+// decimal or colon-separated hexadecimal, is serial.
+func sameSerialNumber(s string, serial *big.Int) bool {
+	s = strings.TrimSpace(s)
+	if n, ok := new(big.Int).SetString(s, 10); ok && n.Cmp(serial) == 0 {
+		return true
+	}
+	n, ok := new(big.Int).SetString(strings.ReplaceAll(s, ":", ""), 16)
+	return ok && n.Cmp(serial) == 0
+}
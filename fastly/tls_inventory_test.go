@@ -0,0 +1,263 @@
+package fastly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// The IDs of the certificates and services of the tls_inventory fixtures.
+const (
+	testTLSBulkID         = "1vHbMx7QeZ3tNcLw9KpR2d"
+	testTLSLegacyID       = "7dKq2WtZnR4pLbVx9HcM3s"
+	testTLSMutualID       = "3QwLz8NvTb5KxRp1MdHc7F"
+	testTLSShopServiceID  = "2mCkD8rWzQe5LnVbXt7HsG"
+	testTLSSiteServiceID  = "7i6HN3TK9wS159v2gPAZ8A"
+	testTLSSubscriptionID = "9PkTzW4nVb6XqLc2RmHd8J"
+	testTLSWildcardID     = "5iYGRBxVeJpwQNkcqU2yXm"
+)
+
+// testRenewedCertificatePEM is the self-signed certificate the fixtures renew
+// certificates with. Its serial number is 0x1234 (4660) and it is valid from
+// 2026-10-01 to 2027-01-01.
+const testRenewedCertificatePEM = `-----BEGIN CERTIFICATE-----
+MIIBSDCB76ADAgECAgISNDAKBggqhkjOPQQDAjAYMRYwFAYDVQQDDA0qLmV4YW1w
+bGUuY29tMB4XDTI2MTAwMTAwMDAwMFoXDTI3MDEwMTAwMDAwMFowGDEWMBQGA1UE
+AwwNKi5leGFtcGxlLmNvbTBZMBMGByqGSM49AgEGCCqGSM49AwEHA0IABKPhkY+w
+02tWKDBoFNqhHODyCuG86WSAZqErNLVsxh9diZljOwVtbOneGVukB3BJ8PgchayZ
+VZNWAPJ8iSzi9pejKTAnMCUGA1UdEQQeMByCDSouZXhhbXBsZS5jb22CC2V4YW1w
+bGUuY29tMAoGCCqGSM49BAMCA0gAMEUCIFiDuKpuvBz+13uji7Mfjgm7913KM0uc
+JEmqpSxFiRPFAiEAhJGg5AYKUnhrXC7RaTrk6ByyJrn+iIeYKxQyIZ/1Ipc=
+-----END CERTIFICATE-----
+`
+
+// testTLSCertificateIDs returns the sources and IDs of certificates.
+func testTLSCertificateIDs(certs []*TLSInventoryCertificate) []string {
+	var ids []string
+	for _, c := range certs {
+		ids = append(ids, string(c.Source)+"/"+c.ID)
+	}
+	return ids
+}
+
+func TestGetTLSInventory(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	var (
+		inv *TLSInventory
+		err error
+	)
+	// The account has a full first page of 100 custom certificates, two of
+	// them expiring soon or expired and the others in 2027, and one more on
+	// the second page. It has one bulk certificate, one subscription with a
+	// previous and a current certificate, and one mutual authentication. The
+	// Site service has version 2 active and a draft version 3; the Shop
+	// service has never been activated.
+	Record(t, "tls_inventory/get", func(c *Client) {
+		inv, err = c.GetTLSInventory(context.TODO(), &GetTLSInventoryInput{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.GeneratedAt.Before(start) || inv.GeneratedAt.After(time.Now()) {
+		t.Errorf("bad generation time %v", inv.GeneratedAt)
+	}
+
+	if len(inv.Certificates) != 104 {
+		t.Fatalf("got %d certificates, want 104", len(inv.Certificates))
+	}
+	want := []string{
+		"custom/" + testTLSLegacyID,
+		"custom/" + testTLSWildcardID,
+		"subscription/" + testTLSSubscriptionID,
+		"bulk/" + testTLSBulkID,
+	}
+	if diff := cmp.Diff(want, testTLSCertificateIDs(inv.Certificates[:4])); diff != "" {
+		t.Errorf("bad certificates: %s", diff)
+	}
+	if got := inv.Certificates[103]; got.Source != TLSCertificateSourceMutualAuthentication || got.ID != testTLSMutualID {
+		t.Errorf("bad last certificate: %+v", got)
+	}
+
+	// Expiry is relative to GeneratedAt: pin it to the date of the fixture.
+	inv.GeneratedAt = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	if diff := cmp.Diff(want[:3], testTLSCertificateIDs(inv.Expiring(30))); diff != "" {
+		t.Errorf("bad expiring certificates: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"custom/" + testTLSWildcardID}, testTLSCertificateIDs(inv.ForDomain("WWW.example.com."))); diff != "" {
+		t.Errorf("bad certificates for domain: %s", diff)
+	}
+
+	wildcard := inv.Certificates[1]
+	if wildcard.Name != "wildcard" || wildcard.Issuer != "Example CA" || wildcard.SerialNumber != "1" {
+		t.Errorf("bad custom certificate: %+v", wildcard)
+	}
+	wantServices := []TLSDomainService{
+		{Domain: "www.example.com", ServiceID: testTLSSiteServiceID, ServiceName: "Site", ServiceVersion: 2},
+		{Domain: "api.example.com", ServiceID: testTLSShopServiceID, ServiceName: "Shop", ServiceVersion: 4},
+	}
+	if diff := cmp.Diff(wantServices, wildcard.Services); diff != "" {
+		t.Errorf("bad services: %s", diff)
+	}
+	// The subscription is listed with its certificates, the current one
+	// being the one expiring last.
+	if sub := inv.Certificates[2]; sub.SerialNumber != "31" || sub.Issuer != "Let's Encrypt" || sub.State != "issued" {
+		t.Errorf("bad subscription certificate: %+v", sub)
+	}
+	// The mutual authentication is listed with its activations.
+	if diff := cmp.Diff([]string{"mtls.example.com"}, inv.Certificates[103].Domains); diff != "" {
+		t.Errorf("bad mutual authentication domains: %s", diff)
+	}
+}
+
+func TestGetTLSInventory_serviceIDs(t *testing.T) {
+	t.Parallel()
+
+	var (
+		shop, site *TLSInventory
+		err        error
+	)
+	// The account has the wildcard certificate only. The given services are
+	// looked up for their name and active version, and the last one does not
+	// exist, which the API reports with a 400.
+	Record(t, "tls_inventory/service_ids", func(c *Client) {
+		if shop, err = c.GetTLSInventory(context.TODO(), &GetTLSInventoryInput{ServiceIDs: []string{testTLSShopServiceID}}); err != nil {
+			return
+		}
+		if site, err = c.GetTLSInventory(context.TODO(), &GetTLSInventoryInput{ServiceIDs: []string{testTLSSiteServiceID}}); err != nil {
+			return
+		}
+		_, err = c.GetTLSInventory(context.TODO(), &GetTLSInventoryInput{ServiceIDs: []string{"6ZqWm3RtYk8LpVb2NxHc5D"}})
+	})
+	var herr *HTTPError
+	if !errors.As(err, &herr) || herr.StatusCode != http.StatusBadRequest {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	want := []TLSDomainService{
+		{Domain: "api.example.com", ServiceID: testTLSShopServiceID, ServiceName: "Shop", ServiceVersion: 4},
+	}
+	if diff := cmp.Diff(want, shop.Certificates[0].Services); diff != "" {
+		t.Errorf("bad services: %s", diff)
+	}
+	want = []TLSDomainService{
+		{Domain: "www.example.com", ServiceID: testTLSSiteServiceID, ServiceName: "Site", ServiceVersion: 2},
+	}
+	if diff := cmp.Diff(want, site.Certificates[0].Services); diff != "" {
+		t.Errorf("bad services: %s", diff)
+	}
+}
+
+func TestListAllPages(t *testing.T) {
+	t.Parallel()
+
+	items := make([]*int, tlsInventoryPageSize*2+1)
+	for n := range items {
+		items[n] = ToPointer(n)
+	}
+	var pages int
+	all, err := listAllPages(func(page int) ([]*int, error) {
+		pages++
+		start := min((page-1)*tlsInventoryPageSize, len(items))
+		return items[start:min(start+tlsInventoryPageSize, len(items))], nil
+	})
+	if err != nil || len(all) != len(items) || pages != 3 {
+		t.Errorf("got %d items in %d pages: %v", len(all), pages, err)
+	}
+}
+
+// testTLSInventoryCertificate returns a certificate of the tls_inventory
+// fixtures, as GetTLSInventory returns it.
+func testTLSInventoryCertificate(source TLSCertificateSource, id, serial string) *TLSInventoryCertificate {
+	notAfter := time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC)
+	return &TLSInventoryCertificate{
+		Domains:      []string{"*.example.com", "example.com"},
+		ID:           id,
+		Name:         "wildcard",
+		NotAfter:     &notAfter,
+		SerialNumber: serial,
+		Source:       source,
+	}
+}
+
+func TestRenewTLSCertificate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	notBefore := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	var renewed []string
+	renew := func(_ context.Context, cert *TLSInventoryCertificate) (*TLSRenewal, error) {
+		renewed = append(renewed, cert.ID)
+		return &TLSRenewal{CertBlob: testRenewedCertificatePEM, IntermediatesBlob: testRenewedCertificatePEM}, nil
+	}
+
+	var (
+		custom, bulk *TLSInventoryCertificate
+		err          error
+	)
+	// The custom certificate is replaced and read back with the serial
+	// number of the renewed one. The bulk certificate is replaced with its
+	// intermediates and read back with its validity period.
+	RecordMatchBody(t, "tls_inventory/renew", func(c *Client) {
+		if custom, err = c.RenewTLSCertificate(ctx, &RenewTLSCertificateInput{Certificate: testTLSInventoryCertificate(TLSCertificateSourceCustom, testTLSWildcardID, "1"), Renew: renew}); err != nil {
+			return
+		}
+		bulk, err = c.RenewTLSCertificate(ctx, &RenewTLSCertificateInput{Certificate: testTLSInventoryCertificate(TLSCertificateSourceBulk, testTLSBulkID, ""), Renew: renew})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custom.SerialNumber != "4660" || !custom.NotAfter.Equal(notBefore.AddDate(0, 3, 0)) || custom.Name != "wildcard" || custom.Issuer != "*.example.com" {
+		t.Errorf("bad renewed certificate: %+v", custom)
+	}
+	if bulk.SerialNumber != "4660" || !bulk.NotAfter.Equal(notBefore.AddDate(0, 3, 0)) || !bulk.NotBefore.Equal(notBefore) {
+		t.Errorf("bad renewed certificate: %+v", bulk)
+	}
+
+	// Renewing with the current certificate is refused before any update.
+	if _, err := TestClient.RenewTLSCertificate(ctx, &RenewTLSCertificateInput{Certificate: bulk, Renew: renew}); err == nil {
+		t.Error("expected an error renewing with the current certificate")
+	}
+	if _, err := TestClient.RenewTLSCertificate(ctx, &RenewTLSCertificateInput{Certificate: testTLSInventoryCertificate(TLSCertificateSourceSubscription, testTLSSubscriptionID, "31"), Renew: renew}); !errors.Is(err, ErrTLSRenewalUnsupported) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := TestClient.RenewTLSCertificate(ctx, &RenewTLSCertificateInput{Certificate: custom}); !errors.Is(err, ErrMissingRenew) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := TestClient.RenewTLSCertificate(ctx, &RenewTLSCertificateInput{Renew: renew}); !errors.Is(err, ErrMissingCertificate) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{testTLSWildcardID, testTLSBulkID, testTLSBulkID}, renewed); diff != "" {
+		t.Errorf("bad renewals: %s", diff)
+	}
+}
+
+func TestRenewTLSCertificate_serialMismatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	renew := func(context.Context, *TLSInventoryCertificate) (*TLSRenewal, error) {
+		return &TLSRenewal{CertBlob: testRenewedCertificatePEM}, nil
+	}
+	var mismatch, hex error
+	// The legacy certificate is replaced twice. It is first read back with
+	// the serial number 12:35, then with 12:34, the hexadecimal form of the
+	// renewed one.
+	RecordMatchBody(t, "tls_inventory/renew_serial_mismatch", func(c *Client) {
+		cert := testTLSInventoryCertificate(TLSCertificateSourceCustom, testTLSLegacyID, "2")
+		cert.Name = "legacy"
+		_, mismatch = c.RenewTLSCertificate(ctx, &RenewTLSCertificateInput{Certificate: cert, Renew: renew})
+		_, hex = c.RenewTLSCertificate(ctx, &RenewTLSCertificateInput{Certificate: cert, Renew: renew})
+	})
+	if !errors.Is(mismatch, ErrTLSSerialMismatch) {
+		t.Errorf("unexpected error: %v", mismatch)
+	}
+	if hex != nil {
+		t.Errorf("unexpected error: %v", hex)
+	}
+}
@@ -0,0 +1,3 @@
+// Package usagereport builds per-service, per-region, per-month usage and
+// cost reports from the Fastly stats, billing and service APIs.
+package usagereport
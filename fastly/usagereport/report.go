@@ -0,0 +1,448 @@
+package usagereport
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+// monthFormat is the format months are written in.
+const monthFormat = "2006-01"
+
+// source is the subset of the fastly.Client API a report is built from.
+type source interface {
+	GetBilling(context.Context, *fastly.GetBillingInput) (*fastly.Billing, error)
+	GetUsage(context.Context, *fastly.GetUsageInput) (*fastly.UsageResponse, error)
+	GetUsageByService(context.Context, *fastly.GetUsageInput) (*fastly.UsageByServiceResponse, error)
+	ListServices(context.Context, *fastly.ListServicesInput) ([]*fastly.Service, error)
+}
+
+// Month identifies a calendar month in UTC.
+type Month struct {
+	time.Time
+}
+
+// NewMonth returns the month containing t, in UTC.
+func NewMonth(t time.Time) Month {
+	t = t.UTC()
+	return Month{time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// Next returns the following month.
+func (m Month) Next() Month {
+	return Month{m.AddDate(0, 1, 0)}
+}
+
+// String formats the month as YYYY-MM.
+func (m Month) String() string {
+	return m.Format(monthFormat)
+}
+
+// MarshalJSON formats the month as a YYYY-MM string.
+func (m Month) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON parses a YYYY-MM string.
+func (m *Month) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	t, err := time.Parse(monthFormat, s)
+	if err != nil {
+		return err
+	}
+	m.Time = t
+	return nil
+}
+
+// Row is the usage and allocated cost of one service in one region for one
+// month.
+type Row struct {
+	// Bandwidth is the number of bytes delivered.
+	Bandwidth uint64 `json:"bandwidth"`
+	// BandwidthCost is the share of the month's bandwidth cost allocated to
+	// the row, in proportion to its bandwidth.
+	BandwidthCost float64 `json:"bandwidth_cost"`
+	// ComputeRequests is the number of Compute requests processed.
+	ComputeRequests uint64 `json:"compute_requests"`
+	// Cost is BandwidthCost plus RequestsCost.
+	Cost float64 `json:"cost"`
+	// Month is the month the usage was recorded in.
+	Month Month `json:"month"`
+	// Region is the Fastly region the usage was recorded in.
+	Region string `json:"region"`
+	// Requests is the number of requests processed.
+	Requests uint64 `json:"requests"`
+	// RequestsCost is the share of the month's requests cost allocated to the
+	// row, in proportion to its requests.
+	RequestsCost float64 `json:"requests_cost"`
+	// ServiceID is the ID of the service.
+	ServiceID string `json:"service_id"`
+	// ServiceName is the name of the service, or empty if the service no
+	// longer exists.
+	ServiceName string `json:"service_name"`
+}
+
+// MonthSummary holds the account-wide totals of one month.
+type MonthSummary struct {
+	// Bandwidth is the number of bytes delivered across all services,
+	// according to GetUsage.
+	Bandwidth uint64 `json:"bandwidth"`
+	// Billed indicates whether billing data was available for the month. If
+	// false, the month's costs are zero.
+	Billed bool `json:"billed"`
+	// BandwidthCost is the billed bandwidth cost.
+	BandwidthCost float64 `json:"bandwidth_cost"`
+	// Cost is the total billed cost, including extras and discounts.
+	Cost float64 `json:"cost"`
+	// ExtrasCost is the billed cost of extras, which is not allocated to
+	// services.
+	ExtrasCost float64 `json:"extras_cost"`
+	// Month is the month summarized.
+	Month Month `json:"month"`
+	// Requests is the number of requests processed across all services,
+	// according to GetUsage.
+	Requests uint64 `json:"requests"`
+	// RequestsCost is the billed requests cost.
+	RequestsCost float64 `json:"requests_cost"`
+}
+
+// Report is a usage and cost report.
+type Report struct {
+	// Months summarizes each month, in order.
+	Months []MonthSummary `json:"months"`
+	// Rows holds the usage of each service and region, ordered by month,
+	// service ID and region.
+	Rows []Row `json:"rows"`
+}
+
+// GenerateInput is used as input to the Generate function.
+type GenerateInput struct {
+	// From is any time in the first month of the report (required).
+	From time.Time
+	// Region limits the report to a single Fastly region.
+	Region *string
+	// To is any time in the last month of the report (default: From).
+	To time.Time
+}
+
+// Generate builds a report covering every month from i.From to i.To.
+//
+// Usage comes from GetUsageByService and GetUsage, costs from GetBilling, and
+// service names from ListServices. Billing is only available for the whole
+// account, so each month's bandwidth and requests costs are allocated to
+// services in proportion to their bandwidth and requests. Months without
+// billing data, such as the current month, are reported with zero costs.
+func Generate(ctx context.Context, c *fastly.Client, i *GenerateInput) (*Report, error) {
+	return generate(ctx, c, i)
+}
+
+// generate implements Generate using src.
+func generate(ctx context.Context, src source, i *GenerateInput) (*Report, error) {
+	if i.From.IsZero() {
+		return nil, fastly.ErrMissingFrom
+	}
+	first := NewMonth(i.From)
+	last := first
+	if !i.To.IsZero() {
+		last = NewMonth(i.To)
+	}
+	if last.Before(first.Time) {
+		return nil, fastly.ErrInvalidStatsRange
+	}
+
+	services, err := src.ListServices(ctx, &fastly.ListServicesInput{})
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(services))
+	for _, s := range services {
+		names[fastly.ToValue(s.ServiceID)] = fastly.ToValue(s.Name)
+	}
+
+	report := &Report{}
+	for m := first; !m.After(last.Time); m = m.Next() {
+		summary, rows, err := generateMonth(ctx, src, m, i.Region, names)
+		if err != nil {
+			return nil, fmt.Errorf("failed to report on %s: %w", m, err)
+		}
+		report.Months = append(report.Months, summary)
+		report.Rows = append(report.Rows, rows...)
+	}
+	return report, nil
+}
+
+// generateMonth builds the summary and rows of one month.
+func generateMonth(ctx context.Context, src source, m Month, region *string, names map[string]string) (MonthSummary, []Row, error) {
+	summary := MonthSummary{Month: m}
+	usageInput := &fastly.GetUsageInput{
+		From:   fastly.ToPointer(strconv.FormatInt(m.Unix(), 10)),
+		Region: region,
+		To:     fastly.ToPointer(strconv.FormatInt(m.Next().Unix(), 10)),
+	}
+
+	byService, err := src.GetUsageByService(ctx, usageInput)
+	if err != nil {
+		return summary, nil, err
+	}
+	var rows []Row
+	if byService.Data != nil {
+		for r, services := range *byService.Data {
+			if services == nil {
+				continue
+			}
+			for id, u := range *services {
+				if u == nil {
+					continue
+				}
+				rows = append(rows, Row{
+					Bandwidth:       fastly.ToValue(u.Bandwidth),
+					ComputeRequests: fastly.ToValue(u.ComputeRequests),
+					Month:           m,
+					Region:          r,
+					Requests:        fastly.ToValue(u.Requests),
+					ServiceID:       id,
+					ServiceName:     names[id],
+				})
+			}
+		}
+	}
+	sort.Slice(rows, func(a, b int) bool {
+		if rows[a].ServiceID != rows[b].ServiceID {
+			return rows[a].ServiceID < rows[b].ServiceID
+		}
+		return rows[a].Region < rows[b].Region
+	})
+
+	usage, err := src.GetUsage(ctx, usageInput)
+	if err != nil {
+		return summary, nil, err
+	}
+	if usage.Data != nil {
+		for _, u := range *usage.Data {
+			if u != nil {
+				summary.Bandwidth += fastly.ToValue(u.Bandwidth)
+				summary.Requests += fastly.ToValue(u.Requests)
+			}
+		}
+	}
+
+	// #nosec G115 -- months and years are in range of the input types
+	billing, err := src.GetBilling(ctx, &fastly.GetBillingInput{
+		Month: uint8(m.Month()),
+		Year:  uint16(m.Year()),
+	})
+	var herr *fastly.HTTPError
+	switch {
+	case errors.As(err, &herr) && herr.IsNotFound():
+		return summary, rows, nil
+	case err != nil:
+		return summary, nil, err
+	case billing == nil || billing.Total == nil:
+		return summary, rows, nil
+	}
+
+	total := billing.Total
+	summary.Billed = true
+	summary.BandwidthCost = fastly.ToValue(total.BandwidthCost)
+	summary.Cost = fastly.ToValue(total.Cost)
+	summary.ExtrasCost = fastly.ToValue(total.ExtrasCost)
+	summary.RequestsCost = fastly.ToValue(total.RequestsCost)
+
+	var bandwidth, requests uint64
+	for _, r := range rows {
+		bandwidth += r.Bandwidth
+		requests += r.Requests
+	}
+	for n := range rows {
+		r := &rows[n]
+		if bandwidth > 0 {
+			r.BandwidthCost = summary.BandwidthCost * float64(r.Bandwidth) / float64(bandwidth)
+		}
+		if requests > 0 {
+			r.RequestsCost = summary.RequestsCost * float64(r.Requests) / float64(requests)
+		}
+		r.Cost = r.BandwidthCost + r.RequestsCost
+	}
+	return summary, rows, nil
+}
+
+// csvHeader is the header row written by Report.WriteCSV.
+var csvHeader = []string{
+	"month", "service_id", "service_name", "region",
+	"bandwidth", "requests", "compute_requests",
+	"bandwidth_cost", "requests_cost", "cost",
+}
+
+// WriteCSV writes the rows of the report as CSV, with a header row.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		err := cw.Write([]string{
+			row.Month.String(), row.ServiceID, row.ServiceName, row.Region,
+			strconv.FormatUint(row.Bandwidth, 10),
+			strconv.FormatUint(row.Requests, 10),
+			strconv.FormatUint(row.ComputeRequests, 10),
+			formatCost(row.BandwidthCost),
+			formatCost(row.RequestsCost),
+			formatCost(row.Cost),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Delta is the change in usage and cost of one service in one region from
+// one month to the next.
+type Delta struct {
+	// Bandwidth is the change in bytes delivered.
+	Bandwidth int64 `json:"bandwidth"`
+	// Cost is the change in allocated cost.
+	Cost float64 `json:"cost"`
+	// CostChange is Cost relative to the previous month's cost, or nil if the
+	// previous month's cost was zero.
+	CostChange *float64 `json:"cost_change"`
+	// Month is the later of the two months compared.
+	Month Month `json:"month"`
+	// Region is the Fastly region.
+	Region string `json:"region"`
+	// Requests is the change in requests processed.
+	Requests int64 `json:"requests"`
+	// ServiceID is the ID of the service.
+	ServiceID string `json:"service_id"`
+	// ServiceName is the name of the service.
+	ServiceName string `json:"service_name"`
+}
+
+// Deltas is a list of month-over-month changes.
+type Deltas []Delta
+
+// rowKey identifies the rows of a service and region across months.
+type rowKey struct {
+	region    string
+	serviceID string
+}
+
+// Deltas computes the month-over-month change of every service and region,
+// for each month of the report after the first. A service or region missing
+// from one of the months counts as zero usage.
+func (r *Report) Deltas() Deltas {
+	byMonth := make(map[string]map[rowKey]Row, len(r.Months))
+	for _, row := range r.Rows {
+		rows, ok := byMonth[row.Month.String()]
+		if !ok {
+			rows = make(map[rowKey]Row)
+			byMonth[row.Month.String()] = rows
+		}
+		rows[rowKey{region: row.Region, serviceID: row.ServiceID}] = row
+	}
+
+	var deltas Deltas
+	for n := 1; n < len(r.Months); n++ {
+		month := r.Months[n].Month
+		prev, cur := byMonth[r.Months[n-1].Month.String()], byMonth[month.String()]
+
+		keys := make(map[rowKey]bool, len(prev)+len(cur))
+		for k := range prev {
+			keys[k] = true
+		}
+		for k := range cur {
+			keys[k] = true
+		}
+		sorted := make([]rowKey, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Slice(sorted, func(a, b int) bool {
+			if sorted[a].serviceID != sorted[b].serviceID {
+				return sorted[a].serviceID < sorted[b].serviceID
+			}
+			return sorted[a].region < sorted[b].region
+		})
+
+		for _, k := range sorted {
+			p, c := prev[k], cur[k]
+			// #nosec G115 -- usage counts are far below math.MaxInt64
+			d := Delta{
+				Bandwidth:   int64(c.Bandwidth) - int64(p.Bandwidth),
+				Cost:        c.Cost - p.Cost,
+				Month:       month,
+				Region:      k.region,
+				Requests:    int64(c.Requests) - int64(p.Requests),
+				ServiceID:   k.serviceID,
+				ServiceName: c.ServiceName,
+			}
+			if d.ServiceName == "" {
+				d.ServiceName = p.ServiceName
+			}
+			if p.Cost != 0 {
+				d.CostChange = fastly.ToPointer(d.Cost / p.Cost)
+			}
+			deltas = append(deltas, d)
+		}
+	}
+	return deltas
+}
+
+// WriteCSV writes the deltas as CSV, with a header row. CostChange is left
+// empty when it is undefined.
+func (d Deltas) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"month", "service_id", "service_name", "region", "bandwidth", "requests", "cost", "cost_change"})
+	if err != nil {
+		return err
+	}
+	for _, delta := range d {
+		change := ""
+		if delta.CostChange != nil {
+			change = strconv.FormatFloat(*delta.CostChange, 'f', 4, 64)
+		}
+		err := cw.Write([]string{
+			delta.Month.String(), delta.ServiceID, delta.ServiceName, delta.Region,
+			strconv.FormatInt(delta.Bandwidth, 10),
+			strconv.FormatInt(delta.Requests, 10),
+			formatCost(delta.Cost),
+			change,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the deltas as indented JSON.
+func (d Deltas) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// formatCost formats a cost with two decimal places.
+func formatCost(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
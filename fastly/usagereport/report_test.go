@@ -0,0 +1,179 @@
+package usagereport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+// fakeSource serves canned usage and billing data keyed by month.
+type fakeSource struct {
+	billing   map[string]*fastly.Billing
+	byService map[string]fastly.ServicesByRegionsUsage
+	services  []*fastly.Service
+}
+
+func (f *fakeSource) month(i *fastly.GetUsageInput) string {
+	ts, _ := strconv.ParseInt(*i.From, 10, 64)
+	return time.Unix(ts, 0).UTC().Format(monthFormat)
+}
+
+func (f *fakeSource) GetBilling(_ context.Context, i *fastly.GetBillingInput) (*fastly.Billing, error) {
+	b, ok := f.billing[time.Date(int(i.Year), time.Month(i.Month), 1, 0, 0, 0, 0, time.UTC).Format(monthFormat)]
+	if !ok {
+		return nil, &fastly.HTTPError{StatusCode: 404}
+	}
+	return b, nil
+}
+
+func (f *fakeSource) GetUsage(_ context.Context, i *fastly.GetUsageInput) (*fastly.UsageResponse, error) {
+	regions := fastly.RegionsUsage{}
+	for region, services := range f.byService[f.month(i)] {
+		total := &fastly.Usage{Bandwidth: fastly.ToPointer(uint64(0)), Requests: fastly.ToPointer(uint64(0))}
+		for _, u := range *services {
+			*total.Bandwidth += *u.Bandwidth
+			*total.Requests += *u.Requests
+		}
+		regions[region] = total
+	}
+	return &fastly.UsageResponse{Data: &regions}, nil
+}
+
+func (f *fakeSource) GetUsageByService(_ context.Context, i *fastly.GetUsageInput) (*fastly.UsageByServiceResponse, error) {
+	data := f.byService[f.month(i)]
+	return &fastly.UsageByServiceResponse{Data: &data}, nil
+}
+
+func (f *fakeSource) ListServices(context.Context, *fastly.ListServicesInput) ([]*fastly.Service, error) {
+	return f.services, nil
+}
+
+func usage(bandwidth, requests uint64) *fastly.Usage {
+	return &fastly.Usage{
+		Bandwidth:       fastly.ToPointer(bandwidth),
+		ComputeRequests: fastly.ToPointer(uint64(0)),
+		Requests:        fastly.ToPointer(requests),
+	}
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		billing: map[string]*fastly.Billing{
+			"2026-01": {Total: &fastly.BillingTotal{
+				BandwidthCost: fastly.ToPointer(100.0),
+				Cost:          fastly.ToPointer(250.0),
+				ExtrasCost:    fastly.ToPointer(50.0),
+				RequestsCost:  fastly.ToPointer(100.0),
+			}},
+		},
+		byService: map[string]fastly.ServicesByRegionsUsage{
+			"2026-01": {
+				"europe": {"svc-a": usage(600, 100), "svc-b": usage(200, 300)},
+				"usa":    {"svc-a": usage(200, 0)},
+			},
+			"2026-02": {
+				"europe": {"svc-a": usage(900, 200)},
+			},
+		},
+		services: []*fastly.Service{
+			{Name: fastly.ToPointer("Service A"), ServiceID: fastly.ToPointer("svc-a")},
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	report, err := generate(context.Background(), newFakeSource(), &GenerateInput{
+		From: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2026, 2, 3, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var csv bytes.Buffer
+	if err := report.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	want := `month,service_id,service_name,region,bandwidth,requests,compute_requests,bandwidth_cost,requests_cost,cost
+2026-01,svc-a,Service A,europe,600,100,0,60.00,25.00,85.00
+2026-01,svc-a,Service A,usa,200,0,0,20.00,0.00,20.00
+2026-01,svc-b,,europe,200,300,0,20.00,75.00,95.00
+2026-02,svc-a,Service A,europe,900,200,0,0.00,0.00,0.00
+`
+	if diff := cmp.Diff(want, csv.String()); diff != "" {
+		t.Errorf("unexpected CSV (-want +got):\n%s", diff)
+	}
+
+	if len(report.Months) != 2 {
+		t.Fatalf("got %d months, want 2", len(report.Months))
+	}
+	jan, feb := report.Months[0], report.Months[1]
+	if !jan.Billed || jan.Bandwidth != 1000 || jan.Requests != 400 || jan.Cost != 250 {
+		t.Errorf("unexpected January summary: %+v", jan)
+	}
+	if feb.Billed || feb.Bandwidth != 900 {
+		t.Errorf("unexpected February summary: %+v", feb)
+	}
+
+	var js bytes.Buffer
+	if err := report.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(report, &decoded); diff != "" {
+		t.Errorf("JSON round trip differs (-want +got):\n%s", diff)
+	}
+}
+
+func TestReport_Deltas(t *testing.T) {
+	t.Parallel()
+
+	report, err := generate(context.Background(), newFakeSource(), &GenerateInput{
+		From: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var csv bytes.Buffer
+	if err := report.Deltas().WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	want := `month,service_id,service_name,region,bandwidth,requests,cost,cost_change
+2026-02,svc-a,Service A,europe,300,100,-85.00,-1.0000
+2026-02,svc-a,Service A,usa,-200,0,-20.00,-1.0000
+2026-02,svc-b,,europe,-200,-300,-95.00,-1.0000
+`
+	if diff := cmp.Diff(want, csv.String()); diff != "" {
+		t.Errorf("unexpected CSV (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenerate_validation(t *testing.T) {
+	t.Parallel()
+
+	_, err := generate(context.Background(), newFakeSource(), &GenerateInput{})
+	if err != fastly.ErrMissingFrom {
+		t.Errorf("bad error: %v", err)
+	}
+	_, err = generate(context.Background(), newFakeSource(), &GenerateInput{
+		From: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != fastly.ErrInvalidStatsRange {
+		t.Errorf("bad error: %v", err)
+	}
+}
@@ -0,0 +1,362 @@
+package vcl
+
+// File is a parsed VCL source, such as a custom VCL file or an init snippet.
+type File struct {
+	// Comments are the comments of the source, in order.
+	Comments []Token
+	// Decls are the top-level declarations of the source, in order.
+	Decls []Decl
+	// Name is the name of the source, used in diagnostics.
+	Name string
+}
+
+// Subs returns the subroutines declared in the file.
+func (f *File) Subs() []*SubDecl {
+	var subs []*SubDecl
+	for _, d := range f.Decls {
+		if s, ok := d.(*SubDecl); ok {
+			subs = append(subs, s)
+		}
+	}
+	return subs
+}
+
+// Objects returns the objects of the given kind declared in the file, such as
+// "backend" or "acl".
+func (f *File) Objects(kind string) []*ObjectDecl {
+	var objects []*ObjectDecl
+	for _, d := range f.Decls {
+		if o, ok := d.(*ObjectDecl); ok && o.Kind == kind {
+			objects = append(objects, o)
+		}
+	}
+	return objects
+}
+
+// Decl is a top-level declaration: a *SubDecl, *ObjectDecl, *IncludeDecl or
+// *ImportDecl.
+type Decl interface {
+	decl()
+}
+
+// SubDecl is a subroutine declaration.
+type SubDecl struct {
+	// Body is the body of the subroutine.
+	Body *Block
+	// Name is the name of the subroutine, such as "vcl_recv".
+	Name Token
+	// Pos is the position of the "sub" keyword.
+	Pos Pos
+}
+
+// ObjectDecl is the declaration of an ACL, backend, director, table,
+// penaltybox or ratecounter.
+type ObjectDecl struct {
+	// End is the position of the closing brace.
+	End Pos
+	// Entries are the ACL or table entries, as written between separators.
+	Entries [][]Token
+	// Fields are the ".name = value" fields and anonymous blocks of the body.
+	Fields []*Field
+	// Kind is the keyword of the declaration, such as "backend".
+	Kind string
+	// Name is the name of the object.
+	Name Token
+	// Pos is the position of the keyword.
+	Pos Pos
+	// Type is the director type or table value type, if any.
+	Type *Token
+}
+
+// Field is a field of an object body. Anonymous blocks, such as the members
+// of a director, are fields with an empty name.
+type Field struct {
+	// Fields are the fields of a nested block.
+	Fields []*Field
+	// Name is the name of the field, without the leading dot.
+	Name string
+	// Pos is the position of the field.
+	Pos Pos
+	// Value is the value of the field, or nil for a nested block.
+	Value Expr
+}
+
+// IncludeDecl is an "include" declaration.
+type IncludeDecl struct {
+	// Path is the included name.
+	Path Token
+	// Pos is the position of the keyword.
+	Pos Pos
+}
+
+// ImportDecl is an "import" declaration.
+type ImportDecl struct {
+	// Name is the imported module.
+	Name Token
+	// Pos is the position of the keyword.
+	Pos Pos
+}
+
+func (*SubDecl) decl()     {}
+func (*ObjectDecl) decl()  {}
+func (*IncludeDecl) decl() {}
+func (*ImportDecl) decl()  {}
+
+// Block is a braced sequence of statements.
+type Block struct {
+	// End is the position of the closing brace.
+	End Pos
+	// Pos is the position of the opening brace.
+	Pos Pos
+	// Stmts are the statements of the block.
+	Stmts []Stmt
+}
+
+// Stmt is a statement: a *AssignStmt, *UnsetStmt, *CallStmt, *ReturnStmt,
+// *IfStmt, *DeclareStmt, *ExprStmt, *LabelStmt, *SimpleStmt or *Block.
+type Stmt interface {
+	stmt()
+}
+
+// AssignStmt is a "set" or "add" statement.
+type AssignStmt struct {
+	// Keyword is "set" or "add".
+	Keyword string
+	// Op is the assignment operator, such as "=" or "+=".
+	Op string
+	// Pos is the position of the keyword.
+	Pos Pos
+	// Target is the assigned variable.
+	Target *Ident
+	// Value is the assigned value.
+	Value Expr
+}
+
+// UnsetStmt is an "unset" or "remove" statement.
+type UnsetStmt struct {
+	// Keyword is "unset" or "remove".
+	Keyword string
+	// Pos is the position of the keyword.
+	Pos Pos
+	// Target is the removed variable.
+	Target *Ident
+}
+
+// CallStmt is a "call" statement.
+type CallStmt struct {
+	// Name is the called subroutine.
+	Name *Ident
+	// Pos is the position of the keyword.
+	Pos Pos
+}
+
+// ReturnStmt is a "return" statement.
+type ReturnStmt struct {
+	// Pos is the position of the keyword.
+	Pos Pos
+	// Value is the returned action or value, or nil.
+	Value Expr
+}
+
+// Action returns the name of the returned action, such as "lookup", or an
+// empty string when the statement returns a value or nothing.
+func (s *ReturnStmt) Action() string {
+	if id, ok := s.Value.(*Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// IfStmt is an "if" statement.
+type IfStmt struct {
+	// Cond is the condition.
+	Cond Expr
+	// Else is the "else" branch, a *Block or an *IfStmt, or nil.
+	Else Stmt
+	// Pos is the position of the keyword.
+	Pos Pos
+	// Then is the block run when the condition is true.
+	Then *Block
+}
+
+// DeclareStmt is a "declare local" statement.
+type DeclareStmt struct {
+	// Name is the declared variable.
+	Name *Ident
+	// Pos is the position of the keyword.
+	Pos Pos
+	// Type is the type of the variable, such as "STRING".
+	Type Token
+}
+
+// ExprStmt is a function call used as a statement, such as
+// "std.collect(req.http.Cookie);".
+type ExprStmt struct {
+	// Call is the function call.
+	Call *CallExpr
+	// Pos is the position of the statement.
+	Pos Pos
+}
+
+// LabelStmt is a "name:" label, the target of "goto".
+type LabelStmt struct {
+	// Name is the name of the label.
+	Name Token
+	// Pos is the position of the label.
+	Pos Pos
+}
+
+// SimpleStmt is any other statement: "error", "restart", "esi",
+// "synthetic", "synthetic.base64", "log" or "goto".
+type SimpleStmt struct {
+	// Args are the arguments of the statement.
+	Args []Expr
+	// Keyword is the statement keyword.
+	Keyword string
+	// Pos is the position of the keyword.
+	Pos Pos
+}
+
+func (*AssignStmt) stmt()  {}
+func (*UnsetStmt) stmt()   {}
+func (*CallStmt) stmt()    {}
+func (*ReturnStmt) stmt()  {}
+func (*IfStmt) stmt()      {}
+func (*DeclareStmt) stmt() {}
+func (*ExprStmt) stmt()    {}
+func (*LabelStmt) stmt()   {}
+func (*SimpleStmt) stmt()  {}
+func (*Block) stmt()       {}
+
+// Expr is an expression: an *Ident, *Literal, *BinaryExpr, *UnaryExpr or
+// *CallExpr.
+type Expr interface {
+	// Position returns the position of the first token of the expression.
+	Position() Pos
+}
+
+// Ident is an identifier, such as a variable, a backend or an ACL.
+type Ident struct {
+	// Name is the identifier.
+	Name string
+	// Pos is the position of the identifier.
+	Pos Pos
+}
+
+// Literal is a string or number literal.
+type Literal struct {
+	// Token is the literal token.
+	Token Token
+}
+
+// BinaryExpr is a binary expression. Implicit concatenation of two operands
+// has an empty Op.
+type BinaryExpr struct {
+	// Op is the operator, such as "==", "~", "&&" or "+".
+	Op string
+	// X is the left operand.
+	X Expr
+	// Y is the right operand.
+	Y Expr
+}
+
+// UnaryExpr is a "!" or "-" expression.
+type UnaryExpr struct {
+	// Op is the operator.
+	Op string
+	// Pos is the position of the operator.
+	Pos Pos
+	// X is the operand.
+	X Expr
+}
+
+// CallExpr is a function call, such as "regsub(req.url, "^/", "")".
+type CallExpr struct {
+	// Args are the arguments.
+	Args []Expr
+	// Fun is the called function.
+	Fun *Ident
+}
+
+// Position implements Expr.
+func (e *Ident) Position() Pos { return e.Pos }
+
+// Position implements Expr.
+func (e *Literal) Position() Pos { return e.Token.Pos }
+
+// Position implements Expr.
+func (e *BinaryExpr) Position() Pos { return e.X.Position() }
+
+// Position implements Expr.
+func (e *UnaryExpr) Position() Pos { return e.Pos }
+
+// Position implements Expr.
+func (e *CallExpr) Position() Pos { return e.Fun.Pos }
+
+// Inspect traverses the statements and expressions below node, which may be a
+// *File, Decl, Stmt, Expr or *Field, in source order. It calls f for each
+// node, and does not descend into the children of a node for which f returns
+// false.
+func Inspect(node any, f func(node any) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *File:
+		for _, d := range n.Decls {
+			Inspect(d, f)
+		}
+	case *SubDecl:
+		Inspect(n.Body, f)
+	case *ObjectDecl:
+		for _, fl := range n.Fields {
+			Inspect(fl, f)
+		}
+	case *Field:
+		if n.Value != nil {
+			Inspect(n.Value, f)
+		}
+		for _, fl := range n.Fields {
+			Inspect(fl, f)
+		}
+	case *Block:
+		for _, s := range n.Stmts {
+			Inspect(s, f)
+		}
+	case *AssignStmt:
+		Inspect(n.Target, f)
+		Inspect(n.Value, f)
+	case *UnsetStmt:
+		Inspect(n.Target, f)
+	case *CallStmt:
+		Inspect(n.Name, f)
+	case *ReturnStmt:
+		if n.Value != nil {
+			Inspect(n.Value, f)
+		}
+	case *IfStmt:
+		Inspect(n.Cond, f)
+		Inspect(n.Then, f)
+		if n.Else != nil {
+			Inspect(n.Else, f)
+		}
+	case *DeclareStmt:
+		Inspect(n.Name, f)
+	case *ExprStmt:
+		Inspect(n.Call, f)
+	case *SimpleStmt:
+		for _, a := range n.Args {
+			Inspect(a, f)
+		}
+	case *BinaryExpr:
+		Inspect(n.X, f)
+		Inspect(n.Y, f)
+	case *UnaryExpr:
+		Inspect(n.X, f)
+	case *CallExpr:
+		Inspect(n.Fun, f)
+		for _, a := range n.Args {
+			Inspect(a, f)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package cond
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fastly/go-fastly/v17/fastly/vcl"
+)
+
+// Precedence levels of expressions, from loosest to tightest, used to add
+// parentheses only where they are needed.
+const (
+	precRaw = iota
+	precOr
+	precAnd
+	precComparison
+	precOperand
+)
+
+// Expr is a boolean VCL expression, to be used as the statement of a
+// condition. An expression built from an invalid identifier still renders,
+// but Validate reports it.
+type Expr struct {
+	err  error
+	prec int
+	vcl  string
+}
+
+// String returns the expression as VCL.
+func (e Expr) String() string {
+	return e.vcl
+}
+
+// And returns the conjunction of e and others.
+func (e Expr) And(others ...Expr) Expr {
+	return All(append([]Expr{e}, others...)...)
+}
+
+// Or returns the disjunction of e and others.
+func (e Expr) Or(others ...Expr) Expr {
+	return Any(append([]Expr{e}, others...)...)
+}
+
+// Not returns the negation of e.
+func (e Expr) Not() Expr {
+	return Not(e)
+}
+
+// Validate validates the expression as the statement of a condition of the
+// given type. See the package-level Validate. The invalid identifiers the
+// expression was built from are reported first.
+func (e Expr) Validate(conditionType string) error {
+	if e.err != nil {
+		return e.err
+	}
+	return Validate(e.vcl, conditionType)
+}
+
+// All returns the conjunction of exprs.
+func All(exprs ...Expr) Expr {
+	return join("&&", precAnd, exprs)
+}
+
+// Any returns the disjunction of exprs.
+func Any(exprs ...Expr) Expr {
+	return join("||", precOr, exprs)
+}
+
+// Not returns the negation of e.
+func Not(e Expr) Expr {
+	return Expr{err: e.err, prec: precOperand, vcl: "!" + wrap(e, precOperand)}
+}
+
+// Raw returns a hand-written VCL expression, parenthesized when it is
+// combined with others.
+func Raw(vcl string) Expr {
+	return Expr{prec: precRaw, vcl: vcl}
+}
+
+// join joins exprs with a binary operator.
+func join(op string, prec int, exprs []Expr) Expr {
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	parts := make([]string, len(exprs))
+	errs := make([]error, len(exprs))
+	for n, e := range exprs {
+		parts[n] = wrap(e, prec)
+		errs[n] = e.err
+	}
+	return Expr{err: errors.Join(errs...), prec: prec, vcl: strings.Join(parts, " "+op+" ")}
+}
+
+// wrap parenthesizes e if it binds looser than prec.
+func wrap(e Expr, prec int) string {
+	if e.prec < prec {
+		return "(" + e.vcl + ")"
+	}
+	return e.vcl
+}
+
+// Value is a VCL variable, such as "req.http.Host".
+type Value struct {
+	err  error
+	name string
+}
+
+// Var returns the variable with the given name, such as "req.url.ext" or
+// "geo.country_code". A name holding characters other than letters, digits
+// and "_.:-" is reported by the Validate method of the expressions using the
+// variable.
+func Var(name string) Value {
+	return Value{err: checkIdent("variable", name), name: name}
+}
+
+// Header returns a request header, req.http.<name>.
+func Header(name string) Value {
+	return Var("req.http." + name)
+}
+
+// BackendRequestHeader returns a header of the backend request,
+// bereq.http.<name>.
+func BackendRequestHeader(name string) Value {
+	return Var("bereq.http." + name)
+}
+
+// BackendResponseHeader returns a header of the backend response,
+// beresp.http.<name>.
+func BackendResponseHeader(name string) Value {
+	return Var("beresp.http." + name)
+}
+
+// ResponseHeader returns a header of the response to the client,
+// resp.http.<name>.
+func ResponseHeader(name string) Value {
+	return Var("resp.http." + name)
+}
+
+// Cookie returns a request cookie, req.http.Cookie:<name>.
+func Cookie(name string) Value {
+	return Var("req.http.Cookie:" + name)
+}
+
+// ClientIP returns client.ip.
+func ClientIP() Value {
+	return Var("client.ip")
+}
+
+// Method returns req.method.
+func Method() Value {
+	return Var("req.method")
+}
+
+// URL returns req.url.
+func URL() Value {
+	return Var("req.url")
+}
+
+// URLPath returns req.url.path.
+func URLPath() Value {
+	return Var("req.url.path")
+}
+
+// BackendResponseStatus returns beresp.status.
+func BackendResponseStatus() Value {
+	return Var("beresp.status")
+}
+
+// ResponseStatus returns resp.status.
+func ResponseStatus() Value {
+	return Var("resp.status")
+}
+
+// String returns the name of the variable.
+func (v Value) String() string {
+	return v.name
+}
+
+// IsSet returns an expression true when the variable is set, typically a
+// header being present.
+func (v Value) IsSet() Expr {
+	return Expr{err: v.err, prec: precOperand, vcl: v.name}
+}
+
+// IsUnset returns an expression true when the variable is not set.
+func (v Value) IsUnset() Expr {
+	return Not(v.IsSet())
+}
+
+// Eq compares the variable with a string.
+func (v Value) Eq(s string) Expr {
+	return v.compare("==", vcl.Quote(s))
+}
+
+// Ne compares the variable with a string for inequality.
+func (v Value) Ne(s string) Expr {
+	return v.compare("!=", vcl.Quote(s))
+}
+
+// EqInt compares the variable with an integer.
+func (v Value) EqInt(n int) Expr {
+	return v.compare("==", strconv.Itoa(n))
+}
+
+// NeInt compares the variable with an integer for inequality.
+func (v Value) NeInt(n int) Expr {
+	return v.compare("!=", strconv.Itoa(n))
+}
+
+// Lt returns an expression true when the variable is less than n.
+func (v Value) Lt(n int) Expr {
+	return v.compare("<", strconv.Itoa(n))
+}
+
+// Le returns an expression true when the variable is at most n.
+func (v Value) Le(n int) Expr {
+	return v.compare("<=", strconv.Itoa(n))
+}
+
+// Gt returns an expression true when the variable is greater than n.
+func (v Value) Gt(n int) Expr {
+	return v.compare(">", strconv.Itoa(n))
+}
+
+// Ge returns an expression true when the variable is at least n.
+func (v Value) Ge(n int) Expr {
+	return v.compare(">=", strconv.Itoa(n))
+}
+
+// EqVar compares the variable with another variable.
+func (v Value) EqVar(other Value) Expr {
+	e := v.compare("==", other.name)
+	e.err = errors.Join(e.err, other.err)
+	return e
+}
+
+// Matches returns an expression true when the variable matches a regular
+// expression.
+func (v Value) Matches(re string) Expr {
+	return v.compare("~", vcl.Quote(re))
+}
+
+// NotMatches returns an expression true when the variable does not match a
+// regular expression.
+func (v Value) NotMatches(re string) Expr {
+	return v.compare("!~", vcl.Quote(re))
+}
+
+// HasPrefix returns an expression true when the variable starts with prefix.
+func (v Value) HasPrefix(prefix string) Expr {
+	return v.Matches("^" + regexp.QuoteMeta(prefix))
+}
+
+// HasSuffix returns an expression true when the variable ends with suffix.
+func (v Value) HasSuffix(suffix string) Expr {
+	return v.Matches(regexp.QuoteMeta(suffix) + "$")
+}
+
+// Contains returns an expression true when the variable contains substr.
+func (v Value) Contains(substr string) Expr {
+	return v.Matches(regexp.QuoteMeta(substr))
+}
+
+// In returns an expression true when the variable, an IP address such as
+// client.ip, matches the named ACL. An invalid ACL name is reported by
+// Validate, as for Var.
+func (v Value) In(acl string) Expr {
+	e := v.compare("~", acl)
+	e.err = errors.Join(e.err, checkIdent("ACL", acl))
+	return e
+}
+
+// NotIn returns an expression true when the variable does not match the named
+// ACL.
+func (v Value) NotIn(acl string) Expr {
+	e := v.compare("!~", acl)
+	e.err = errors.Join(e.err, checkIdent("ACL", acl))
+	return e
+}
+
+// compare returns a comparison of the variable.
+func (v Value) compare(op, operand string) Expr {
+	return Expr{err: v.err, prec: precComparison, vcl: v.name + " " + op + " " + operand}
+}
+
+// checkIdent returns an error if name is not a valid identifier: a non-empty
+// run of letters, digits, underscores, dots, colons and dashes, which cannot
+// change the meaning of the expression it is rendered in.
+func checkIdent(kind, name string) error {
+	if name == "" || strings.IndexFunc(name, func(r rune) bool { return !isIdentRune(r) }) >= 0 {
+		return fmt.Errorf("invalid %s name %q", kind, name)
+	}
+	return nil
+}
+
+// isIdentRune reports whether r may appear in an identifier.
+func isIdentRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_.:-", r)
+}
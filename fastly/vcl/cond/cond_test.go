@@ -0,0 +1,158 @@
+package cond
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+	"github.com/fastly/go-fastly/v17/fastly/vcl"
+)
+
+func TestExpr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expr Expr
+		want string
+	}{
+		{
+			expr: Header("Host").Eq("example.com").And(ClientIP().In("office")),
+			want: `req.http.Host == "example.com" && client.ip ~ office`,
+		},
+		{
+			expr: Header("Host").Eq("a.com").Or(Header("Host").Eq("b.com")).And(Method().Ne("PURGE")),
+			want: `(req.http.Host == "a.com" || req.http.Host == "b.com") && req.method != "PURGE"`,
+		},
+		{
+			expr: Any(URLPath().HasPrefix("/api/"), URL().HasSuffix(".json"), URL().Contains("a+b")),
+			want: `req.url.path ~ "^/api/" || req.url ~ "\.json$" || req.url ~ "a\+b"`,
+		},
+		{
+			expr: Not(Header("Host").Eq("x")).And(Header("Authorization").IsUnset(), Cookie("session").IsSet()),
+			want: `!(req.http.Host == "x") && !req.http.Authorization && req.http.Cookie:session`,
+		},
+		{
+			expr: BackendResponseStatus().Ge(500).And(BackendResponseStatus().NeInt(503)).Not(),
+			want: `!(beresp.status >= 500 && beresp.status != 503)`,
+		},
+		{
+			expr: ResponseHeader("X-Say").Eq(`say "hi"`).Or(Raw("resp.status == 404 || resp.status == 410")),
+			want: `resp.http.X-Say == {"say "hi""} || (resp.status == 404 || resp.status == 410)`,
+		},
+		{
+			// Plain strings decode %XX escapes.
+			expr: URL().Eq("/a%20b"),
+			want: `req.url == {"/a%20b"}`,
+		},
+		{
+			// A long string cannot hold "}.
+			expr: Header("X-Foo").Eq(`{"a"}%`),
+			want: `req.http.X-Foo == "{%22a%22}%25"`,
+		},
+		{
+			expr: Header("X-Foo").EqVar(Var("req.http.X-Bar")).And(All(ClientIP().NotIn("blocked"))),
+			want: `req.http.X-Foo == req.http.X-Bar && client.ip !~ blocked`,
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.expr.String(); got != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+		// Built expressions parse.
+		if _, err := vcl.ParseExpr(tt.expr.String()); err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		statement     string
+		conditionType string
+		want          string
+	}{
+		{statement: `req.url ~ "^/api/" && client.ip ~ office`, conditionType: "REQUEST"},
+		{statement: `beresp.status == 404`, conditionType: "CACHE"},
+		{statement: `bereq.url ~ "^/a" && req.http.X`, conditionType: "prefetch"},
+		{statement: `resp.status == 200 && obj.hits > 0`, conditionType: "RESPONSE"},
+		{
+			statement:     `beresp.status == 404 || resp.status == 404 || beresp.status == 410`,
+			conditionType: "REQUEST",
+			want:          "1:1: beresp.status is not available in REQUEST conditions\n1:25: resp.status is not available in REQUEST conditions",
+		},
+		{
+			statement:     `beresp.ttl > 0s`,
+			conditionType: "PREFETCH",
+			want:          "1:1: beresp.ttl is not available in PREFETCH conditions",
+		},
+		{
+			statement:     `req.url ==`,
+			conditionType: "REQUEST",
+			want:          "1:11: unexpected end of file, expected expression",
+		},
+		{
+			statement:     `req.url`,
+			conditionType: "DELIVER",
+			want:          `unknown condition type "DELIVER", expected one of REQUEST, CACHE, RESPONSE, PREFETCH`,
+		},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.statement, tt.conditionType)
+		var got string
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("%s (%s): got %q, want %q", tt.statement, tt.conditionType, got, tt.want)
+		}
+	}
+
+	if err := BackendResponseStatus().EqInt(404).Validate("CACHE"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := ValidateCondition(&fastly.Condition{
+		Name:      fastly.ToPointer("is-404"),
+		Statement: fastly.ToPointer("beresp.status == 404"),
+		Type:      fastly.ToPointer("REQUEST"),
+	})
+	if err == nil || err.Error() != `condition "is-404": 1:1: beresp.status is not available in REQUEST conditions` {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var serr *ScopeError
+	if !errors.As(err, &serr) || serr.Variable != "beresp.status" || serr.ConditionType != "REQUEST" {
+		t.Errorf("expected a *ScopeError, got %#v", err)
+	}
+	var synerr *vcl.SyntaxError
+	if errors.As(err, &synerr) {
+		t.Errorf("scope violation reported as a syntax error: %v", err)
+	}
+
+	// Identifiers which could change the meaning of the statement are
+	// reported, however the expression is combined.
+	for _, tt := range []struct {
+		expr Expr
+		want string
+	}{
+		{
+			expr: Header(`Host == "x" || req.http.Y`).IsSet().And(URL().Eq("/")),
+			want: `invalid variable name "req.http.Host == \"x\" || req.http.Y"`,
+		},
+		{
+			expr: Not(ClientIP().In("office || true")),
+			want: `invalid ACL name "office || true"`,
+		},
+		{
+			expr: Header("X-Foo").EqVar(Var("")).Or(ClientIP().NotIn("")),
+			want: "invalid variable name \"\"\ninvalid ACL name \"\"",
+		},
+	} {
+		if err := tt.expr.Validate("REQUEST"); err == nil || err.Error() != tt.want {
+			t.Errorf("%s: got %v, want %s", tt.expr, err, tt.want)
+		}
+	}
+	if err := Cookie("session_id").IsSet().And(ClientIP().In("office-v2")).Validate("REQUEST"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+// Package cond builds and validates the statements of Fastly VCL conditions.
+//
+// Statements are built from typed values and render to valid VCL:
+//
+//	stmt := cond.Header("Host").Eq("example.com").And(cond.ClientIP().In("office"))
+//	// req.http.Host == "example.com" && client.ip ~ office
+//
+// Strings are quoted with vcl.Quote. Variable and ACL names are not quoted,
+// so the ones holding characters other than letters, digits and "_.:-" are
+// reported by Expr.Validate.
+//
+// Validate parses a statement, written by hand or built, and reports the
+// variables which are not available in the subroutine a condition of the
+// given type (REQUEST, CACHE, RESPONSE or PREFETCH) is evaluated in.
+package cond
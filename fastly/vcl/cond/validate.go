@@ -0,0 +1,72 @@
+package cond
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+	"github.com/fastly/go-fastly/v17/fastly/vcl"
+)
+
+// Types are the condition types.
+var Types = []string{"REQUEST", "CACHE", "RESPONSE", "PREFETCH"}
+
+// ScopeError reports a variable used in a condition whose type evaluates it
+// in a subroutine where the variable is not available.
+type ScopeError struct {
+	// ConditionType is the type of the condition, such as "REQUEST".
+	ConditionType string
+	// Pos is where the variable is used.
+	Pos vcl.Pos
+	// Variable is the name of the variable, such as "beresp.status".
+	Variable string
+}
+
+// Error implements the error interface.
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("%s: %s is not available in %s conditions", e.Pos, e.Variable, e.ConditionType)
+}
+
+// Validate parses the statement of a condition of the given type and returns
+// an error joining the problems found: a syntax error, an unknown type, or a
+// *ScopeError for each variable which is not available in the subroutine the
+// condition is evaluated in, such as beresp.status in a REQUEST condition.
+func Validate(statement, conditionType string) error {
+	sub := vcl.ConditionSub(conditionType)
+	if sub == "" {
+		return fmt.Errorf("unknown condition type %q, expected one of %s", conditionType, strings.Join(Types, ", "))
+	}
+	x, err := vcl.ParseExpr(statement)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	vcl.Inspect(x, func(n any) bool {
+		id, ok := n.(*vcl.Ident)
+		if !ok || seen[id.Name] || vcl.VariableAvailable(sub, id.Name) {
+			return true
+		}
+		seen[id.Name] = true
+		errs = append(errs, &ScopeError{
+			ConditionType: strings.ToUpper(conditionType),
+			Pos:           id.Pos,
+			Variable:      id.Name,
+		})
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+// ValidateCondition validates the statement of a condition against its type.
+func ValidateCondition(c *fastly.Condition) error {
+	if c == nil {
+		return nil
+	}
+	if err := Validate(fastly.ToValue(c.Statement), fastly.ToValue(c.Type)); err != nil {
+		return fmt.Errorf("condition %q: %w", fastly.ToValue(c.Name), err)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+// Package vcl parses and lints Fastly VCL offline.
+//
+// Parse, ParseStatements and ParseExpr parse custom VCL files, snippets and
+// condition statements, reporting syntax errors with their line and column.
+// Lint checks a snapshot of the VCL-related objects of a service, loaded with
+// LoadService or built from local files, for mistakes which otherwise only
+// show up when the version is validated, so that CI can fail before any API
+// call.
+package vcl
@@ -0,0 +1,401 @@
+package vcl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+// Rules reported by Lint.
+const (
+	// RuleConditionType reports an object using a condition of the wrong
+	// type, such as a response condition as the cache condition of a header.
+	RuleConditionType = "condition-type"
+	// RuleMissingMacro reports a builtin subroutine of the main VCL without
+	// its "#FASTLY" macro, or a snippet placed in a subroutine the main VCL
+	// does not declare. The generated code or snippet is then left out.
+	RuleMissingMacro = "missing-macro"
+	// RuleScope reports a variable or return action which is not available
+	// in the builtin subroutine it is used in.
+	RuleScope = "scope"
+	// RuleSnippetType reports a variable or return action which is not
+	// available in the subroutine a snippet is placed in, given its type.
+	RuleSnippetType = "snippet-type"
+	// RuleSyntax reports a syntax error.
+	RuleSyntax = "syntax"
+	// RuleUndeclaredSub reports a call to a subroutine which is not declared.
+	RuleUndeclaredSub = "undeclared-sub"
+	// RuleUndefinedACL reports a reference to an ACL which is not defined.
+	RuleUndefinedACL = "undefined-acl"
+	// RuleUndefinedBackend reports a reference to a backend or director
+	// which is not defined.
+	RuleUndefinedBackend = "undefined-backend"
+	// RuleUndefinedCondition reports a reference to a condition which is not
+	// defined.
+	RuleUndefinedCondition = "undefined-condition"
+	// RuleUndefinedTable reports a reference to a table or dictionary which
+	// is not defined.
+	RuleUndefinedTable = "undefined-table"
+)
+
+// Severity is the severity of a Diagnostic.
+type Severity int
+
+const (
+	// SeverityError is a mistake which fails validation or breaks the
+	// service.
+	SeverityError Severity = iota
+	// SeverityWarning is a likely mistake.
+	SeverityWarning
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic is a problem found by Lint.
+type Diagnostic struct {
+	// Message describes the problem.
+	Message string
+	// Pos is the position of the problem in the source, or the zero Pos for
+	// problems of service objects.
+	Pos Pos
+	// Rule is the rule which found the problem, such as RuleSyntax.
+	Rule string
+	// Severity is the severity of the problem.
+	Severity Severity
+	// Source is the object the problem was found in, such as "vcl/main",
+	// "snippet/redirects" or "header/Add Host".
+	Source string
+}
+
+// String formats the diagnostic as "source:line:column: severity: message
+// (rule)", without the position for problems of service objects.
+func (d Diagnostic) String() string {
+	src := d.Source
+	if d.Pos.Line > 0 {
+		src += ":" + d.Pos.String()
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", src, d.Severity, d.Message, d.Rule)
+}
+
+// HasErrors reports whether any of the diagnostics is an error.
+func HasErrors(diags []Diagnostic) bool {
+	return slices.ContainsFunc(diags, func(d Diagnostic) bool { return d.Severity == SeverityError })
+}
+
+// Lint checks the custom VCL, snippets and conditions of a service snapshot,
+// and the conditions its objects refer to. It reports syntax errors and
+// common mistakes which otherwise only show up when the version is validated:
+// calls to undeclared subroutines, builtin subroutines of the main VCL
+// without their "#FASTLY" macro, references to undefined backends, ACLs,
+// tables and conditions, and variables or return actions used in a
+// subroutine, or a snippet type, where they are not available.
+//
+// Diagnostics are returned in the order of the snapshot: VCLs, snippets,
+// conditions and then the other objects.
+func Lint(s *Service) []Diagnostic {
+	if s == nil {
+		return nil
+	}
+	l := &linter{
+		acls:       make(map[string]bool),
+		backends:   make(map[string]bool),
+		conditions: make(map[string]string),
+		subs:       make(map[string]bool),
+		tables:     make(map[string]bool),
+	}
+
+	// Parse every source first, so that declarations are known before the
+	// references are checked.
+	type vclFile struct {
+		file *File
+		main bool
+	}
+	var files []vclFile
+	for _, v := range s.VCLs {
+		src := "vcl/" + fastly.ToValue(v.Name)
+		f, err := Parse(src, fastly.ToValue(v.Content))
+		if err != nil {
+			l.syntax(src, err)
+			continue
+		}
+		files = append(files, vclFile{file: f, main: fastly.ToValue(v.Main)})
+		l.declare(f)
+	}
+
+	type snippetBlock struct {
+		block *Block
+		sub   string
+		src   string
+	}
+	var blocks []snippetBlock
+	for _, sn := range s.Snippets {
+		src := "snippet/" + fastly.ToValue(sn.Name)
+		content := fastly.ToValue(sn.Content)
+		typ := fastly.ToValue(sn.Type)
+		if typ == fastly.SnippetTypeInit {
+			f, err := Parse(src, content)
+			if err != nil {
+				l.syntax(src, err)
+				continue
+			}
+			files = append(files, vclFile{file: f})
+			l.declare(f)
+			continue
+		}
+		b, err := ParseStatements(content)
+		if err != nil && typ == fastly.SnippetTypeNone {
+			// Snippets of type none are included by hand, anywhere.
+			f, ferr := Parse(src, content)
+			if ferr == nil {
+				files = append(files, vclFile{file: f})
+				l.declare(f)
+				continue
+			}
+		}
+		if err != nil {
+			l.syntax(src, err)
+			continue
+		}
+		blocks = append(blocks, snippetBlock{block: b, sub: SnippetSub(typ), src: src})
+	}
+
+	for _, a := range s.ACLs {
+		l.acls[fastly.ToValue(a.Name)] = true
+	}
+	for _, b := range s.Backends {
+		name := fastly.ToValue(b.Name)
+		l.backends[name] = true
+		l.backends[BackendName(name)] = true
+	}
+	for _, d := range s.Directors {
+		name := fastly.ToValue(d.Name)
+		l.backends[name] = true
+		l.backends[BackendName(name)] = true
+	}
+	for _, d := range s.Dictionaries {
+		l.tables[fastly.ToValue(d.Name)] = true
+	}
+	for _, c := range s.Conditions {
+		l.conditions[fastly.ToValue(c.Name)] = strings.ToUpper(fastly.ToValue(c.Type))
+	}
+
+	// Check the sources.
+	var mainSubs map[string]bool
+	for _, vf := range files {
+		if vf.main {
+			mainSubs = make(map[string]bool)
+			for _, sub := range vf.file.Subs() {
+				mainSubs[sub.Name.Text] = true
+			}
+			l.checkMacros(vf.file)
+		}
+		for _, d := range vf.file.Decls {
+			sub := ""
+			if sd, ok := d.(*SubDecl); ok {
+				sub = sd.Name.Text
+			}
+			l.check(vf.file.Name, d, sub, RuleScope)
+		}
+	}
+	for _, b := range blocks {
+		if b.sub != "" && mainSubs != nil && !mainSubs[b.sub] {
+			l.report(b.src, Pos{}, RuleMissingMacro, SeverityWarning, "snippet is left out: the main VCL does not declare %s", b.sub)
+		}
+		l.check(b.src, b.block, b.sub, RuleSnippetType)
+	}
+	for _, c := range s.Conditions {
+		src := "condition/" + fastly.ToValue(c.Name)
+		x, err := ParseExpr(fastly.ToValue(c.Statement))
+		if err != nil {
+			l.syntax(src, err)
+			continue
+		}
+		l.check(src, x, ConditionSub(fastly.ToValue(c.Type)), RuleScope)
+	}
+
+	// Check the conditions of the objects.
+	for _, b := range s.Backends {
+		l.checkCondition("backend/"+fastly.ToValue(b.Name), b.RequestCondition, "REQUEST")
+	}
+	for _, c := range s.CacheSettings {
+		l.checkCondition("cache_settings/"+fastly.ToValue(c.Name), c.CacheCondition, "CACHE")
+	}
+	for _, g := range s.Gzips {
+		l.checkCondition("gzip/"+fastly.ToValue(g.Name), g.CacheCondition, "CACHE")
+	}
+	for _, h := range s.Headers {
+		src := "header/" + fastly.ToValue(h.Name)
+		l.checkCondition(src, h.CacheCondition, "CACHE")
+		l.checkCondition(src, h.RequestCondition, "REQUEST")
+		l.checkCondition(src, h.ResponseCondition, "RESPONSE")
+	}
+	for _, r := range s.RequestSettings {
+		l.checkCondition("request_settings/"+fastly.ToValue(r.Name), r.RequestCondition, "REQUEST")
+	}
+	for _, r := range s.ResponseObjects {
+		src := "response_object/" + fastly.ToValue(r.Name)
+		l.checkCondition(src, r.CacheCondition, "CACHE")
+		l.checkCondition(src, r.RequestCondition, "REQUEST")
+	}
+	return l.diags
+}
+
+// linter holds the symbols of a service and the diagnostics found so far.
+type linter struct {
+	acls       map[string]bool
+	backends   map[string]bool
+	conditions map[string]string
+	diags      []Diagnostic
+	subs       map[string]bool
+	tables     map[string]bool
+}
+
+// report adds a diagnostic.
+func (l *linter) report(src string, pos Pos, rule string, severity Severity, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{
+		Message:  fmt.Sprintf(format, args...),
+		Pos:      pos,
+		Rule:     rule,
+		Severity: severity,
+		Source:   src,
+	})
+}
+
+// syntax reports a syntax error.
+func (l *linter) syntax(src string, err error) {
+	var se *SyntaxError
+	if errors.As(err, &se) {
+		l.report(src, se.Pos, RuleSyntax, SeverityError, "%s", se.Message)
+		return
+	}
+	l.report(src, Pos{}, RuleSyntax, SeverityError, "%s", err)
+}
+
+// declare records the subroutines and objects declared in a file.
+func (l *linter) declare(f *File) {
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *SubDecl:
+			l.subs[d.Name.Text] = true
+		case *ObjectDecl:
+			switch d.Kind {
+			case "acl":
+				l.acls[d.Name.Text] = true
+			case "backend", "director":
+				l.backends[d.Name.Text] = true
+			case "table":
+				l.tables[d.Name.Text] = true
+			}
+		}
+	}
+}
+
+// macroPattern matches a "#FASTLY <sub>" macro.
+var macroPattern = regexp.MustCompile(`(?i)^#\s*FASTLY\s+(\w+)`)
+
+// checkMacros reports the builtin subroutines of the main VCL which lack
+// their "#FASTLY" macro.
+func (l *linter) checkMacros(f *File) {
+	for _, sub := range f.Subs() {
+		name := sub.Name.Text
+		if !IsBuiltinSub(name) {
+			continue
+		}
+		found := slices.ContainsFunc(f.Comments, func(c Token) bool {
+			m := macroPattern.FindStringSubmatch(c.Text)
+			return m != nil && strings.EqualFold(m[1], strings.TrimPrefix(name, "vcl_")) &&
+				after(c.Pos, sub.Body.Pos) && after(sub.Body.End, c.Pos)
+		})
+		if !found {
+			l.report(f.Name, sub.Name.Pos, RuleMissingMacro, SeverityWarning, "%s does not contain the #FASTLY %s macro", name, strings.TrimPrefix(name, "vcl_"))
+		}
+	}
+}
+
+// after reports whether a is after b.
+func after(a, b Pos) bool {
+	return a.Line > b.Line || (a.Line == b.Line && a.Column > b.Column)
+}
+
+// check reports the undefined references of node and, when sub is a builtin
+// subroutine, the variables and return actions not available in it.
+func (l *linter) check(src string, node any, sub, scopeRule string) {
+	Inspect(node, func(n any) bool {
+		switch n := n.(type) {
+		case *CallStmt:
+			if !l.subs[n.Name.Name] && !IsBuiltinSub(n.Name.Name) {
+				l.report(src, n.Name.Pos, RuleUndeclaredSub, SeverityError, "call to undeclared subroutine %s", n.Name.Name)
+			}
+		case *AssignStmt:
+			if strings.HasSuffix(n.Target.Name, ".backend") {
+				l.checkBackend(src, n.Value)
+			}
+		case *Field:
+			if n.Name == "backend" {
+				l.checkBackend(src, n.Value)
+			}
+		case *BinaryExpr:
+			switch n.Op {
+			case "~", "!~":
+				if id, ok := n.Y.(*Ident); ok && !strings.Contains(id.Name, ".") && !l.acls[id.Name] {
+					l.report(src, id.Pos, RuleUndefinedACL, SeverityError, "undefined ACL %s", id.Name)
+				}
+			case "==", "!=":
+				if id, ok := n.X.(*Ident); ok && strings.HasSuffix(id.Name, ".backend") {
+					l.checkBackend(src, n.Y)
+				}
+			}
+		case *CallExpr:
+			if strings.HasPrefix(n.Fun.Name, "table.") && len(n.Args) > 0 {
+				if id, ok := n.Args[0].(*Ident); ok && !l.tables[id.Name] {
+					l.report(src, id.Pos, RuleUndefinedTable, SeverityError, "undefined table %s", id.Name)
+				}
+			}
+		case *ReturnStmt:
+			action := n.Action()
+			if allowed := returnActions[sub]; action != "" && allowed != nil && !slices.Contains(allowed, action) {
+				l.report(src, n.Pos, scopeRule, SeverityError, "return(%s) is not allowed in %s", action, sub)
+			}
+			return false
+		case *Ident:
+			if !VariableAvailable(sub, n.Name) {
+				l.report(src, n.Pos, scopeRule, SeverityError, "%s is not available in %s", n.Name, sub)
+			}
+		}
+		return true
+	})
+}
+
+// checkBackend reports x if it names an undefined backend.
+func (l *linter) checkBackend(src string, x Expr) {
+	id, ok := x.(*Ident)
+	if !ok || strings.Contains(id.Name, ".") || l.backends[id.Name] {
+		return
+	}
+	l.report(src, id.Pos, RuleUndefinedBackend, SeverityError, "undefined backend %s", id.Name)
+}
+
+// checkCondition reports a condition of an object which is not defined or
+// not of the expected type.
+func (l *linter) checkCondition(src string, name *string, want string) {
+	if name == nil || *name == "" {
+		return
+	}
+	got, ok := l.conditions[*name]
+	switch {
+	case !ok:
+		l.report(src, Pos{}, RuleUndefinedCondition, SeverityError, "undefined condition %q", *name)
+	case got != want:
+		l.report(src, Pos{}, RuleConditionType, SeverityWarning, "condition %q is a %s condition, expected %s", *name, got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package vcl
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+func testSnippet(name string, typ fastly.SnippetType, content string) *fastly.Snippet {
+	return &fastly.Snippet{Content: fastly.ToPointer(content), Name: fastly.ToPointer(name), Type: &typ}
+}
+
+func testCondition(name, typ, statement string) *fastly.Condition {
+	return &fastly.Condition{Name: fastly.ToPointer(name), Statement: fastly.ToPointer(statement), Type: fastly.ToPointer(typ)}
+}
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	svc := &Service{
+		ACLs:         []*fastly.ACL{{Name: fastly.ToPointer("office")}},
+		Backends:     []*fastly.Backend{{Name: fastly.ToPointer("origin 1"), RequestCondition: fastly.ToPointer("is api")}},
+		Dictionaries: []*fastly.Dictionary{{Name: fastly.ToPointer("redirects")}},
+		Conditions: []*fastly.Condition{
+			testCondition("is api", "REQUEST", `req.url ~ "^/api"`),
+			testCondition("is error", "RESPONSE", `resp.status >= 500 && client.ip ~ vpn`),
+			testCondition("broken", "CACHE", `beresp.status ==`),
+		},
+		Headers: []*fastly.Header{
+			{Name: fastly.ToPointer("Add Host"), CacheCondition: fastly.ToPointer("is error"), ResponseCondition: fastly.ToPointer("missing")},
+		},
+		Snippets: []*fastly.Snippet{
+			testSnippet("helpers", fastly.SnippetTypeInit, "sub set_backend {\n  set req.backend = F_origin_1;\n}"),
+			testSnippet("api", fastly.SnippetTypeRecv, "if (table.lookup(redirects, req.url)) {\n  call set_backend;\n  set beresp.ttl = 1s;\n  return(deliver);\n}"),
+			testSnippet("logging", fastly.SnippetTypeLog, "log req.url;"),
+			testSnippet("bad", fastly.SnippetTypeFetch, "set beresp.ttl = ;"),
+		},
+		VCLs: []*fastly.VCL{
+			{Main: fastly.ToPointer(true), Name: fastly.ToPointer("main"), Content: fastly.ToPointer(`
+sub vcl_recv {
+  #FASTLY recv
+  if (client.ip ~ office) {
+    set req.backend = F_missing;
+  }
+  call undeclared;
+  call set_backend;
+  return(lookup);
+}
+
+sub vcl_deliver {
+  set resp.http.X-Tables = table.lookup(nope, "a");
+  return(deliver);
+}
+`)},
+		},
+	}
+
+	var got []string
+	for _, d := range Lint(svc) {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"snippet/bad:1:18: error: unexpected \";\", expected expression (syntax)",
+		"vcl/main:12:5: warning: vcl_deliver does not contain the #FASTLY deliver macro (missing-macro)",
+		"vcl/main:5:23: error: undefined backend F_missing (undefined-backend)",
+		"vcl/main:7:8: error: call to undeclared subroutine undeclared (undeclared-sub)",
+		"vcl/main:13:41: error: undefined table nope (undefined-table)",
+		"snippet/api:3:7: error: beresp.ttl is not available in vcl_recv (snippet-type)",
+		"snippet/api:4:3: error: return(deliver) is not allowed in vcl_recv (snippet-type)",
+		"snippet/logging: warning: snippet is left out: the main VCL does not declare vcl_log (missing-macro)",
+		"condition/is error:1:35: error: undefined ACL vpn (undefined-acl)",
+		"condition/broken:1:17: error: unexpected end of file, expected expression (syntax)",
+		"header/Add Host: warning: condition \"is error\" is a RESPONSE condition, expected CACHE (condition-type)",
+		"header/Add Host: error: undefined condition \"missing\" (undefined-condition)",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("bad diagnostics: %s", diff)
+	}
+	if !HasErrors(Lint(svc)) {
+		t.Error("expected errors")
+	}
+}
+
+func TestLintClean(t *testing.T) {
+	t.Parallel()
+
+	svc := &Service{
+		Backends: []*fastly.Backend{{Name: fastly.ToPointer("origin")}},
+		Snippets: []*fastly.Snippet{
+			testSnippet("ttl", fastly.SnippetTypeFetch, "if (beresp.status == 404) {\n  set beresp.ttl = 60s;\n  return(deliver);\n}"),
+			testSnippet("header", fastly.SnippetTypeDeliver, "set resp.http.X-Hits = obj.hits;"),
+		},
+		Conditions: []*fastly.Condition{testCondition("api", "REQUEST", `req.url ~ "^/api" && req.backend == F_origin`)},
+	}
+	if diags := Lint(svc); len(diags) != 0 {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+	if Lint(nil) != nil {
+		t.Error("expected no diagnostics for a nil service")
+	}
+}
@@ -0,0 +1,513 @@
+package vcl
+
+import (
+	"fmt"
+	"slices"
+)
+
+// objectKinds are the keywords which declare an object.
+var objectKinds = []string{"acl", "backend", "director", "penaltybox", "ratecounter", "table"}
+
+// assignOps are the operators of "set" and "add" statements.
+var assignOps = []string{"=", "+=", "-=", "*=", "/=", "%=", "|=", "&=", "^=", "<<=", ">>=", "||=", "&&="}
+
+// comparisonOps are the comparison operators.
+var comparisonOps = []string{"==", "!=", "~", "!~", "<", ">", "<=", ">="}
+
+// Parse parses a VCL source made of declarations, such as a custom VCL file
+// or an init snippet. The name is used in diagnostics only. A syntax error is
+// returned as a *SyntaxError.
+func Parse(name, src string) (*File, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{Comments: p.comments, Name: name}
+	err = p.run(func() {
+		for !p.at(TokenEOF, "") {
+			f.Decls = append(f.Decls, p.decl())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ParseStatements parses a VCL source made of statements, such as a snippet
+// placed in a subroutine. A syntax error is returned as a *SyntaxError.
+func ParseStatements(src string) (*Block, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+	b := &Block{Pos: Pos{Line: 1, Column: 1}}
+	err = p.run(func() {
+		for !p.at(TokenEOF, "") {
+			b.Stmts = append(b.Stmts, p.stmt())
+		}
+		b.End = p.tok().Pos
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// ParseExpr parses a VCL expression, such as the statement of a condition. A
+// syntax error is returned as a *SyntaxError.
+func ParseExpr(src string) (Expr, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+	var x Expr
+	err = p.run(func() {
+		x = p.expr()
+		if !p.at(TokenEOF, "") {
+			p.fail("unexpected %s after expression", p.tok())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// parser is a recursive descent parser over the tokens of a source.
+type parser struct {
+	comments []Token
+	off      int
+	tokens   []Token
+}
+
+// newParser tokenizes src and sets the comments aside.
+func newParser(src string) (*parser, error) {
+	tokens, err := Tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{}
+	for _, t := range tokens {
+		if t.Kind == TokenComment {
+			p.comments = append(p.comments, t)
+		} else {
+			p.tokens = append(p.tokens, t)
+		}
+	}
+	return p, nil
+}
+
+// bailout is raised by fail to unwind the parser on the first error.
+type bailout struct {
+	err *SyntaxError
+}
+
+// run runs fn, returning the error passed to fail if any.
+func (p *parser) run(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			b, ok := r.(bailout)
+			if !ok {
+				panic(r)
+			}
+			err = b.err
+		}
+	}()
+	fn()
+	return nil
+}
+
+// fail stops parsing with a syntax error at the current token.
+func (p *parser) fail(format string, args ...any) {
+	panic(bailout{&SyntaxError{Message: fmt.Sprintf(format, args...), Pos: p.tok().Pos}})
+}
+
+// tok returns the current token.
+func (p *parser) tok() Token {
+	return p.peek(0)
+}
+
+// peek returns the token n tokens after the current one.
+func (p *parser) peek(n int) Token {
+	if p.off+n < len(p.tokens) {
+		return p.tokens[p.off+n]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+// at reports whether the current token has the given kind and, unless text
+// is empty, the given text.
+func (p *parser) at(kind Kind, text string) bool {
+	t := p.tok()
+	return t.Kind == kind && (text == "" || t.Text == text)
+}
+
+// next returns the current token and moves past it.
+func (p *parser) next() Token {
+	t := p.tok()
+	if t.Kind != TokenEOF {
+		p.off++
+	}
+	return t
+}
+
+// accept moves past the current token if it has the given kind and text.
+func (p *parser) accept(kind Kind, text string) bool {
+	if p.at(kind, text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// expect returns the current token and moves past it, failing unless it has
+// the given kind and, unless text is empty, the given text.
+func (p *parser) expect(kind Kind, text string) Token {
+	if !p.at(kind, text) {
+		want := kind.String()
+		if text != "" {
+			want = fmt.Sprintf("%q", text)
+		}
+		p.fail("unexpected %s, expected %s", p.tok(), want)
+	}
+	return p.next()
+}
+
+// ident parses an identifier.
+func (p *parser) ident() *Ident {
+	t := p.expect(TokenIdent, "")
+	return &Ident{Name: t.Text, Pos: t.Pos}
+}
+
+// decl parses a top-level declaration.
+func (p *parser) decl() Decl {
+	t := p.tok()
+	if t.Kind != TokenIdent {
+		p.fail("unexpected %s, expected declaration", t)
+	}
+	switch {
+	case t.Text == "sub":
+		return p.subDecl()
+	case t.Text == "include":
+		p.next()
+		d := &IncludeDecl{Path: p.expect(TokenString, ""), Pos: t.Pos}
+		p.expect(TokenOperator, ";")
+		return d
+	case t.Text == "import":
+		p.next()
+		d := &ImportDecl{Name: p.expect(TokenIdent, ""), Pos: t.Pos}
+		p.expect(TokenOperator, ";")
+		return d
+	case slices.Contains(objectKinds, t.Text):
+		return p.objectDecl()
+	}
+	p.fail("unexpected %s, expected declaration", t)
+	return nil
+}
+
+// subDecl parses a subroutine, with optional parameters and return type.
+func (p *parser) subDecl() *SubDecl {
+	d := &SubDecl{Pos: p.next().Pos}
+	d.Name = p.expect(TokenIdent, "")
+	if p.accept(TokenOperator, "(") {
+		for !p.accept(TokenOperator, ")") {
+			p.expect(TokenIdent, "")
+			p.expect(TokenIdent, "")
+			if !p.at(TokenOperator, ")") {
+				p.expect(TokenOperator, ",")
+			}
+		}
+	}
+	p.accept(TokenIdent, "")
+	d.Body = p.block()
+	return d
+}
+
+// objectDecl parses an object declaration.
+func (p *parser) objectDecl() *ObjectDecl {
+	kw := p.next()
+	d := &ObjectDecl{Kind: kw.Text, Pos: kw.Pos}
+	d.Name = p.expect(TokenIdent, "")
+	if p.at(TokenIdent, "") {
+		t := p.next()
+		d.Type = &t
+	}
+	p.expect(TokenOperator, "{")
+	d.Fields, d.Entries = p.objectBody()
+	d.End = p.expect(TokenOperator, "}").Pos
+	return d
+}
+
+// objectBody parses the fields and entries of an object body, up to the
+// closing brace.
+func (p *parser) objectBody() ([]*Field, [][]Token) {
+	var (
+		fields  []*Field
+		entries [][]Token
+	)
+	for !p.at(TokenOperator, "}") {
+		switch t := p.tok(); {
+		case t.Kind == TokenEOF:
+			p.fail("unexpected %s, expected \"}\"", t)
+		case t.Kind == TokenOperator && t.Text == "." && p.peek(1).Kind == TokenIdent:
+			p.next()
+			f := &Field{Name: p.next().Text, Pos: t.Pos}
+			p.expect(TokenOperator, "=")
+			if p.accept(TokenOperator, "{") {
+				f.Fields, _ = p.objectBody()
+				p.expect(TokenOperator, "}")
+				p.accept(TokenOperator, ";")
+			} else {
+				f.Value = p.expr()
+				p.expect(TokenOperator, ";")
+			}
+			fields = append(fields, f)
+		case t.Kind == TokenOperator && t.Text == "{":
+			p.next()
+			f := &Field{Pos: t.Pos}
+			f.Fields, _ = p.objectBody()
+			p.expect(TokenOperator, "}")
+			fields = append(fields, f)
+		default:
+			var entry []Token
+			for !p.at(TokenOperator, "}") && !p.at(TokenEOF, "") {
+				e := p.next()
+				if e.Kind == TokenOperator && (e.Text == ";" || e.Text == ",") {
+					break
+				}
+				if e.Kind == TokenOperator && e.Text == "{" {
+					p.fail("unexpected %s in entry", e)
+				}
+				entry = append(entry, e)
+			}
+			if len(entry) > 0 {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return fields, entries
+}
+
+// block parses a braced block of statements.
+func (p *parser) block() *Block {
+	b := &Block{Pos: p.expect(TokenOperator, "{").Pos}
+	for !p.at(TokenOperator, "}") {
+		if p.at(TokenEOF, "") {
+			p.fail("unexpected %s, expected \"}\"", p.tok())
+		}
+		b.Stmts = append(b.Stmts, p.stmt())
+	}
+	b.End = p.next().Pos
+	return b
+}
+
+// stmt parses a statement.
+func (p *parser) stmt() Stmt {
+	t := p.tok()
+	if t.Kind == TokenOperator && t.Text == "{" {
+		return p.block()
+	}
+	if t.Kind != TokenIdent {
+		p.fail("unexpected %s, expected statement", t)
+	}
+
+	switch t.Text {
+	case "set", "add":
+		p.next()
+		s := &AssignStmt{Keyword: t.Text, Pos: t.Pos, Target: p.ident()}
+		if op := p.tok(); op.Kind != TokenOperator || !slices.Contains(assignOps, op.Text) {
+			p.fail("unexpected %s, expected assignment operator", op)
+		}
+		s.Op = p.next().Text
+		s.Value = p.expr()
+		p.expect(TokenOperator, ";")
+		return s
+	case "unset", "remove":
+		p.next()
+		s := &UnsetStmt{Keyword: t.Text, Pos: t.Pos, Target: p.ident()}
+		p.expect(TokenOperator, ";")
+		return s
+	case "call":
+		p.next()
+		s := &CallStmt{Name: p.ident(), Pos: t.Pos}
+		p.expect(TokenOperator, ";")
+		return s
+	case "return":
+		p.next()
+		s := &ReturnStmt{Pos: t.Pos}
+		if !p.at(TokenOperator, ";") {
+			s.Value = p.expr()
+		}
+		p.expect(TokenOperator, ";")
+		return s
+	case "if":
+		return p.ifStmt()
+	case "declare":
+		p.next()
+		p.expect(TokenIdent, "local")
+		s := &DeclareStmt{Name: p.ident(), Pos: t.Pos, Type: p.expect(TokenIdent, "")}
+		p.expect(TokenOperator, ";")
+		return s
+	case "error":
+		p.next()
+		s := &SimpleStmt{Keyword: t.Text, Pos: t.Pos}
+		for !p.at(TokenOperator, ";") {
+			s.Args = append(s.Args, p.unary())
+		}
+		p.expect(TokenOperator, ";")
+		return s
+	case "restart", "esi":
+		p.next()
+		p.expect(TokenOperator, ";")
+		return &SimpleStmt{Keyword: t.Text, Pos: t.Pos}
+	case "synthetic", "synthetic.base64", "log":
+		p.next()
+		s := &SimpleStmt{Args: []Expr{p.expr()}, Keyword: t.Text, Pos: t.Pos}
+		p.expect(TokenOperator, ";")
+		return s
+	case "goto":
+		p.next()
+		s := &SimpleStmt{Args: []Expr{p.ident()}, Keyword: t.Text, Pos: t.Pos}
+		p.expect(TokenOperator, ";")
+		return s
+	}
+
+	switch n := p.peek(1); {
+	case n.Kind == TokenOperator && n.Text == ":":
+		p.next()
+		p.next()
+		return &LabelStmt{Name: t, Pos: t.Pos}
+	case n.Kind == TokenOperator && n.Text == "(":
+		call, _ := p.primary().(*CallExpr)
+		p.expect(TokenOperator, ";")
+		return &ExprStmt{Call: call, Pos: t.Pos}
+	}
+	p.fail("unexpected %s, expected statement", t)
+	return nil
+}
+
+// ifStmt parses an "if" statement and its "else" branches.
+func (p *parser) ifStmt() *IfStmt {
+	s := &IfStmt{Pos: p.next().Pos}
+	p.expect(TokenOperator, "(")
+	s.Cond = p.expr()
+	p.expect(TokenOperator, ")")
+	s.Then = p.block()
+
+	switch t := p.tok(); {
+	case t.Kind == TokenIdent && (t.Text == "elseif" || t.Text == "elsif"):
+		s.Else = p.ifStmt()
+	case t.Kind == TokenIdent && t.Text == "else":
+		p.next()
+		if p.at(TokenIdent, "if") {
+			s.Else = p.ifStmt()
+		} else {
+			s.Else = p.block()
+		}
+	}
+	return s
+}
+
+// expr parses an expression.
+func (p *parser) expr() Expr {
+	return p.binary(0)
+}
+
+// precedence lists the binary operators from lowest to highest precedence.
+// Concatenation binds tighter than comparisons.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	comparisonOps,
+}
+
+// binary parses the binary expressions of the given precedence level.
+func (p *parser) binary(level int) Expr {
+	if level == len(precedence) {
+		return p.concat()
+	}
+	x := p.binary(level + 1)
+	for p.tok().Kind == TokenOperator && slices.Contains(precedence[level], p.tok().Text) {
+		op := p.next().Text
+		x = &BinaryExpr{Op: op, X: x, Y: p.binary(level + 1)}
+		if level == len(precedence)-1 {
+			// Comparisons do not chain.
+			break
+		}
+	}
+	return x
+}
+
+// concat parses the concatenation of operands, written with "+" or by
+// juxtaposition.
+func (p *parser) concat() Expr {
+	x := p.unary()
+	for {
+		switch t := p.tok(); {
+		case t.Kind == TokenOperator && t.Text == "+":
+			p.next()
+			x = &BinaryExpr{Op: "+", X: x, Y: p.unary()}
+		case t.Kind == TokenString || t.Kind == TokenIdent:
+			x = &BinaryExpr{X: x, Y: p.unary()}
+		default:
+			return x
+		}
+	}
+}
+
+// unary parses a negated operand.
+func (p *parser) unary() Expr {
+	if t := p.tok(); t.Kind == TokenOperator && (t.Text == "!" || t.Text == "-") {
+		p.next()
+		return &UnaryExpr{Op: t.Text, Pos: t.Pos, X: p.unary()}
+	}
+	return p.primary()
+}
+
+// primary parses an identifier, literal, function call or parenthesized
+// expression.
+func (p *parser) primary() Expr {
+	switch t := p.tok(); t.Kind {
+	case TokenString, TokenNumber:
+		p.next()
+		return &Literal{Token: t}
+	case TokenIdent:
+		id := p.ident()
+		if !p.accept(TokenOperator, "(") {
+			return id
+		}
+		call := &CallExpr{Fun: id}
+		for !p.accept(TokenOperator, ")") {
+			call.Args = append(call.Args, p.expr())
+			if !p.at(TokenOperator, ")") {
+				p.expect(TokenOperator, ",")
+			}
+		}
+		return call
+	case TokenOperator:
+		if t.Text == "(" {
+			p.next()
+			x := p.expr()
+			p.expect(TokenOperator, ")")
+			return x
+		}
+	}
+	p.fail("unexpected %s, expected expression", p.tok())
+	return nil
+}
+
+// ValidateSnippet parses the content of a snippet, made of statements or, for
+// init snippets, of declarations, and returns the syntax error if it is
+// neither. It can be used as the Validate function of a
+// fastly.DynamicSnippetManager.
+func ValidateSnippet(content string) error {
+	_, err := ParseStatements(content)
+	if err == nil {
+		return nil
+	}
+	if _, derr := Parse("snippet", content); derr == nil {
+		return nil
+	}
+	return err
+}
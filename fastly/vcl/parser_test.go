@@ -0,0 +1,218 @@
+package vcl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testMainVCL = `
+import boltsort;
+include "helpers";
+
+acl internal {
+  "10.0.0.0"/8;
+  !"10.1.0.0"/16;
+}
+
+backend F_origin {
+  .host = "origin.example.com";
+  .port = "443";
+  .probe = {
+    .request = "GET / HTTP/1.1" "Host: origin.example.com";
+    .timeout = 2s;
+  }
+}
+
+director pool random {
+  .quorum = 50%;
+  { .backend = F_origin; .weight = 1; }
+}
+
+table redirects STRING {
+  "/old": "/new",
+  "/a": "/b"
+}
+
+sub normalize(STRING path) STRING {
+  return std.tolower(path);
+}
+
+sub vcl_recv {
+  #FASTLY recv
+  declare local var.path STRING;
+  set var.path = normalize(req.url.path);
+  if (client.ip ~ internal && !req.http.Fastly-FF) {
+    set req.backend = pool;
+  } else if (table.contains(redirects, req.url.path)) {
+    error 801 "redirect";
+  } elsif (req.http.Host == "a" || req.http.Host == "b") {
+    esi;
+  } else {
+    unset req.http.Cookie;
+  }
+  std.collect(req.http.Cookie);
+  return(lookup);
+}
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	f, err := Parse("main", testMainVCL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decls []string
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *SubDecl:
+			decls = append(decls, "sub "+d.Name.Text)
+		case *ObjectDecl:
+			decls = append(decls, d.Kind+" "+d.Name.Text)
+		case *IncludeDecl:
+			decls = append(decls, "include "+d.Path.Value())
+		case *ImportDecl:
+			decls = append(decls, "import "+d.Name.Text)
+		}
+	}
+	want := []string{
+		"import boltsort", "include helpers", "acl internal", "backend F_origin", "director pool",
+		"table redirects", "sub normalize", "sub vcl_recv",
+	}
+	if diff := cmp.Diff(want, decls); diff != "" {
+		t.Errorf("bad declarations: %s", diff)
+	}
+
+	if acl := f.Objects("acl")[0]; len(acl.Entries) != 2 {
+		t.Errorf("bad ACL entries: %v", acl.Entries)
+	}
+	if table := f.Objects("table")[0]; len(table.Entries) != 2 || table.Type.Text != "STRING" {
+		t.Errorf("bad table: %+v", table)
+	}
+	backend := f.Objects("backend")[0]
+	if len(backend.Fields) != 3 || backend.Fields[2].Name != "probe" || len(backend.Fields[2].Fields) != 2 {
+		t.Errorf("bad backend fields: %+v", backend.Fields)
+	}
+	director := f.Objects("director")[0]
+	if director.Type.Text != "random" || len(director.Fields) != 2 || director.Fields[1].Fields[0].Name != "backend" {
+		t.Errorf("bad director: %+v", director)
+	}
+
+	recv := f.Subs()[1]
+	var stmts []string
+	for _, s := range recv.Body.Stmts {
+		switch s := s.(type) {
+		case *DeclareStmt:
+			stmts = append(stmts, "declare "+s.Name.Name)
+		case *AssignStmt:
+			stmts = append(stmts, s.Keyword+" "+s.Target.Name)
+		case *IfStmt:
+			stmts = append(stmts, "if")
+		case *ExprStmt:
+			stmts = append(stmts, "call "+s.Call.Fun.Name)
+		case *ReturnStmt:
+			stmts = append(stmts, "return "+s.Action())
+		}
+	}
+	if diff := cmp.Diff([]string{"declare var.path", "set var.path", "if", "call std.collect", "return lookup"}, stmts); diff != "" {
+		t.Errorf("bad statements: %s", diff)
+	}
+
+	var idents []string
+	Inspect(recv.Body.Stmts[2], func(n any) bool {
+		if id, ok := n.(*Ident); ok {
+			idents = append(idents, id.Name)
+		}
+		return true
+	})
+	want = []string{
+		"client.ip", "internal", "req.http.Fastly-FF", "req.backend", "pool", "table.contains", "redirects",
+		"req.url.path", "req.http.Host", "req.http.Host", "req.http.Cookie",
+	}
+	if diff := cmp.Diff(want, idents); diff != "" {
+		t.Errorf("bad identifiers: %s", diff)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for src, want := range map[string]string{
+		"sub vcl_recv {\n  set req.url \"/\";\n}":    `2:15: unexpected "\"/\"", expected assignment operator`,
+		"sub vcl_recv {\n  if req.url { }\n}":        `2:6: unexpected "req.url", expected "("`,
+		"sub vcl_recv {\n  return(lookup);\n":        "3:1: unexpected end of file, expected \"}\"",
+		"set req.url = \"/\";":                       `1:1: unexpected "set", expected declaration`,
+		"backend b {\n  .host = ;\n}":                `2:11: unexpected ";", expected expression`,
+		"sub vcl_recv {\n  lookup;\n}":               `2:3: unexpected "lookup", expected statement`,
+		"sub vcl_recv {\n  set req.url = (\"a\";\n}": `2:21: unexpected ";", expected ")"`,
+	} {
+		_, err := Parse("test", src)
+		var se *SyntaxError
+		if !errors.As(err, &se) || err.Error() != want {
+			t.Errorf("Parse(%q): got %v, want %s", src, err, want)
+		}
+	}
+}
+
+func TestParseStatements(t *testing.T) {
+	t.Parallel()
+
+	b, err := ParseStatements("if (req.url ~ \"^/api\") {\n  set req.backend = F_api;\n}\nrestart;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Stmts) != 2 {
+		t.Errorf("bad statements: %+v", b.Stmts)
+	}
+	if _, err := ParseStatements("sub vcl_recv {}"); err == nil {
+		t.Error("expected an error for a declaration")
+	}
+}
+
+func TestParseExpr(t *testing.T) {
+	t.Parallel()
+
+	x, err := ParseExpr(`req.url ~ "^/a" && !(req.http.Host == "x" "y" || client.ip ~ internal)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	and, ok := x.(*BinaryExpr)
+	if !ok || and.Op != "&&" {
+		t.Fatalf("bad expression: %#v", x)
+	}
+	not, ok := and.Y.(*UnaryExpr)
+	if !ok || not.Op != "!" {
+		t.Fatalf("bad negation: %#v", and.Y)
+	}
+	or, ok := not.X.(*BinaryExpr)
+	if !ok || or.Op != "||" {
+		t.Fatalf("bad disjunction: %#v", not.X)
+	}
+	if eq, ok := or.X.(*BinaryExpr); !ok || eq.Op != "==" {
+		t.Fatalf("bad comparison: %#v", or.X)
+	} else if cat, ok := eq.Y.(*BinaryExpr); !ok || cat.Op != "" {
+		t.Errorf("bad concatenation: %#v", eq.Y)
+	}
+
+	if _, err := ParseExpr(`req.url == "a" )`); err == nil || err.Error() != `1:16: unexpected ")" after expression` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateSnippet(t *testing.T) {
+	t.Parallel()
+
+	for content, valid := range map[string]bool{
+		`set req.http.X-Foo = "1";`:       true,
+		"sub helper {\n  esi;\n}":         true,
+		`set req.http.X-Foo = "1"`:        false,
+		"if (req.url) {\n  return(pass);": false,
+	} {
+		if err := ValidateSnippet(content); (err == nil) != valid {
+			t.Errorf("ValidateSnippet(%q): got %v", content, err)
+		}
+	}
+}
@@ -0,0 +1,759 @@
+package vcl
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+// defaultVCL is the boilerplate used when a service has no main custom VCL.
+const defaultVCL = `sub vcl_recv {
+#FASTLY recv
+  if (req.method != "HEAD" && req.method != "GET" && req.method != "FASTLYPURGE") {
+    return(pass);
+  }
+  return(lookup);
+}
+
+sub vcl_hash {
+  set req.hash += req.url;
+  set req.hash += req.http.host;
+#FASTLY hash
+  return(hash);
+}
+
+sub vcl_hit {
+#FASTLY hit
+  if (!obj.cacheable) {
+    return(pass);
+  }
+  return(deliver);
+}
+
+sub vcl_miss {
+#FASTLY miss
+  return(fetch);
+}
+
+sub vcl_pass {
+#FASTLY pass
+  return(pass);
+}
+
+sub vcl_fetch {
+#FASTLY fetch
+  if ((beresp.status == 500 || beresp.status == 503) && req.restarts < 1 && (req.method == "GET" || req.method == "HEAD")) {
+    restart;
+  }
+  if (req.restarts > 0) {
+    set beresp.http.Fastly-Restarts = req.restarts;
+  }
+  if (beresp.http.Set-Cookie) {
+    set req.http.Fastly-Cachetype = "SETCOOKIE";
+    return(pass);
+  }
+  if (beresp.http.Cache-Control ~ "private") {
+    set req.http.Fastly-Cachetype = "PRIVATE";
+    return(pass);
+  }
+  if (beresp.status == 500 || beresp.status == 503) {
+    set req.http.Fastly-Cachetype = "ERROR";
+    set beresp.ttl = 1s;
+    set beresp.grace = 5s;
+    return(deliver);
+  }
+  if (!(beresp.http.Expires || beresp.http.Surrogate-Control ~ "max-age" || beresp.http.Cache-Control ~ "(s-maxage|max-age)")) {
+    set beresp.ttl = 3600s;
+  }
+  return(deliver);
+}
+
+sub vcl_error {
+#FASTLY error
+}
+
+sub vcl_deliver {
+#FASTLY deliver
+  return(deliver);
+}
+
+sub vcl_log {
+#FASTLY log
+}
+`
+
+// directorTypes are the VCL names of the director types.
+var directorTypes = map[fastly.DirectorType]string{
+	fastly.DirectorTypeRandom:     "random",
+	fastly.DirectorTypeRoundRobin: "round-robin",
+	fastly.DirectorTypeHash:       "hash",
+	fastly.DirectorTypeClient:     "client",
+}
+
+// fastlyCode is the code Fastly adds at the start of the "#FASTLY" macro of
+// each builtin subroutine, before the snippets.
+var fastlyCode = map[string][]string{
+	"vcl_recv": {
+		"if (req.restarts == 0) {",
+		"  if (!req.http.X-Timer) {",
+		`    set req.http.X-Timer = "S" time.start.sec "." time.start.usec_frac;`,
+		"  }",
+		`  set req.http.X-Timer = req.http.X-Timer ",VS0";`,
+		"}",
+	},
+	"vcl_hit": {
+		"# we cannot reach obj.ttl and obj.grace in deliver, save them when we can in vcl_hit",
+		"set req.http.Fastly-Tmp-Obj-TTL = obj.ttl;",
+		"set req.http.Fastly-Tmp-Obj-Grace = obj.grace;",
+	},
+	"vcl_fetch": {
+		"# record which cache ran vcl_fetch for this object and when",
+		`set beresp.http.Fastly-Debug-Path = "(F " server.identity " " now.sec ") " if(beresp.http.Fastly-Debug-Path, beresp.http.Fastly-Debug-Path, "");`,
+	},
+	"vcl_deliver": {
+		"if (resp.status >= 500 && resp.status < 600) {",
+		"  /* restart if the stale object is available */",
+		"  if (stale.exists) {",
+		"    restart;",
+		"  }",
+		"}",
+	},
+}
+
+// Render renders the VCL a service snapshot generates, as a local preview of
+// GetGeneratedVCL for changes which have not been made yet.
+//
+// The output starts with the declarations of the backends, directors, ACLs,
+// dictionaries and init snippets, followed by the main custom VCL or, without
+// one, the default boilerplate. Its includes are inlined and each "#FASTLY"
+// macro is expanded, between "#--FASTLY <SUB> BEGIN" and "#--FASTLY <SUB>
+// END" markers, to the snippets of the matching type ordered by priority and
+// then to the code of the service objects: default and conditional backends,
+// request settings, headers, response objects, gzip and cache settings. The
+// code Fastly adds to every service, such as request timing and the debug
+// path, comes first.
+// Request and cache settings with a condition come before the others, whose
+// action would otherwise shadow them.
+//
+// The code between the markers matches the VCL generated by Fastly for a
+// service without shielding, apart from comments and whitespace. The rest is
+// not byte-identical to it: Fastly declares backend defaults and adds internal
+// code, such as clustering, and the entries of ACLs and dictionaries are not
+// part of the snapshot. Request settings are rendered for their action, default host,
+// forced miss or TLS, hash keys and X-Forwarded-For handling only.
+func Render(s *Service) (string, error) {
+	if s == nil {
+		s = &Service{}
+	}
+	r := &renderer{conditions: make(map[string]*fastly.Condition), s: s}
+	for _, c := range s.Conditions {
+		r.conditions[fastly.ToValue(c.Name)] = c
+	}
+	return r.render()
+}
+
+// renderer holds the state of Render.
+type renderer struct {
+	conditions map[string]*fastly.Condition
+	out        []string
+	s          *Service
+}
+
+// render renders the whole service.
+func (r *renderer) render() (string, error) {
+	r.declarations()
+
+	main := defaultVCL
+	mains := 0
+	for _, v := range r.s.VCLs {
+		if fastly.ToValue(v.Main) {
+			main = fastly.ToValue(v.Content)
+			mains++
+		}
+	}
+	if mains > 1 {
+		return "", fmt.Errorf("vcl: %d VCLs are marked as main", mains)
+	}
+
+	lines, err := r.inline(main, nil)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range lines {
+		m := macroLine.FindStringSubmatch(line)
+		if m == nil {
+			r.out = append(r.out, line)
+			continue
+		}
+		sub := "vcl_" + strings.ToLower(m[2])
+		code, err := r.subCode(sub)
+		if err != nil {
+			return "", err
+		}
+		marker := strings.ToUpper(strings.TrimPrefix(sub, "vcl_"))
+		body := m[1]
+		if body == "" {
+			body = "  "
+		}
+		r.out = append(r.out, m[1]+"#--FASTLY "+marker+" BEGIN")
+		r.out = append(r.out, indent(body, code)...)
+		r.out = append(r.out, m[1]+"#--FASTLY "+marker+" END")
+	}
+	return strings.Join(r.out, "\n") + "\n", nil
+}
+
+// macroLine matches a line made of a "#FASTLY" macro.
+var macroLine = regexp.MustCompile(`(?i)^(\s*)#\s*FASTLY\s+(recv|hash|hit|miss|pass|fetch|error|deliver|log)\s*$`)
+
+// includeLine matches a line made of an include.
+var includeLine = regexp.MustCompile(`^(\s*)include\s+"([^"]+)"\s*;\s*$`)
+
+// inline splits src into lines, replacing each include of a custom VCL or of
+// a "snippet::<name>" with its content, indented like the include.
+func (r *renderer) inline(src string, seen []string) ([]string, error) {
+	var lines []string
+	for line := range strings.SplitSeq(strings.TrimRight(src, "\n"), "\n") {
+		m := includeLine.FindStringSubmatch(line)
+		if m == nil {
+			lines = append(lines, line)
+			continue
+		}
+		name := m[2]
+		if slices.Contains(seen, name) {
+			return nil, fmt.Errorf("vcl: include cycle through %q", name)
+		}
+		content, ok := r.includable(name)
+		if !ok {
+			return nil, fmt.Errorf("vcl: include of unknown VCL %q", name)
+		}
+		inlined, err := r.inline(content, append(slices.Clone(seen), name))
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, indent(m[1], inlined)...)
+	}
+	return lines, nil
+}
+
+// includable returns the content of the custom VCL or snippet included with
+// the given name.
+func (r *renderer) includable(name string) (string, bool) {
+	if sn, ok := strings.CutPrefix(name, "snippet::"); ok {
+		for _, s := range r.s.Snippets {
+			if fastly.ToValue(s.Name) == sn {
+				return fastly.ToValue(s.Content), true
+			}
+		}
+		return "", false
+	}
+	for _, v := range r.s.VCLs {
+		if fastly.ToValue(v.Name) == name {
+			return fastly.ToValue(v.Content), true
+		}
+	}
+	return "", false
+}
+
+// declarations renders the objects declared before the main VCL.
+func (r *renderer) declarations() {
+	for _, b := range sortedByName(r.s.Backends, func(b *fastly.Backend) string { return fastly.ToValue(b.Name) }) {
+		r.out = append(r.out, "backend "+BackendName(fastly.ToValue(b.Name))+" {")
+		r.field("host", Quote(fastly.ToValue(b.Address)), b.Address != nil)
+		r.field("port", Quote(strconv.Itoa(fastly.ToValue(b.Port))), b.Port != nil)
+		r.field("ssl", "true", fastly.ToValue(b.UseSSL))
+		r.field("ssl_cert_hostname", Quote(fastly.ToValue(b.SSLCertHostname)), fastly.ToValue(b.SSLCertHostname) != "")
+		r.field("ssl_sni_hostname", Quote(fastly.ToValue(b.SSLSNIHostname)), fastly.ToValue(b.SSLSNIHostname) != "")
+		r.field("ssl_check_cert", "always", fastly.ToValue(b.SSLCheckCert))
+		r.field("host_header", Quote(fastly.ToValue(b.OverrideHost)), fastly.ToValue(b.OverrideHost) != "")
+		r.field("connect_timeout", timeout(fastly.ToValue(b.ConnectTimeout)), b.ConnectTimeout != nil)
+		r.field("first_byte_timeout", timeout(fastly.ToValue(b.FirstByteTimeout)), b.FirstByteTimeout != nil)
+		r.field("between_bytes_timeout", timeout(fastly.ToValue(b.BetweenBytesTimeout)), b.BetweenBytesTimeout != nil)
+		r.field("max_connections", strconv.Itoa(fastly.ToValue(b.MaxConn)), b.MaxConn != nil)
+		r.out = append(r.out, "}", "")
+	}
+
+	for _, d := range sortedByName(r.s.Directors, func(d *fastly.Director) string { return fastly.ToValue(d.Name) }) {
+		typ := directorTypes[fastly.ToValue(d.Type)]
+		if typ == "" {
+			typ = "random"
+		}
+		r.out = append(r.out, "director "+fastly.ToValue(d.Name)+" "+typ+" {")
+		r.field("quorum", fmt.Sprintf("%d%%", fastly.ToValue(d.Quorum)), d.Quorum != nil)
+		r.field("retries", strconv.Itoa(fastly.ToValue(d.Retries)), d.Retries != nil)
+		for _, b := range d.Backends {
+			r.out = append(r.out, "  { .backend = "+BackendName(b)+"; .weight = 100; }")
+		}
+		r.out = append(r.out, "}", "")
+	}
+
+	for _, a := range sortedByName(r.s.ACLs, func(a *fastly.ACL) string { return fastly.ToValue(a.Name) }) {
+		r.out = append(r.out, "acl "+fastly.ToValue(a.Name)+" {", "}", "")
+	}
+	for _, d := range sortedByName(r.s.Dictionaries, func(d *fastly.Dictionary) string { return fastly.ToValue(d.Name) }) {
+		r.out = append(r.out, "table "+fastly.ToValue(d.Name)+" {", "}", "")
+	}
+
+	for _, sn := range r.snippets(fastly.SnippetTypeInit) {
+		r.out = append(r.out, snippetComment(sn))
+		r.out = append(r.out, strings.Split(strings.TrimRight(fastly.ToValue(sn.Content), "\n"), "\n")...)
+		r.out = append(r.out, "")
+	}
+}
+
+// field renders a field of an object body if ok is true.
+func (r *renderer) field(name, value string, ok bool) {
+	if ok {
+		r.out = append(r.out, "  ."+name+" = "+value+";")
+	}
+}
+
+// subCode returns the code a "#FASTLY" macro expands to in a builtin
+// subroutine.
+func (r *renderer) subCode(sub string) ([]string, error) {
+	code := slices.Clone(fastlyCode[sub])
+	for _, sn := range r.snippets(fastly.SnippetType(strings.TrimPrefix(sub, "vcl_"))) {
+		code = append(code, snippetComment(sn))
+		code = append(code, strings.Split(strings.TrimRight(fastly.ToValue(sn.Content), "\n"), "\n")...)
+	}
+
+	var (
+		objects []string
+		err     error
+	)
+	switch sub {
+	case "vcl_recv":
+		objects, err = r.recvCode()
+	case "vcl_hash":
+		objects, err = r.hashCode()
+	case "vcl_miss", "vcl_pass":
+		objects, err = r.headerCode(fastly.HeaderTypeFetch)
+	case "vcl_fetch":
+		objects, err = r.fetchCode()
+	case "vcl_error":
+		objects = r.errorCode()
+	case "vcl_deliver":
+		objects, err = r.headerCode(fastly.HeaderTypeResponse)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(code, objects...), nil
+}
+
+// recvCode renders the backends, request settings, request headers and
+// response objects applied in vcl_recv.
+func (r *renderer) recvCode() ([]string, error) {
+	code := []string{
+		"declare local var.fastly_req_do_shield BOOL;",
+		"set var.fastly_req_do_shield = (req.restarts == 0);",
+	}
+	backends := sortedByName(r.s.Backends, func(b *fastly.Backend) string { return fastly.ToValue(b.Name) })
+	for _, b := range backends {
+		if fastly.ToValue(b.RequestCondition) == "" {
+			code = append(code, "# default conditions", "set req.backend = "+BackendName(fastly.ToValue(b.Name))+";", "# end default conditions")
+			break
+		}
+	}
+	for _, b := range backends {
+		if name := fastly.ToValue(b.RequestCondition); name != "" {
+			c, err := r.when("backend", fastly.ToValue(b.Name), name, []string{"set req.backend = " + BackendName(fastly.ToValue(b.Name)) + ";"})
+			if err != nil {
+				return nil, err
+			}
+			code = append(code, c...)
+		}
+	}
+
+	settings := sortedByName(r.s.RequestSettings, func(rs *fastly.RequestSetting) string { return fastly.ToValue(rs.Name) })
+	for _, rs := range conditionalFirst(settings, func(rs *fastly.RequestSetting) *string { return rs.RequestCondition }) {
+		var body []string
+		if fastly.ToValue(rs.ForceSSL) {
+			body = append(body, "if (!req.is_ssl) {", `  error 801 "Force SSL";`, "}")
+		}
+		if host := fastly.ToValue(rs.DefaultHost); host != "" {
+			body = append(body, "set req.http.host = "+Quote(host)+";")
+		}
+		if fastly.ToValue(rs.ForceMiss) {
+			body = append(body, "set req.hash_always_miss = true;")
+		}
+		switch fastly.ToValue(rs.XForwardedFor) {
+		case fastly.RequestSettingXFFClear:
+			body = append(body, "unset req.http.X-Forwarded-For;")
+		case fastly.RequestSettingXFFOverwrite:
+			body = append(body, "set req.http.X-Forwarded-For = client.ip;")
+		case fastly.RequestSettingXFFAppend, fastly.RequestSettingXFFAppendAll:
+			body = append(body, `set req.http.X-Forwarded-For = if(req.http.X-Forwarded-For, req.http.X-Forwarded-For ", ", "") client.ip;`)
+		}
+		c, err := r.when("request setting", fastly.ToValue(rs.Name), fastly.ToValue(rs.RequestCondition), body)
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, c...)
+	}
+
+	headers, err := r.headerCode(fastly.HeaderTypeRequest)
+	if err != nil {
+		return nil, err
+	}
+	code = append(code, headers...)
+
+	for _, ro := range r.responseObjects() {
+		name := fastly.ToValue(ro.RequestCondition)
+		if name == "" && fastly.ToValue(ro.CacheCondition) != "" {
+			continue
+		}
+		c, err := r.when("response object", fastly.ToValue(ro.Name), name, []string{responseError(ro)})
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, c...)
+	}
+
+	// Actions end vcl_recv, so they come after the request headers and
+	// response objects, which would otherwise never run.
+	for _, rs := range conditionalFirst(settings, func(rs *fastly.RequestSetting) *string { return rs.RequestCondition }) {
+		action := fastly.ToValue(rs.Action)
+		if action == fastly.RequestSettingActionUnset {
+			continue
+		}
+		c, err := r.when("request setting", fastly.ToValue(rs.Name)+" action", fastly.ToValue(rs.RequestCondition), []string{"return(" + string(action) + ");"})
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, c...)
+	}
+	return code, nil
+}
+
+// hashCode renders the hash keys of the request settings.
+func (r *renderer) hashCode() ([]string, error) {
+	var code []string
+	for _, rs := range sortedByName(r.s.RequestSettings, func(rs *fastly.RequestSetting) string { return fastly.ToValue(rs.Name) }) {
+		var body []string
+		for key := range strings.SplitSeq(fastly.ToValue(rs.HashKeys), ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				body = append(body, "set req.hash += "+key+";")
+			}
+		}
+		c, err := r.when("request setting", fastly.ToValue(rs.Name), fastly.ToValue(rs.RequestCondition), body)
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, c...)
+	}
+	return code, nil
+}
+
+// fetchCode renders the cache headers, gzip, response objects and cache
+// settings applied in vcl_fetch.
+func (r *renderer) fetchCode() ([]string, error) {
+	code, err := r.headerCode(fastly.HeaderTypeCache)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range sortedByName(r.s.Gzips, func(g *fastly.Gzip) string { return fastly.ToValue(g.Name) }) {
+		var match []string
+		if types := strings.Fields(fastly.ToValue(g.ContentTypes)); len(types) > 0 {
+			for n, t := range types {
+				types[n] = regexp.QuoteMeta(t)
+			}
+			match = append(match, `beresp.http.content-type ~ "^(`+strings.Join(types, "|")+`)\s*($|;)"`)
+		}
+		if exts := strings.Fields(fastly.ToValue(g.Extensions)); len(exts) > 0 {
+			for n, e := range exts {
+				exts[n] = regexp.QuoteMeta(e)
+			}
+			match = append(match, `req.url.ext ~ "(?i)^(`+strings.Join(exts, "|")+`)$"`)
+		}
+		if len(match) == 0 {
+			continue
+		}
+		// Responses are compressed only for clients accepting gzip, so the
+		// cached object must vary on Accept-Encoding.
+		body := []string{
+			"if ((beresp.status == 200 || beresp.status == 404) && (" + strings.Join(match, " || ") + ")) {",
+			`  if (!beresp.http.Vary ~ "Accept-Encoding") {`,
+			"    if (beresp.http.Vary) {",
+			`      set beresp.http.Vary = beresp.http.Vary ", Accept-Encoding";`,
+			"    } else {",
+			`      set beresp.http.Vary = "Accept-Encoding";`,
+			"    }",
+			"  }",
+			`  if (req.http.Accept-Encoding == "gzip") {`,
+			"    set beresp.gzip = true;",
+			"  }",
+			"}",
+		}
+		c, err := r.when("gzip", fastly.ToValue(g.Name), fastly.ToValue(g.CacheCondition), body)
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, c...)
+	}
+
+	for _, ro := range r.responseObjects() {
+		if name := fastly.ToValue(ro.CacheCondition); name != "" {
+			c, err := r.when("response object", fastly.ToValue(ro.Name), name, []string{responseError(ro)})
+			if err != nil {
+				return nil, err
+			}
+			code = append(code, c...)
+		}
+	}
+
+	settings := sortedByName(r.s.CacheSettings, func(cs *fastly.CacheSetting) string { return fastly.ToValue(cs.Name) })
+	for _, cs := range conditionalFirst(settings, func(cs *fastly.CacheSetting) *string { return cs.CacheCondition }) {
+		var body []string
+		if cs.TTL != nil {
+			body = append(body, fmt.Sprintf("set beresp.ttl = %ds;", *cs.TTL))
+		}
+		if cs.StaleTTL != nil {
+			body = append(body, fmt.Sprintf("set beresp.grace = %ds;", *cs.StaleTTL))
+		}
+		switch fastly.ToValue(cs.Action) {
+		case fastly.CacheSettingActionCache:
+			body = append(body, "return(deliver);")
+		case fastly.CacheSettingActionPass:
+			body = append(body, "return(pass);")
+		case fastly.CacheSettingActionRestart:
+			body = append(body, "restart;")
+		}
+		c, err := r.when("cache setting", fastly.ToValue(cs.Name), fastly.ToValue(cs.CacheCondition), body)
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, c...)
+	}
+	return code, nil
+}
+
+// errorCode renders the synthetic responses of the response objects, and the
+// redirection of requests forced to TLS.
+func (r *renderer) errorCode() []string {
+	var code []string
+	if slices.ContainsFunc(r.s.RequestSettings, func(rs *fastly.RequestSetting) bool { return fastly.ToValue(rs.ForceSSL) }) {
+		code = append(code,
+			"if (obj.status == 801) {",
+			"  set obj.status = 301;",
+			`  set obj.response = "Moved Permanently";`,
+			`  set obj.http.Location = "https://" req.http.host req.url;`,
+			`  synthetic {""};`,
+			"  return(deliver);",
+			"}",
+		)
+	}
+	for _, ro := range r.responseObjects() {
+		code = append(code, fmt.Sprintf("if (obj.status == %d && obj.response == %s) {", responseStatus(ro), Quote(responseText(ro))))
+		if ct := fastly.ToValue(ro.ContentType); ct != "" {
+			code = append(code, "  set obj.http.Content-Type = "+Quote(ct)+";")
+		}
+		code = append(code, "  synthetic "+longString(fastly.ToValue(ro.Content))+";", "  return(deliver);", "}")
+	}
+	return code
+}
+
+// headerCode renders the header objects of the given type, ordered by
+// priority and then by name.
+func (r *renderer) headerCode(typ fastly.HeaderType) ([]string, error) {
+	var headers []*fastly.Header
+	for _, h := range r.s.Headers {
+		if fastly.ToValue(h.Type) == typ {
+			headers = append(headers, h)
+		}
+	}
+	slices.SortStableFunc(headers, func(a, b *fastly.Header) int {
+		return cmp.Or(cmp.Compare(fastly.ToValue(a.Priority), fastly.ToValue(b.Priority)), cmp.Compare(fastly.ToValue(a.Name), fastly.ToValue(b.Name)))
+	})
+
+	prefix := map[fastly.HeaderType]string{
+		fastly.HeaderTypeRequest:  "req.",
+		fastly.HeaderTypeFetch:    "bereq.",
+		fastly.HeaderTypeCache:    "beresp.",
+		fastly.HeaderTypeResponse: "resp.",
+	}[typ]
+
+	var code []string
+	for _, h := range headers {
+		dst := prefix + fastly.ToValue(h.Destination)
+		src := fastly.ToValue(h.Source)
+		var body []string
+		switch fastly.ToValue(h.Action) {
+		case fastly.HeaderActionSet:
+			body = []string{"set " + dst + " = " + src + ";"}
+		case fastly.HeaderActionAppend:
+			body = []string{"set " + dst + " = " + dst + " " + src + ";"}
+		case fastly.HeaderActionDelete:
+			body = []string{"unset " + dst + ";"}
+		case fastly.HeaderActionRegex:
+			body = []string{"set " + dst + " = regsub(" + src + ", " + Quote(fastly.ToValue(h.Regex)) + ", " + Quote(fastly.ToValue(h.Substitution)) + ");"}
+		case fastly.HeaderActionRegexRepeat:
+			body = []string{"set " + dst + " = regsuball(" + src + ", " + Quote(fastly.ToValue(h.Regex)) + ", " + Quote(fastly.ToValue(h.Substitution)) + ");"}
+		}
+		if fastly.ToValue(h.IgnoreIfSet) && len(body) > 0 {
+			body = append([]string{"if (!" + dst + ") {"}, append(indent("  ", body), "}")...)
+		}
+
+		condition := fastly.ToValue(h.RequestCondition)
+		switch typ {
+		case fastly.HeaderTypeCache:
+			condition = fastly.ToValue(h.CacheCondition)
+		case fastly.HeaderTypeResponse:
+			condition = fastly.ToValue(h.ResponseCondition)
+		}
+		c, err := r.when("header", fastly.ToValue(h.Name), condition, body)
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, c...)
+	}
+	return code, nil
+}
+
+// when wraps the code of an object in its condition, if any.
+func (r *renderer) when(kind, name, condition string, body []string) ([]string, error) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+	code := []string{"# " + kind + " " + name}
+	if condition == "" {
+		return append(code, body...), nil
+	}
+	c, ok := r.conditions[condition]
+	if !ok {
+		return nil, fmt.Errorf("vcl: %s %q: undefined condition %q", kind, name, condition)
+	}
+	code = append(code, "if ("+fastly.ToValue(c.Statement)+") {")
+	code = append(code, indent("  ", body)...)
+	return append(code, "}"), nil
+}
+
+// snippets returns the snippets of the given type, ordered by priority and
+// then by name.
+func (r *renderer) snippets(typ fastly.SnippetType) []*fastly.Snippet {
+	var snippets []*fastly.Snippet
+	for _, s := range r.s.Snippets {
+		if fastly.ToValue(s.Type) == typ {
+			snippets = append(snippets, s)
+		}
+	}
+	slices.SortStableFunc(snippets, func(a, b *fastly.Snippet) int {
+		return cmp.Or(cmp.Compare(snippetPriority(a), snippetPriority(b)), cmp.Compare(fastly.ToValue(a.Name), fastly.ToValue(b.Name)))
+	})
+	return snippets
+}
+
+// responseObjects returns the response objects ordered by name.
+func (r *renderer) responseObjects() []*fastly.ResponseObject {
+	return sortedByName(r.s.ResponseObjects, func(ro *fastly.ResponseObject) string { return fastly.ToValue(ro.Name) })
+}
+
+// snippetPriority returns the priority of a snippet, 100 by default.
+func snippetPriority(s *fastly.Snippet) int {
+	p, err := strconv.Atoi(fastly.ToValue(s.Priority))
+	if err != nil {
+		return 100
+	}
+	return p
+}
+
+// snippetComment returns the comment introducing a snippet.
+func snippetComment(s *fastly.Snippet) string {
+	return fmt.Sprintf("# Snippet %s : %d", fastly.ToValue(s.Name), snippetPriority(s))
+}
+
+// timeout returns a backend timeout given in milliseconds as a VCL duration,
+// in seconds when it is a whole number of them, as Fastly writes it.
+func timeout(ms int) string {
+	if ms%1000 == 0 {
+		return fmt.Sprintf("%ds", ms/1000)
+	}
+	return fmt.Sprintf("%dms", ms)
+}
+
+// responseError returns the error statement triggering a response object.
+func responseError(ro *fastly.ResponseObject) string {
+	return fmt.Sprintf("error %d %s;", responseStatus(ro), Quote(responseText(ro)))
+}
+
+// responseStatus returns the status of a response object, 200 by default.
+func responseStatus(ro *fastly.ResponseObject) int {
+	if ro.Status == nil {
+		return 200
+	}
+	return *ro.Status
+}
+
+// responseText returns the response text of a response object, "OK" by
+// default.
+func responseText(ro *fastly.ResponseObject) string {
+	if t := fastly.ToValue(ro.Response); t != "" {
+		return t
+	}
+	return "OK"
+}
+
+// sortedByName returns a copy of objects ordered by name.
+func sortedByName[T any](objects []T, name func(T) string) []T {
+	return slices.SortedStableFunc(slices.Values(objects), func(a, b T) int {
+		return cmp.Compare(name(a), name(b))
+	})
+}
+
+// conditionalFirst returns a copy of settings with the settings which have a
+// condition first, so that an unconditional action does not shadow them.
+func conditionalFirst[T any](settings []T, condition func(T) *string) []T {
+	return slices.SortedStableFunc(slices.Values(settings), func(a, b T) int {
+		return cmp.Compare(unconditional(fastly.ToValue(condition(a))), unconditional(fastly.ToValue(condition(b))))
+	})
+}
+
+// unconditional returns 1 for an empty condition name and 0 otherwise.
+func unconditional(condition string) int {
+	if condition == "" {
+		return 1
+	}
+	return 0
+}
+
+// indent prefixes every non-empty line with prefix.
+func indent(prefix string, lines []string) []string {
+	out := make([]string, len(lines))
+	for n, line := range lines {
+		if line != "" {
+			line = prefix + line
+		}
+		out[n] = line
+	}
+	return out
+}
+
+// Quote returns s as a VCL string literal which decodes back to s. Plain
+// strings decode %XX escapes and cannot hold a double quote or a newline, so
+// the {"long"} form is used when s contains any of those, or, if s also
+// contains the "} ending a long string, a plain string with percent escapes.
+func Quote(s string) string {
+	if strings.ContainsAny(s, "\"\n\r%") {
+		return longString(s)
+	}
+	return `"` + s + `"`
+}
+
+// percentEscaper escapes the characters a plain VCL string cannot hold
+// literally.
+var percentEscaper = strings.NewReplacer("%", "%25", `"`, "%22", "\n", "%0A", "\r", "%0D")
+
+// longString returns s as a {"long"} VCL string literal. A long string ends
+// at the first "}, so if s contains one, it is written as a plain string with
+// percent escapes instead.
+func longString(s string) string {
+	if strings.Contains(s, `"}`) {
+		return `"` + percentEscaper.Replace(s) + `"`
+	}
+	return `{"` + s + `"}`
+}
@@ -0,0 +1,225 @@
+package vcl
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// testRenderService returns a service using every kind of object rendered.
+// The generated_vcl fixture records the VCL the API generates for it.
+func testRenderService() *Service {
+	return &Service{
+		ACLs: []*fastly.ACL{{Name: fastly.ToPointer("office")}},
+		Backends: []*fastly.Backend{
+			{
+				Address:          fastly.ToPointer("origin.example.com"),
+				ConnectTimeout:   fastly.ToPointer(1000),
+				FirstByteTimeout: fastly.ToPointer(15000),
+				Name:             fastly.ToPointer("origin"),
+				OverrideHost:     fastly.ToPointer("www.example.com"),
+				Port:             fastly.ToPointer(443),
+				SSLCertHostname:  fastly.ToPointer("origin.example.com"),
+				SSLCheckCert:     fastly.ToPointer(true),
+				UseSSL:           fastly.ToPointer(true),
+			},
+			{Address: fastly.ToPointer("api.example.com"), Name: fastly.ToPointer("api-1"), Port: fastly.ToPointer(80), RequestCondition: fastly.ToPointer("is api")},
+		},
+		CacheSettings: []*fastly.CacheSetting{
+			{Action: fastly.ToPointer(fastly.CacheSettingActionPass), CacheCondition: fastly.ToPointer("no store"), Name: fastly.ToPointer("pass private")},
+			{Action: fastly.ToPointer(fastly.CacheSettingActionCache), Name: fastly.ToPointer("default ttl"), StaleTTL: fastly.ToPointer(60), TTL: fastly.ToPointer(300)},
+		},
+		Conditions: []*fastly.Condition{
+			testCondition("is api", "REQUEST", `req.url ~ "^/api/"`),
+			testCondition("no store", "CACHE", `beresp.http.Cache-Control ~ "no-store"`),
+			testCondition("is error", "RESPONSE", `resp.status >= 500`),
+			testCondition("is gone", "REQUEST", `req.url.path == "/gone"`),
+		},
+		Dictionaries: []*fastly.Dictionary{{Name: fastly.ToPointer("redirects")}},
+		Directors: []*fastly.Director{
+			{Backends: []string{"origin", "api-1"}, Name: fastly.ToPointer("pool"), Quorum: fastly.ToPointer(50), Retries: fastly.ToPointer(3), Type: fastly.ToPointer(fastly.DirectorTypeRandom)},
+		},
+		Gzips: []*fastly.Gzip{
+			{ContentTypes: fastly.ToPointer("text/html application/xml+rss"), Extensions: fastly.ToPointer("css js"), Name: fastly.ToPointer("gzip")},
+		},
+		Headers: []*fastly.Header{
+			{Action: fastly.ToPointer(fastly.HeaderActionDelete), Destination: fastly.ToPointer("http.Server"), Name: fastly.ToPointer("no server"), Priority: fastly.ToPointer(10), Type: fastly.ToPointer(fastly.HeaderTypeResponse)},
+			{Action: fastly.ToPointer(fastly.HeaderActionSet), Destination: fastly.ToPointer("http.X-Error"), Name: fastly.ToPointer("error flag"), Priority: fastly.ToPointer(20), ResponseCondition: fastly.ToPointer("is error"), Source: fastly.ToPointer(`"1"`), Type: fastly.ToPointer(fastly.HeaderTypeResponse)},
+			{Action: fastly.ToPointer(fastly.HeaderActionSet), Destination: fastly.ToPointer("http.X-Client-IP"), IgnoreIfSet: fastly.ToPointer(true), Name: fastly.ToPointer("client ip"), Priority: fastly.ToPointer(10), Source: fastly.ToPointer("client.ip"), Type: fastly.ToPointer(fastly.HeaderTypeRequest)},
+			{Action: fastly.ToPointer(fastly.HeaderActionRegex), Destination: fastly.ToPointer("url"), Name: fastly.ToPointer("strip prefix"), Priority: fastly.ToPointer(10), Regex: fastly.ToPointer("^/api"), Source: fastly.ToPointer("bereq.url"), Substitution: fastly.ToPointer(""), Type: fastly.ToPointer(fastly.HeaderTypeFetch)},
+			{Action: fastly.ToPointer(fastly.HeaderActionAppend), Destination: fastly.ToPointer("http.Vary"), Name: fastly.ToPointer("vary"), Priority: fastly.ToPointer(10), Source: fastly.ToPointer(`", Accept-Language"`), Type: fastly.ToPointer(fastly.HeaderTypeCache)},
+		},
+		RequestSettings: []*fastly.RequestSetting{
+			{Action: fastly.ToPointer(fastly.RequestSettingActionPass), Name: fastly.ToPointer("api"), RequestCondition: fastly.ToPointer("is api"), XForwardedFor: fastly.ToPointer(fastly.RequestSettingXFFAppend)},
+			{Action: fastly.ToPointer(fastly.RequestSettingActionLookup), DefaultHost: fastly.ToPointer("www.example.com"), ForceSSL: fastly.ToPointer(true), HashKeys: fastly.ToPointer("req.url, req.http.Accept-Language"), Name: fastly.ToPointer("defaults")},
+		},
+		ResponseObjects: []*fastly.ResponseObject{
+			{Content: fastly.ToPointer("<h1>Gone</h1>"), ContentType: fastly.ToPointer("text/html"), Name: fastly.ToPointer("gone"), RequestCondition: fastly.ToPointer("is gone"), Response: fastly.ToPointer("Gone"), Status: fastly.ToPointer(410)},
+		},
+		Snippets: []*fastly.Snippet{
+			testSnippet("geo", fastly.SnippetTypeRecv, `set req.http.X-Country = client.geo.country_code;`),
+			{Content: fastly.ToPointer(`set req.http.X-First = "1";`), Name: fastly.ToPointer("first"), Priority: fastly.ToPointer("5"), Type: fastly.ToPointer(fastly.SnippetTypeRecv)},
+			testSnippet("helpers", fastly.SnippetTypeInit, "sub strip_cookies {\n  unset req.http.Cookie;\n}"),
+			testSnippet("timing", fastly.SnippetTypeLog, `log "syslog " req.service_id " timing :: " time.elapsed.msec;`),
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	custom := testRenderService()
+	custom.Snippets = append(custom.Snippets, testSnippet("shared", fastly.SnippetTypeNone, "set req.http.X-Shared = \"1\";"))
+	custom.VCLs = []*fastly.VCL{
+		{Main: fastly.ToPointer(true), Name: fastly.ToPointer("main"), Content: fastly.ToPointer(`include "helpers";
+
+sub vcl_recv {
+  #FASTLY recv
+  include "snippet::shared";
+  call strip_cookies;
+  return(lookup);
+}
+
+sub vcl_deliver {
+  #FASTLY deliver
+  return(deliver);
+}
+`)},
+		{Name: fastly.ToPointer("helpers"), Content: fastly.ToPointer("sub add_debug {\n  set resp.http.X-Debug = \"1\";\n}\n")},
+	}
+
+	for name, svc := range map[string]*Service{
+		"default.vcl": testRenderService(),
+		"custom.vcl":  custom,
+	} {
+		got, err := Render(svc)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		path := filepath.Join("testdata", name)
+		if *updateGolden {
+			if err := os.WriteFile(path, []byte(got), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := os.ReadFile(path) // #nosec G304 -- the path is a constant of the test
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(string(want), got); diff != "" {
+			t.Errorf("%s: output differs from the golden file (run with -update to refresh it): %s", name, diff)
+		}
+
+		// The rendered VCL must itself be valid: it parses, and linting it on
+		// its own finds no errors.
+		diags := Lint(&Service{VCLs: []*fastly.VCL{{Content: &got, Main: fastly.ToPointer(true), Name: fastly.ToPointer("generated")}}})
+		if HasErrors(diags) {
+			t.Errorf("%s: rendered VCL has errors: %v", name, diags)
+		}
+	}
+}
+
+// blockComment matches a /* */ comment.
+var blockComment = regexp.MustCompile(`/\*.*?\*/`)
+
+// fastlyBlocks returns the code between the #--FASTLY BEGIN and END markers
+// of a VCL, by subroutine, with comments and blank lines removed and
+// whitespace normalized.
+func fastlyBlocks(vcl string) map[string][]string {
+	blocks := make(map[string][]string)
+	block := ""
+	for line := range strings.Lines(vcl) {
+		line = strings.TrimSpace(blockComment.ReplaceAllString(line, ""))
+		if marker, ok := strings.CutPrefix(line, "#--FASTLY "); ok {
+			block, _ = strings.CutSuffix(marker, " BEGIN")
+			if strings.HasSuffix(marker, " END") {
+				block = ""
+			}
+			continue
+		}
+		if block == "" || line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		line = strings.ReplaceAll(strings.Join(strings.Fields(line), " "), " (", "(")
+		blocks[block] = append(blocks[block], line)
+	}
+	return blocks
+}
+
+func TestRender_generatedVCL(t *testing.T) {
+	t.Parallel()
+
+	var (
+		generated *fastly.VCL
+		err       error
+	)
+	fastly.Record(t, "generated_vcl", func(c *fastly.Client) {
+		generated, err = c.GetGeneratedVCL(context.TODO(), &fastly.GetGeneratedVCLInput{
+			ServiceID:      "7i6HN3TK9wS159v2gPAZ8A",
+			ServiceVersion: 3,
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Render(testRenderService())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The declarations and the boilerplate around the #--FASTLY blocks
+	// differ, but the code within the blocks must match.
+	want := fastlyBlocks(fastly.ToValue(generated.Content))
+	if len(want) != 9 {
+		t.Fatalf("found %d #--FASTLY blocks in the generated VCL, want 9", len(want))
+	}
+	if diff := cmp.Diff(want, fastlyBlocks(got)); diff != "" {
+		t.Errorf("rendered VCL differs from the generated VCL (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	t.Parallel()
+
+	for want, svc := range map[string]*Service{
+		`undefined condition "nope"`:       {Headers: []*fastly.Header{{Action: fastly.ToPointer(fastly.HeaderActionDelete), Destination: fastly.ToPointer("http.X"), Name: fastly.ToPointer("h"), RequestCondition: fastly.ToPointer("nope"), Type: fastly.ToPointer(fastly.HeaderTypeRequest)}}},
+		`include of unknown VCL "missing"`: {VCLs: []*fastly.VCL{{Main: fastly.ToPointer(true), Name: fastly.ToPointer("main"), Content: fastly.ToPointer(`include "missing";`)}}},
+		`include cycle through "a"`: {VCLs: []*fastly.VCL{
+			{Main: fastly.ToPointer(true), Name: fastly.ToPointer("main"), Content: fastly.ToPointer(`include "a";`)},
+			{Name: fastly.ToPointer("a"), Content: fastly.ToPointer(`include "a";`)},
+		}},
+		"2 VCLs are marked as main": {VCLs: []*fastly.VCL{{Main: fastly.ToPointer(true)}, {Main: fastly.ToPointer(true)}}},
+	} {
+		if _, err := Render(svc); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want %s", err, want)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{
+		"example.com": `"example.com"`,
+		`say "hi"`:    `{"say "hi""}`,
+		"100%":        `{"100%"}`,
+		`{"a"}`:       `"{%22a%22}"`,
+		"50%\n\"}":    `"50%25%0A%22}"`,
+	} {
+		if got := Quote(in); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
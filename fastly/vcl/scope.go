@@ -0,0 +1,97 @@
+package vcl
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+// BuiltinSubs are the subroutines of the Fastly request lifecycle, in the
+// order they appear in the generated VCL.
+var BuiltinSubs = []string{"vcl_recv", "vcl_hash", "vcl_hit", "vcl_miss", "vcl_pass", "vcl_fetch", "vcl_error", "vcl_deliver", "vcl_log"}
+
+// returnActions are the actions each builtin subroutine may return.
+var returnActions = map[string][]string{
+	"vcl_recv":    {"error", "lookup", "pass", "restart"},
+	"vcl_hash":    {"hash"},
+	"vcl_hit":     {"deliver", "error", "pass", "restart"},
+	"vcl_miss":    {"deliver_stale", "error", "fetch", "pass"},
+	"vcl_pass":    {"error", "pass"},
+	"vcl_fetch":   {"deliver", "deliver_stale", "error", "pass", "restart"},
+	"vcl_error":   {"deliver", "deliver_stale", "restart"},
+	"vcl_deliver": {"deliver", "restart"},
+	"vcl_log":     {"deliver"},
+}
+
+// scopedVariables are the variable prefixes only available in some
+// subroutines. Other variables, such as req.* or client.*, are available
+// everywhere.
+var scopedVariables = map[string][]string{
+	"bereq":  {"vcl_miss", "vcl_pass", "vcl_fetch"},
+	"beresp": {"vcl_fetch"},
+	"obj":    {"vcl_hit", "vcl_error", "vcl_deliver", "vcl_log"},
+	"resp":   {"vcl_deliver", "vcl_log"},
+}
+
+// IsBuiltinSub reports whether name is the name of a builtin subroutine.
+func IsBuiltinSub(name string) bool {
+	return slices.Contains(BuiltinSubs, name)
+}
+
+// ReturnActions returns the actions a builtin subroutine may return, or nil
+// for any other subroutine.
+func ReturnActions(sub string) []string {
+	return slices.Clone(returnActions[sub])
+}
+
+// VariableAvailable reports whether a variable, such as "beresp.ttl" or
+// "req.http.Host", may be used in a builtin subroutine. Variables are always
+// available in other subroutines, whose caller is unknown.
+func VariableAvailable(sub, variable string) bool {
+	if !IsBuiltinSub(sub) {
+		return true
+	}
+	prefix, _, _ := strings.Cut(variable, ".")
+	subs, ok := scopedVariables[prefix]
+	return !ok || slices.Contains(subs, sub)
+}
+
+// SnippetSub returns the subroutine a snippet of the given type is placed
+// in, or an empty string for the "init" and "none" types.
+func SnippetSub(t fastly.SnippetType) string {
+	switch t {
+	case fastly.SnippetTypeInit, fastly.SnippetTypeNone, "":
+		return ""
+	}
+	return "vcl_" + string(t)
+}
+
+// ConditionSub returns the subroutine the statement of a condition of the
+// given type (REQUEST, CACHE, RESPONSE or PREFETCH) is evaluated in, or an
+// empty string for an unknown type.
+func ConditionSub(conditionType string) string {
+	switch strings.ToUpper(conditionType) {
+	case "REQUEST":
+		return "vcl_recv"
+	case "PREFETCH":
+		return "vcl_miss"
+	case "CACHE":
+		return "vcl_fetch"
+	case "RESPONSE":
+		return "vcl_deliver"
+	}
+	return ""
+}
+
+// BackendName returns the name a backend is declared with in the generated
+// VCL: the backend name prefixed with "F_", with every character other than
+// a letter, digit or underscore replaced by an underscore.
+func BackendName(name string) string {
+	return "F_" + strings.Map(func(r rune) rune {
+		if r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,103 @@
+package vcl
+
+import (
+	"context"
+
+	"github.com/fastly/go-fastly/v17/fastly"
+)
+
+// Service is a snapshot of the VCL-related objects of a service version, as
+// checked by Lint. It can be loaded with LoadService, or built by hand from
+// local files, for example in CI before any API call.
+type Service struct {
+	// ACLs are the ACLs of the service.
+	ACLs []*fastly.ACL
+	// Backends are the backends of the service.
+	Backends []*fastly.Backend
+	// CacheSettings are the cache settings of the service.
+	CacheSettings []*fastly.CacheSetting
+	// Conditions are the conditions of the service.
+	Conditions []*fastly.Condition
+	// Dictionaries are the edge dictionaries of the service, which are
+	// declared as tables in the generated VCL.
+	Dictionaries []*fastly.Dictionary
+	// Directors are the directors of the service.
+	Directors []*fastly.Director
+	// Gzips are the gzip configurations of the service.
+	Gzips []*fastly.Gzip
+	// Headers are the header objects of the service.
+	Headers []*fastly.Header
+	// RequestSettings are the request settings of the service.
+	RequestSettings []*fastly.RequestSetting
+	// ResponseObjects are the response objects of the service.
+	ResponseObjects []*fastly.ResponseObject
+	// Snippets are the VCL snippets of the service. The content of dynamic
+	// snippets is included by LoadService.
+	Snippets []*fastly.Snippet
+	// VCLs are the custom VCL files of the service.
+	VCLs []*fastly.VCL
+}
+
+// LoadService loads the VCL-related objects of a service version, including
+// the content of its dynamic snippets.
+func LoadService(ctx context.Context, c *fastly.Client, serviceID string, serviceVersion int) (*Service, error) {
+	if serviceID == "" {
+		return nil, fastly.ErrMissingServiceID
+	}
+	if serviceVersion == 0 {
+		return nil, fastly.ErrMissingServiceVersion
+	}
+
+	var (
+		s   Service
+		err error
+	)
+	if s.ACLs, err = c.ListACLs(ctx, &fastly.ListACLsInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+	if s.Backends, err = c.ListBackends(ctx, &fastly.ListBackendsInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+	if s.CacheSettings, err = c.ListCacheSettings(ctx, &fastly.ListCacheSettingsInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+	if s.Conditions, err = c.ListConditions(ctx, &fastly.ListConditionsInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+	if s.Dictionaries, err = c.ListDictionaries(ctx, &fastly.ListDictionariesInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+	if s.Directors, err = c.ListDirectors(ctx, &fastly.ListDirectorsInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+	if s.Gzips, err = c.ListGzips(ctx, &fastly.ListGzipsInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+	if s.Headers, err = c.ListHeaders(ctx, &fastly.ListHeadersInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+	if s.RequestSettings, err = c.ListRequestSettings(ctx, &fastly.ListRequestSettingsInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+	if s.ResponseObjects, err = c.ListResponseObjects(ctx, &fastly.ListResponseObjectsInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+	if s.Snippets, err = c.ListSnippets(ctx, &fastly.ListSnippetsInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+	if s.VCLs, err = c.ListVCLs(ctx, &fastly.ListVCLsInput{ServiceID: serviceID, ServiceVersion: serviceVersion}); err != nil {
+		return nil, err
+	}
+
+	for _, sn := range s.Snippets {
+		if fastly.ToValue(sn.Dynamic) != 1 || sn.SnippetID == nil {
+			continue
+		}
+		ds, err := c.GetDynamicSnippet(ctx, &fastly.GetDynamicSnippetInput{ServiceID: serviceID, SnippetID: *sn.SnippetID})
+		if err != nil {
+			return nil, err
+		}
+		sn.Content = ds.Content
+	}
+	return &s, nil
+}
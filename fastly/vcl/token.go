@@ -0,0 +1,240 @@
+package vcl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pos is a position in a VCL source.
+type Pos struct {
+	// Column is the column number, starting at 1 and counted in bytes.
+	Column int
+	// Line is the line number, starting at 1.
+	Line int
+}
+
+// String formats the position as "line:column".
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Kind is the kind of a Token.
+type Kind int
+
+const (
+	// TokenEOF marks the end of the source.
+	TokenEOF Kind = iota
+	// TokenComment is a "#", "//" or "/* */" comment.
+	TokenComment
+	// TokenIdent is an identifier, such as a keyword, a subroutine name or a
+	// variable such as "req.http.X-Forwarded-For".
+	TokenIdent
+	// TokenNumber is a number, possibly with a unit, such as "10", "1.5", "50%"
+	// or "30s".
+	TokenNumber
+	// TokenString is a "quoted" or {"long"} string.
+	TokenString
+	// TokenOperator is an operator or punctuation, such as "==", "{" or ";".
+	TokenOperator
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case TokenEOF:
+		return "end of file"
+	case TokenComment:
+		return "comment"
+	case TokenIdent:
+		return "identifier"
+	case TokenNumber:
+		return "number"
+	case TokenString:
+		return "string"
+	case TokenOperator:
+		return "operator"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Token is a lexical token of VCL.
+type Token struct {
+	// Kind is the kind of token.
+	Kind Kind
+	// Pos is the position of the first character of the token.
+	Pos Pos
+	// Text is the text of the token as written in the source.
+	Text string
+}
+
+// Value returns the value of a String token without its delimiters, and the
+// text of any other token.
+func (t Token) Value() string {
+	if t.Kind != TokenString {
+		return t.Text
+	}
+	if strings.HasPrefix(t.Text, `{"`) {
+		return t.Text[2 : len(t.Text)-2]
+	}
+	return t.Text[1 : len(t.Text)-1]
+}
+
+// String formats the token for error messages.
+func (t Token) String() string {
+	if t.Kind == TokenEOF {
+		return t.Kind.String()
+	}
+	return fmt.Sprintf("%q", t.Text)
+}
+
+// SyntaxError is a syntax error in a VCL source.
+type SyntaxError struct {
+	// Message describes the error.
+	Message string
+	// Pos is where the error was found.
+	Pos Pos
+}
+
+// Error implements the error interface.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
+
+// operators lists the operators of VCL, longest first so that they are
+// matched greedily.
+var operators = []string{
+	"<<=", ">>=", "||=", "&&=",
+	"==", "!=", "!~", "<=", ">=", "&&", "||", "+=", "-=", "*=", "/=", "%=", "|=", "&=", "^=",
+	"{", "}", "(", ")", ";", ",", ".", "=", "~", "!", "<", ">", "+", "-", "*", "/", "%", ":", "|", "&", "^",
+}
+
+// Tokenize splits a VCL source into tokens, ending with an EOF token.
+// Comments are included, so that "#FASTLY" macros can be found.
+func Tokenize(src string) ([]Token, error) {
+	l := &lexer{src: src, line: 1, col: 1}
+	var tokens []Token
+	for {
+		t, err := l.next()
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, t)
+		if t.Kind == TokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// lexer holds the state of Tokenize.
+type lexer struct {
+	src  string
+	off  int
+	line int
+	col  int
+}
+
+// advance moves past n bytes, keeping track of lines and columns.
+func (l *lexer) advance(n int) {
+	for _, c := range []byte(l.src[l.off : l.off+n]) {
+		if c == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+	}
+	l.off += n
+}
+
+// token returns a token for the next n bytes and moves past them.
+func (l *lexer) token(kind Kind, n int) Token {
+	t := Token{Kind: kind, Pos: Pos{Line: l.line, Column: l.col}, Text: l.src[l.off : l.off+n]}
+	l.advance(n)
+	return t
+}
+
+// next returns the next token.
+func (l *lexer) next() (Token, error) {
+	for l.off < len(l.src) && strings.IndexByte(" \t\r\n", l.src[l.off]) >= 0 {
+		l.advance(1)
+	}
+	pos := Pos{Line: l.line, Column: l.col}
+	rest := l.src[l.off:]
+	if rest == "" {
+		return Token{Kind: TokenEOF, Pos: pos}, nil
+	}
+
+	switch c := rest[0]; {
+	case c == '#' || strings.HasPrefix(rest, "//"):
+		n := strings.IndexByte(rest, '\n')
+		if n < 0 {
+			n = len(rest)
+		}
+		return l.token(TokenComment, len(strings.TrimRight(rest[:n], "\r"))), nil
+	case strings.HasPrefix(rest, "/*"):
+		n := strings.Index(rest[2:], "*/")
+		if n < 0 {
+			return Token{}, &SyntaxError{Message: "unterminated comment", Pos: pos}
+		}
+		return l.token(TokenComment, n+4), nil
+	case c == '"':
+		n := strings.IndexAny(rest[1:], "\"\n")
+		if n < 0 || rest[1+n] == '\n' {
+			return Token{}, &SyntaxError{Message: "unterminated string", Pos: pos}
+		}
+		return l.token(TokenString, n+2), nil
+	case strings.HasPrefix(rest, `{"`):
+		n := strings.Index(rest[2:], `"}`)
+		if n < 0 {
+			return Token{}, &SyntaxError{Message: "unterminated long string", Pos: pos}
+		}
+		return l.token(TokenString, n+4), nil
+	case isDigit(c):
+		n := 1
+		for n < len(rest) && (isDigit(rest[n]) || rest[n] == '.') {
+			n++
+		}
+		if n < len(rest) && rest[n] == '%' {
+			n++
+		}
+		for n < len(rest) && isLetter(rest[n]) {
+			n++
+		}
+		return l.token(TokenNumber, n), nil
+	case isLetter(c) || c == '_':
+		n := 1
+		for n < len(rest) && isIdentChar(rest[n]) {
+			n++
+		}
+		// An identifier cannot end with a separator, so that "a." and "a-"
+		// are not swallowed.
+		for n > 1 && strings.IndexByte(".-:", rest[n-1]) >= 0 {
+			n--
+		}
+		return l.token(TokenIdent, n), nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			return l.token(TokenOperator, len(op)), nil
+		}
+	}
+	return Token{}, &SyntaxError{Message: fmt.Sprintf("unexpected character %q", rest[0]), Pos: pos}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isIdentChar reports whether c may appear after the first character of an
+// identifier. Dots separate the parts of a variable name, dashes appear in
+// header names and colons select a subfield, such as
+// "req.http.Cookie:session".
+func isIdentChar(c byte) bool {
+	return isLetter(c) || isDigit(c) || strings.IndexByte("_.-:", c) >= 0
+}
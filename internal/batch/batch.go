@@ -0,0 +1,80 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetryPolicy controls how Apply retries a failed batch.
+type RetryPolicy struct {
+	// BaseDelay is the delay before the first retry. Each subsequent
+	// retry doubles the delay.
+	BaseDelay time.Duration
+	// MaxRetries is the number of times a failed batch is retried.
+	MaxRetries int
+	// Retryable reports whether an error returned by a batch is
+	// transient. Errors for which it returns false are returned
+	// immediately.
+	Retryable func(error) bool
+}
+
+// Size validates an optional batch size against limit, returning
+// limit when size is nil. The second return value is false if size is
+// out of range.
+func Size(size *int, limit int) (int, bool) {
+	if size == nil {
+		return limit, true
+	}
+	if *size < 1 || *size > limit {
+		return 0, false
+	}
+	return *size, true
+}
+
+// Chunk splits s into consecutive slices of at most size elements.
+func Chunk[T any](s []T, size int) [][]T {
+	var chunks [][]T
+	for size < len(s) {
+		s, chunks = s[size:], append(chunks, s[:size:size])
+	}
+	if len(s) > 0 {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}
+
+// Apply sends items to apply in chunks of at most size elements,
+// retrying each chunk according to policy. It returns the number of
+// batches that were successfully applied.
+func Apply[T any](ctx context.Context, items []T, size int, policy RetryPolicy, apply func(context.Context, []T) error) (int, error) {
+	var applied int
+	for n, chunk := range Chunk(items, size) {
+		if err := Retry(ctx, policy, func() error { return apply(ctx, chunk) }); err != nil {
+			return applied, fmt.Errorf("failed to apply batch %d: %w", n+1, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// Retry calls fn until it succeeds, returns a non-retryable error, or
+// the policy's retries are exhausted.
+func Retry(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	delay := policy.BaseDelay
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= policy.MaxRetries || policy.Retryable == nil || !policy.Retryable(err) {
+			return err
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		delay *= 2
+	}
+}
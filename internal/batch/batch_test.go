@@ -0,0 +1,115 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChunk(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input []int
+		size  int
+		want  [][]int
+	}{
+		{name: "empty", input: nil, size: 2, want: nil},
+		{name: "exact", input: []int{1, 2, 3, 4}, size: 2, want: [][]int{{1, 2}, {3, 4}}},
+		{name: "remainder", input: []int{1, 2, 3}, size: 2, want: [][]int{{1, 2}, {3}}},
+		{name: "single", input: []int{1, 2}, size: 5, want: [][]int{{1, 2}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.want, Chunk(tc.input, tc.size)); diff != "" {
+				t.Errorf("unexpected chunks (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSize(t *testing.T) {
+	t.Parallel()
+
+	if got, ok := Size(nil, 10); !ok || got != 10 {
+		t.Errorf("expected default size, got %d (%t)", got, ok)
+	}
+	size := 5
+	if got, ok := Size(&size, 10); !ok || got != 5 {
+		t.Errorf("expected size 5, got %d (%t)", got, ok)
+	}
+	for _, size := range []int{0, 11} {
+		if _, ok := Size(&size, 10); ok {
+			t.Errorf("expected size %d to be rejected", size)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	serverErr := errors.New("server error")
+	badRequest := errors.New("bad request")
+	retryable := func(err error) bool { return errors.Is(err, serverErr) }
+
+	t.Run("retries server errors", func(t *testing.T) {
+		t.Parallel()
+
+		var calls [][]int
+		failures := 2
+		n, err := Apply(context.TODO(), []int{1, 2, 3}, 2, RetryPolicy{MaxRetries: 3, Retryable: retryable}, func(_ context.Context, batch []int) error {
+			calls = append(calls, batch)
+			if failures > 0 {
+				failures--
+				return serverErr
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 2 {
+			t.Errorf("expected 2 batches, got %d", n)
+		}
+		if len(calls) != 4 {
+			t.Errorf("expected 4 calls, got %d", len(calls))
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		n, err := Apply(context.TODO(), []int{1}, 1, RetryPolicy{MaxRetries: 1, Retryable: retryable}, func(context.Context, []int) error {
+			calls++
+			return serverErr
+		})
+		if !errors.Is(err, serverErr) {
+			t.Fatalf("expected server error, got %v", err)
+		}
+		if n != 0 || calls != 2 {
+			t.Errorf("expected 0 batches and 2 calls, got %d and %d", n, calls)
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		_, err := Apply(context.TODO(), []int{1}, 1, RetryPolicy{MaxRetries: 3, Retryable: retryable}, func(context.Context, []int) error {
+			calls++
+			return badRequest
+		})
+		if !errors.Is(err, badRequest) {
+			t.Fatalf("expected bad request error, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+}
@@ -0,0 +1,3 @@
+// Package batch provides helpers used to split large sets of changes
+// into API-sized batches and to apply those batches with retries
+package batch
@@ -0,0 +1,156 @@
+package cidr
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// Normalize returns p in canonical form: IPv4-mapped IPv6 prefixes
+// are converted to IPv4 and any host bits are cleared.
+func Normalize(p netip.Prefix) netip.Prefix {
+	addr, bits := p.Addr(), p.Bits()
+	if addr.Is4In6() && bits >= 96 {
+		addr = addr.Unmap()
+		bits -= 96
+	}
+	return netip.PrefixFrom(addr.WithZone(""), bits).Masked()
+}
+
+// ParseAddrOrPrefix parses s as either a CIDR prefix or a single
+// address, returning a normalized prefix. A single address is treated
+// as a host prefix (/32 or /128).
+func ParseAddrOrPrefix(s string) (netip.Prefix, error) {
+	if p, err := netip.ParsePrefix(s); err == nil {
+		return Normalize(p), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address or prefix %q", s)
+	}
+	return Normalize(netip.PrefixFrom(addr, addr.BitLen())), nil
+}
+
+// Collapse returns the smallest set of prefixes that matches every
+// address in exactly the same way as entries under longest-prefix-match
+// semantics, where each prefix is associated with a value (for example
+// an action, or whether the entry is negated). All prefixes in entries
+// must be normalized.
+//
+// Two kinds of reduction are applied until nothing changes:
+//
+//   - A prefix whose nearest enclosing prefix has the same value is
+//     redundant and removed. A prefix with no enclosing prefix is
+//     removed if unenclosed reports true for its value (for example a
+//     negated entry that negates nothing).
+//   - Two sibling prefixes with the same value are replaced by their
+//     parent, provided the parent is not already present with a
+//     different value.
+func Collapse[V comparable](entries map[netip.Prefix]V, unenclosed func(V) bool) map[netip.Prefix]V {
+	out := make(map[netip.Prefix]V, len(entries))
+	for p, v := range entries {
+		out[p] = v
+	}
+
+	for changed := true; changed; {
+		changed = removeRedundant(out, unenclosed)
+		if mergeSiblings(out) {
+			changed = true
+		}
+	}
+
+	return out
+}
+
+// Covering returns the index of the longest prefix of prefixes that
+// contains p, or -1 if none does. All prefixes must be normalized.
+func Covering(prefixes []netip.Prefix, p netip.Prefix) int {
+	best := -1
+	for n, q := range prefixes {
+		if q.Bits() <= p.Bits() && q.Contains(p.Addr()) && (best < 0 || q.Bits() > prefixes[best].Bits()) {
+			best = n
+		}
+	}
+	return best
+}
+
+// removeRedundant deletes every prefix whose removal does not change
+// how any address is matched, reporting whether anything was deleted.
+func removeRedundant[V comparable](entries map[netip.Prefix]V, unenclosed func(V) bool) bool {
+	var changed bool
+	for p, v := range entries {
+		pv, ok := enclosing(entries, p)
+		if (ok && pv == v) || (!ok && unenclosed != nil && unenclosed(v)) {
+			delete(entries, p)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// mergeSiblings replaces pairs of sibling prefixes having the same
+// value with their parent, reporting whether anything was merged.
+// Prefixes are visited from the longest to the shortest so merges
+// cascade within a single call.
+func mergeSiblings[V comparable](entries map[netip.Prefix]V) bool {
+	var byLen [129][]netip.Prefix
+	for p := range entries {
+		byLen[p.Bits()] = append(byLen[p.Bits()], p)
+	}
+
+	var changed bool
+	for bits := len(byLen) - 1; bits > 0; bits-- {
+		for _, p := range byLen[bits] {
+			v, ok := entries[p]
+			if !ok {
+				continue
+			}
+			s := sibling(p)
+			if sv, ok := entries[s]; !ok || sv != v {
+				continue
+			}
+			parent := netip.PrefixFrom(p.Addr(), bits-1).Masked()
+			if qv, ok := entries[parent]; ok && qv != v {
+				continue
+			}
+			delete(entries, p)
+			delete(entries, s)
+			if _, ok := entries[parent]; !ok {
+				entries[parent] = v
+				byLen[bits-1] = append(byLen[bits-1], parent)
+			}
+			changed = true
+		}
+	}
+	return changed
+}
+
+// enclosing returns the value of the longest prefix in entries that
+// strictly contains p.
+func enclosing[V any](entries map[netip.Prefix]V, p netip.Prefix) (V, bool) {
+	for bits := p.Bits() - 1; bits >= 0; bits-- {
+		if v, ok := entries[netip.PrefixFrom(p.Addr(), bits).Masked()]; ok {
+			return v, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// sibling returns the prefix of the same length that differs from p
+// only in its last network bit.
+func sibling(p netip.Prefix) netip.Prefix {
+	bits := p.Bits()
+	b := p.Addr().As16()
+	offset := 0
+	if p.Addr().Is4() {
+		offset = 96
+	}
+	i := offset + bits - 1
+	b[i/8] ^= 0x80 >> (i % 8)
+
+	addr := netip.AddrFrom16(b)
+	if p.Addr().Is4() {
+		addr = addr.Unmap()
+	}
+	return netip.PrefixFrom(addr, bits)
+}
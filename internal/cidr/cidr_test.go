@@ -0,0 +1,144 @@
+package cidr
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"10.1.2.3/8":          "10.0.0.0/8",
+		"::ffff:10.1.2.3/120": "10.1.2.0/24",
+		"2001:db8::1/32":      "2001:db8::/32",
+	}
+	for in, want := range tests {
+		if got := Normalize(netip.MustParsePrefix(in)).String(); got != want {
+			t.Errorf("Normalize(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestParseAddrOrPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"192.0.2.1":    "192.0.2.1/32",
+		"192.0.2.1/24": "192.0.2.0/24",
+		"2001:db8::1":  "2001:db8::1/128",
+	}
+	for in, want := range tests {
+		got, err := ParseAddrOrPrefix(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want {
+			t.Errorf("ParseAddrOrPrefix(%s) = %s, want %s", in, got, want)
+		}
+	}
+	if _, err := ParseAddrOrPrefix("not-an-ip"); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	t.Parallel()
+
+	negatedUnenclosed := func(negated bool) bool { return negated }
+
+	tests := []struct {
+		name  string
+		input map[string]bool
+		want  map[string]bool
+	}{
+		{
+			name:  "adjacent prefixes are merged",
+			input: map[string]bool{"10.0.0.0/25": false, "10.0.0.128/25": false},
+			want:  map[string]bool{"10.0.0.0/24": false},
+		},
+		{
+			name: "merges cascade",
+			input: map[string]bool{
+				"10.0.0.0/26": false, "10.0.0.64/26": false,
+				"10.0.0.128/26": false, "10.0.0.192/26": false,
+			},
+			want: map[string]bool{"10.0.0.0/24": false},
+		},
+		{
+			name:  "contained prefixes are dropped",
+			input: map[string]bool{"10.0.0.0/8": false, "10.1.0.0/16": false, "10.1.2.3/32": false},
+			want:  map[string]bool{"10.0.0.0/8": false},
+		},
+		{
+			name:  "negated exceptions are kept",
+			input: map[string]bool{"10.0.0.0/8": false, "10.1.0.0/16": true},
+			want:  map[string]bool{"10.0.0.0/8": false, "10.1.0.0/16": true},
+		},
+		{
+			name:  "prefix more specific than a negation is kept",
+			input: map[string]bool{"10.0.0.0/8": false, "10.0.0.0/12": true, "10.1.0.0/16": false},
+			want:  map[string]bool{"10.0.0.0/8": false, "10.0.0.0/12": true, "10.1.0.0/16": false},
+		},
+		{
+			name:  "unenclosed negation is dropped",
+			input: map[string]bool{"192.0.2.0/24": true, "10.0.0.0/8": false},
+			want:  map[string]bool{"10.0.0.0/8": false},
+		},
+		{
+			name:  "merge blocked by conflicting parent",
+			input: map[string]bool{"10.0.0.0/24": true, "10.0.0.0/25": false, "10.0.0.128/25": false, "10.0.0.0/16": false},
+			want:  map[string]bool{"10.0.0.0/16": false, "10.0.0.0/24": true, "10.0.0.0/25": false, "10.0.0.128/25": false},
+		},
+		{
+			name:  "IPv4 and IPv6 are independent",
+			input: map[string]bool{"0.0.0.0/1": false, "128.0.0.0/1": false, "2001:db8::/33": false, "2001:db8:8000::/33": false},
+			want:  map[string]bool{"0.0.0.0/0": false, "2001:db8::/32": false},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			input := make(map[netip.Prefix]bool, len(tc.input))
+			for p, v := range tc.input {
+				input[netip.MustParsePrefix(p)] = v
+			}
+			got := make(map[string]bool)
+			for p, v := range Collapse(input, negatedUnenclosed) {
+				got[p.String()] = v
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCovering(t *testing.T) {
+	t.Parallel()
+
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("10.1.0.0/16"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+	tests := []struct {
+		prefix string
+		want   int
+	}{
+		{prefix: "10.1.2.0/24", want: 1},
+		{prefix: "10.2.0.0/16", want: 0},
+		{prefix: "10.1.0.0/16", want: 1},
+		{prefix: "0.0.0.0/0", want: -1},
+		{prefix: "192.0.2.0/24", want: -1},
+		{prefix: "2001:db8:1::/48", want: 2},
+	}
+	for _, tc := range tests {
+		if got := Covering(prefixes, netip.MustParsePrefix(tc.prefix)); got != tc.want {
+			t.Errorf("Covering(%s) = %d, want %d", tc.prefix, got, tc.want)
+		}
+	}
+}
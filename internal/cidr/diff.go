@@ -0,0 +1,107 @@
+package cidr
+
+import (
+	"net/netip"
+	"sort"
+)
+
+// Operation is the kind of a Change.
+type Operation int
+
+const (
+	// Delete removes an existing entry.
+	Delete Operation = iota
+	// Update changes the value of an existing entry.
+	Update
+	// Create adds an entry for a prefix.
+	Create
+)
+
+// Entry is an existing entry passed to Diff. Prefix must be normalized, or
+// the zero value if the entry could not be parsed, in which case the entry
+// is deleted.
+type Entry[V comparable] struct {
+	Prefix netip.Prefix
+	Value  V
+}
+
+// Change is one operation returned by Diff. Index is the position of the
+// affected entry in the current entries for deletes and updates, and -1 for
+// creates. Value is the desired value for updates and creates.
+type Change[V comparable] struct {
+	Operation Operation
+	Index     int
+	Prefix    netip.Prefix
+	Value     V
+}
+
+// Diff computes the changes needed to turn the current entries into the
+// desired prefixes, and the number of entries left unchanged. Duplicate
+// entries for the same prefix are deleted. All prefixes in desired must be
+// normalized.
+//
+// Deletes that no created prefix covers are ordered first, followed by
+// updates. Each create of a prefix covering deleted entries then comes
+// before those deletes, so the addresses they match stay matched even if
+// the changes are applied in several batches and a later batch fails. The
+// remaining creates come last, sorted by prefix.
+func Diff[V comparable](current []Entry[V], desired map[netip.Prefix]V) ([]Change[V], int) {
+	var (
+		deletes   []Change[V]
+		updates   []Change[V]
+		unchanged int
+	)
+
+	seen := make(map[netip.Prefix]bool, len(current))
+	for n, e := range current {
+		v, wanted := desired[e.Prefix]
+		if !e.Prefix.IsValid() || !wanted || seen[e.Prefix] {
+			deletes = append(deletes, Change[V]{Operation: Delete, Index: n, Prefix: e.Prefix})
+			continue
+		}
+		seen[e.Prefix] = true
+		if e.Value != v {
+			updates = append(updates, Change[V]{Operation: Update, Index: n, Prefix: e.Prefix, Value: v})
+			continue
+		}
+		unchanged++
+	}
+
+	var missing []netip.Prefix
+	for p := range desired {
+		if !seen[p] {
+			missing = append(missing, p)
+		}
+	}
+	sort.Slice(missing, func(a, b int) bool {
+		if c := missing[a].Addr().Compare(missing[b].Addr()); c != 0 {
+			return c < 0
+		}
+		return missing[a].Bits() < missing[b].Bits()
+	})
+
+	changes := make([]Change[V], 0, len(deletes)+len(updates)+len(missing))
+	replaced := make([][]Change[V], len(missing))
+	for _, d := range deletes {
+		if c := Covering(missing, d.Prefix); d.Prefix.IsValid() && c >= 0 {
+			replaced[c] = append(replaced[c], d)
+			continue
+		}
+		changes = append(changes, d)
+	}
+	changes = append(changes, updates...)
+
+	var creates []Change[V]
+	for n, p := range missing {
+		create := Change[V]{Operation: Create, Index: -1, Prefix: p, Value: desired[p]}
+		if len(replaced[n]) == 0 {
+			creates = append(creates, create)
+			continue
+		}
+		changes = append(changes, create)
+		changes = append(changes, replaced[n]...)
+	}
+	changes = append(changes, creates...)
+
+	return changes, unchanged
+}
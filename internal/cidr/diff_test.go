@@ -0,0 +1,58 @@
+package cidr
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	current := []Entry[string]{
+		{Prefix: netip.MustParsePrefix("10.0.0.0/25"), Value: "allow"},
+		{Prefix: netip.MustParsePrefix("192.0.2.0/24"), Value: "allow"},
+		{Prefix: netip.MustParsePrefix("198.51.100.0/24"), Value: "allow"},
+		{Prefix: netip.MustParsePrefix("203.0.113.0/24"), Value: "block"},
+		{Prefix: netip.MustParsePrefix("203.0.113.0/24"), Value: "block"},
+		{Value: "allow"},
+	}
+	desired := map[netip.Prefix]string{
+		netip.MustParsePrefix("10.0.0.0/24"):     "allow",
+		netip.MustParsePrefix("172.16.0.0/12"):   "block",
+		netip.MustParsePrefix("198.51.100.0/24"): "allow",
+		netip.MustParsePrefix("203.0.113.0/24"):  "allow",
+	}
+
+	type change struct {
+		Operation Operation
+		Index     int
+		Prefix    string
+		Value     string
+	}
+	diff, unchanged := Diff(current, desired)
+	got := make([]change, len(diff))
+	for n, d := range diff {
+		got[n] = change{Operation: d.Operation, Index: d.Index, Value: d.Value}
+		if d.Prefix.IsValid() {
+			got[n].Prefix = d.Prefix.String()
+		}
+	}
+
+	want := []change{
+		{Operation: Delete, Index: 1, Prefix: "192.0.2.0/24"},
+		{Operation: Delete, Index: 4, Prefix: "203.0.113.0/24"},
+		{Operation: Delete, Index: 5},
+		{Operation: Update, Index: 3, Prefix: "203.0.113.0/24", Value: "allow"},
+		{Operation: Create, Index: -1, Prefix: "10.0.0.0/24", Value: "allow"},
+		{Operation: Delete, Index: 0, Prefix: "10.0.0.0/25"},
+		{Operation: Create, Index: -1, Prefix: "172.16.0.0/12", Value: "block"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected changes (-want +got):\n%s", diff)
+	}
+	if unchanged != 1 {
+		t.Errorf("unchanged = %d, want 1", unchanged)
+	}
+}
@@ -0,0 +1,3 @@
+// Package cidr provides helpers used to normalize, collapse and match
+// sets of IP prefixes with longest-prefix-match semantics
+package cidr
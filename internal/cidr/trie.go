@@ -0,0 +1,114 @@
+package cidr
+
+import (
+	"net/netip"
+)
+
+// Trie is a binary prefix trie supporting longest-prefix-match lookups
+// of IPv4 and IPv6 addresses. The zero value is an empty trie.
+type Trie[V any] struct {
+	v4, v6 *node[V]
+	size   int
+}
+
+type node[V any] struct {
+	child  [2]*node[V]
+	prefix netip.Prefix
+	set    bool
+	value  V
+}
+
+// Insert associates v with prefix p, replacing any existing value. p is
+// normalized before insertion.
+func (t *Trie[V]) Insert(p netip.Prefix, v V) {
+	p = Normalize(p)
+	n := t.root(p.Addr(), true)
+	b := p.Addr().As16()
+	offset := bitOffset(p.Addr())
+	for i := range p.Bits() {
+		bit := bitAt(b, offset+i)
+		if n.child[bit] == nil {
+			n.child[bit] = &node[V]{}
+		}
+		n = n.child[bit]
+	}
+	if !n.set {
+		t.size++
+	}
+	n.prefix, n.set, n.value = p, true, v
+}
+
+// Get returns the value stored for exactly prefix p.
+func (t *Trie[V]) Get(p netip.Prefix) (V, bool) {
+	p = Normalize(p)
+	n := t.root(p.Addr(), false)
+	b := p.Addr().As16()
+	offset := bitOffset(p.Addr())
+	for i := 0; n != nil && i < p.Bits(); i++ {
+		n = n.child[bitAt(b, offset+i)]
+	}
+	if n == nil || !n.set {
+		var zero V
+		return zero, false
+	}
+	return n.value, true
+}
+
+// Lookup returns the value of the longest prefix containing addr,
+// along with that prefix. IPv4-mapped IPv6 addresses are matched as
+// IPv4.
+func (t *Trie[V]) Lookup(addr netip.Addr) (V, netip.Prefix, bool) {
+	var (
+		best  *node[V]
+		zero  V
+		addrV = addr.Unmap().WithZone("")
+	)
+	if !addrV.IsValid() {
+		return zero, netip.Prefix{}, false
+	}
+	n := t.root(addrV, false)
+	b := addrV.As16()
+	offset := bitOffset(addrV)
+	for i := 0; n != nil; i++ {
+		if n.set {
+			best = n
+		}
+		if i == addrV.BitLen() {
+			break
+		}
+		n = n.child[bitAt(b, offset+i)]
+	}
+	if best == nil {
+		return zero, netip.Prefix{}, false
+	}
+	return best.value, best.prefix, true
+}
+
+// Len returns the number of prefixes stored in the trie.
+func (t *Trie[V]) Len() int {
+	return t.size
+}
+
+func (t *Trie[V]) root(addr netip.Addr, create bool) *node[V] {
+	r := &t.v6
+	if addr.Is4() {
+		r = &t.v4
+	}
+	if *r == nil && create {
+		*r = &node[V]{}
+	}
+	return *r
+}
+
+// bitOffset returns the position of the first network bit of addr
+// within its 16-byte representation.
+func bitOffset(addr netip.Addr) int {
+	if addr.Is4() {
+		return 96
+	}
+	return 0
+}
+
+func bitAt(b [16]byte, i int) int {
+	return int(b[i/8]>>(7-i%8)) & 1
+}
@@ -0,0 +1,53 @@
+package cidr
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestTrie(t *testing.T) {
+	t.Parallel()
+
+	var trie Trie[string]
+	trie.Insert(netip.MustParsePrefix("0.0.0.0/0"), "default")
+	trie.Insert(netip.MustParsePrefix("10.0.0.0/8"), "ten")
+	trie.Insert(netip.MustParsePrefix("10.1.2.0/24"), "ten-one-two")
+	trie.Insert(netip.MustParsePrefix("10.1.2.3/32"), "host")
+	trie.Insert(netip.MustParsePrefix("2001:db8::/32"), "doc")
+	trie.Insert(netip.MustParsePrefix("10.0.0.0/8"), "ten")
+
+	if trie.Len() != 5 {
+		t.Errorf("expected 5 prefixes, got %d", trie.Len())
+	}
+
+	tests := []struct {
+		addr   string
+		want   string
+		prefix string
+		ok     bool
+	}{
+		{addr: "10.1.2.3", want: "host", prefix: "10.1.2.3/32", ok: true},
+		{addr: "10.1.2.4", want: "ten-one-two", prefix: "10.1.2.0/24", ok: true},
+		{addr: "10.9.9.9", want: "ten", prefix: "10.0.0.0/8", ok: true},
+		{addr: "192.0.2.1", want: "default", prefix: "0.0.0.0/0", ok: true},
+		{addr: "::ffff:10.1.2.3", want: "host", prefix: "10.1.2.3/32", ok: true},
+		{addr: "2001:db8::1", want: "doc", prefix: "2001:db8::/32", ok: true},
+		{addr: "2001:db9::1", ok: false},
+	}
+	for _, tc := range tests {
+		got, prefix, ok := trie.Lookup(netip.MustParseAddr(tc.addr))
+		if ok != tc.ok || got != tc.want || (ok && prefix.String() != tc.prefix) {
+			t.Errorf("Lookup(%s) = %q, %s, %t; want %q, %s, %t", tc.addr, got, prefix, ok, tc.want, tc.prefix, tc.ok)
+		}
+	}
+
+	if _, _, ok := trie.Lookup(netip.Addr{}); ok {
+		t.Error("expected no match for the zero address")
+	}
+	if v, ok := trie.Get(netip.MustParsePrefix("10.1.2.0/24")); !ok || v != "ten-one-two" {
+		t.Errorf("Get returned %q, %t", v, ok)
+	}
+	if _, ok := trie.Get(netip.MustParsePrefix("10.1.0.0/16")); ok {
+		t.Error("expected no exact match for 10.1.0.0/16")
+	}
+}
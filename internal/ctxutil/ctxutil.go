@@ -0,0 +1,30 @@
+package ctxutil
+
+import (
+	"context"
+	"time"
+)
+
+// Send delivers v on ch unless ctx is cancelled first. It reports whether v
+// was delivered.
+func Send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Sleep waits for d unless ctx is cancelled first. It reports whether the
+// full duration elapsed.
+func Sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,3 @@
+// Package ctxutil provides helpers for blocking operations that give up
+// when a context is cancelled
+package ctxutil
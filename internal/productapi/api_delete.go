@@ -1,4 +1,4 @@
-package productcore
+package productapi
 
 import (
 	"context"
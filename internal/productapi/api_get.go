@@ -1,10 +1,9 @@
-package productcore
+package productapi
 
 import (
 	"context"
 
 	"github.com/fastly/go-fastly/v17/fastly"
-	"github.com/fastly/go-fastly/v17/fastly/products"
 )
 
 // GetInput specifies the information needed for the Get()
@@ -18,11 +17,10 @@ type GetInput struct {
 
 // Get implements a product-specific 'get' operation.
 //
-// This function requires a type parameter which is a pointer to an
-// struct which matches the ProductOutput interface, and that type
-// is used to construct, populate, and return the output present in
-// the response body.
-func Get[O products.ProductOutput](ctx context.Context, i *GetInput) (o O, err error) {
+// This function requires a type parameter for the output; that
+// type is used to construct, populate, and return the output present
+// in the response body.
+func Get[O any](ctx context.Context, i *GetInput) (o O, err error) {
 	if i.ServiceID == "" {
 		err = fastly.ErrMissingServiceID
 		return
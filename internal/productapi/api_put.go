@@ -1,10 +1,9 @@
-package productcore
+package productapi
 
 import (
 	"context"
 
 	"github.com/fastly/go-fastly/v17/fastly"
-	"github.com/fastly/go-fastly/v17/fastly/products"
 )
 
 // PutInput specifies the information needed for the Put()
@@ -26,11 +25,10 @@ type PutInput[I any] struct {
 // struct; the input type parameter is used to marshal the input data
 // into the request body (encoded as JSON).
 //
-// It also requires a type parameter which is a pointer to an
-// struct which matches the ProductOutput interface, and that type
-// is used to construct, populate, and return the output present in
-// the response body.
-func Put[O products.ProductOutput, I any](ctx context.Context, i *PutInput[I]) (o O, err error) {
+// It also requires a type parameter for the output; that
+// type is used to construct, populate, and return the output present
+// in the response body.
+func Put[O, I any](ctx context.Context, i *PutInput[I]) (o O, err error) {
 	if i.ServiceID == "" {
 		err = fastly.ErrMissingServiceID
 		return
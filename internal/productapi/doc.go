@@ -0,0 +1,6 @@
+// Package productapi provides the generic HTTP operations used to
+// enable, disable, and configure products on a service. It has no
+// dependency on the products package, so that package can build on
+// it; productcore wraps these operations with the ProductOutput
+// constraint used by the product packages
+package productapi
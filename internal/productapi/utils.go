@@ -1,4 +1,4 @@
-package productcore
+package productapi
 
 import "github.com/fastly/go-fastly/v17/fastly"
 
@@ -0,0 +1,70 @@
+package productcore
+
+import (
+	"context"
+
+	"github.com/fastly/go-fastly/v17/fastly/products"
+	"github.com/fastly/go-fastly/v17/internal/productapi"
+)
+
+// GetInput specifies the information needed for the Get()
+// function to perform the operation.
+type GetInput = productapi.GetInput
+
+// PutInput specifies the information needed for the Put()
+// function to perform the operation.
+type PutInput[I any] = productapi.PutInput[I]
+
+// PatchInput specifies the information needed for the Patch()
+// function to perform the operation.
+type PatchInput[I any] = productapi.PatchInput[I]
+
+// DeleteInput specifies the information needed for the Delete
+// function to perform the operation.
+type DeleteInput = productapi.DeleteInput
+
+// Get implements a product-specific 'get' operation.
+//
+// This function requires a type parameter which is a pointer to an
+// struct which matches the ProductOutput interface, and that type
+// is used to construct, populate, and return the output present in
+// the response body.
+func Get[O products.ProductOutput](ctx context.Context, i *GetInput) (O, error) {
+	return productapi.Get[O](ctx, i)
+}
+
+// Put implements a product-specific 'put' operation.
+//
+// This function requires the same type parameter as the PutInput
+// struct; the input type parameter is used to marshal the input data
+// into the request body (encoded as JSON).
+//
+// It also requires a type parameter which is a pointer to an
+// struct which matches the ProductOutput interface, and that type
+// is used to construct, populate, and return the output present in
+// the response body.
+func Put[O products.ProductOutput, I any](ctx context.Context, i *PutInput[I]) (O, error) {
+	return productapi.Put[O](ctx, i)
+}
+
+// Patch implements a product-specific 'patch' operation.
+//
+// This function requires the same type parameter as the PatchInput
+// struct; the input type parameter is used to marshal the input data
+// into the request body (encoded as JSON).
+//
+// It also requires a type parameter which is a pointer to an
+// struct which matches the ProductOutput interface, and that type
+// is used to construct, populate, and return the output present in
+// the response body.
+func Patch[O products.ProductOutput, I any](ctx context.Context, i *PatchInput[I]) (O, error) {
+	return productapi.Patch[O](ctx, i)
+}
+
+// Delete implements a product-specific 'delete' operation. Since this
+// operation does not accept any input or produce any output (other
+// than a potential error), this function does not have any type
+// parameters.
+func Delete(ctx context.Context, i *DeleteInput) error {
+	return productapi.Delete(ctx, i)
+}